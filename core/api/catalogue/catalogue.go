@@ -0,0 +1,379 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package catalogue reads the templates an installation offers from a directory.
+//
+// A template is described by a template.json file naming its id, title, description, author,
+// category and version, next to its content: a template.docx converted when a document is started,
+// or a document.json package exported from Documize. The directory may hold each template
+// as a sub-directory, or as a .zip file with the same files. A .json file in the directory is read
+// as a document package, described by its "template" field or else by the document it holds.
+//
+// Templates sharing an id are versions of one template, and only the latest is offered.
+package catalogue
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/documize/community/core/log"
+)
+
+const (
+	// FormatDocx is a Word document converted into a new document.
+	FormatDocx = "docx"
+	// FormatPackage is a document package copied into a new document.
+	FormatPackage = "package"
+)
+
+// maxSize caps what is read from a template, which keeps a malformed zip from exhausting memory.
+const maxSize = 50 << 20
+
+// ErrNotFound is returned when the catalogue has no template with the id asked for.
+var ErrNotFound = errors.New("template not found")
+
+// Entry is a template in the catalogue.
+type Entry struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Category    string `json:"category"`
+	Version     string `json:"version"`
+	Format      string `json:"-"`
+	path        string // the sub-directory, zip or json file holding the template
+}
+
+// Load returns the latest version of each template in the directory, by category then title.
+// Templates that cannot be read are logged and left out.
+func Load(dir string) (entries []Entry, err error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	latest := make(map[string]Entry)
+
+	for _, f := range files {
+		e, err := cached(filepath.Join(dir, f.Name()), f)
+		if err != nil {
+			log.Error(fmt.Sprintf("unable to read template %s", f.Name()), err)
+			continue
+		}
+		if len(e.ID) == 0 {
+			continue // not a template
+		}
+
+		if l, ok := latest[e.ID]; !ok || Compare(e.Version, l.Version) > 0 {
+			latest[e.ID] = e
+		}
+	}
+
+	entries = []Entry{}
+	for _, e := range latest {
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Category != entries[j].Category {
+			return entries[i].Category < entries[j].Category
+		}
+		return entries[i].Title < entries[j].Title
+	})
+
+	return
+}
+
+// cache holds what was read from each template, so unchanged templates are not read again on every Load.
+var cache = struct {
+	sync.Mutex
+	entries map[string]cacheEntry
+}{entries: make(map[string]cacheEntry)}
+
+type cacheEntry struct {
+	stamp string
+	entry Entry
+}
+
+// cached is read, reusing the last result while the template is unchanged.
+func cached(name string, f os.FileInfo) (e Entry, err error) {
+	st := stamp(name, f)
+
+	cache.Lock()
+	c, ok := cache.entries[name]
+	cache.Unlock()
+	if ok && c.stamp == st {
+		return c.entry, nil
+	}
+
+	if e, err = read(name, f); err != nil {
+		return
+	}
+
+	cache.Lock()
+	cache.entries[name] = cacheEntry{stamp: st, entry: e}
+	cache.Unlock()
+
+	return
+}
+
+// stamp identifies a version of a template by modification time and size.
+// A sub-directory changes when files are added or removed, but its template.json is stamped too
+// as editing it leaves the directory untouched.
+func stamp(name string, f os.FileInfo) string {
+	st := fmt.Sprintf("%d:%d", f.ModTime().UnixNano(), f.Size())
+	if f.IsDir() {
+		if c, err := os.Stat(filepath.Join(name, "template.json")); err == nil {
+			st += fmt.Sprintf(":%d:%d", c.ModTime().UnixNano(), c.Size())
+		}
+	}
+
+	return st
+}
+
+// Find returns the latest version of a template in the directory.
+func Find(dir, id string) (e Entry, err error) {
+	entries, err := Load(dir)
+	if err != nil {
+		return
+	}
+
+	for _, e = range entries {
+		if e.ID == id {
+			return
+		}
+	}
+
+	return Entry{}, ErrNotFound
+}
+
+// Content returns the Word document or document package of the template.
+func (e Entry) Content() (data []byte, err error) {
+	name := "template.docx"
+	if e.Format == FormatPackage {
+		name = "document.json"
+	}
+
+	switch {
+	case strings.EqualFold(filepath.Ext(e.path), ".json"):
+		return readFile(e.path)
+	case strings.EqualFold(filepath.Ext(e.path), ".zip"):
+		return readZip(e.path, name)
+	default:
+		return readFile(filepath.Join(e.path, name))
+	}
+}
+
+// Compare orders versions such as 1.2 and 1.10 by their numeric parts, returning -1, 0 or 1.
+func Compare(a, b string) int {
+	split := func(r rune) bool { return r == '.' || r == '-' }
+	x, y := strings.FieldsFunc(a, split), strings.FieldsFunc(b, split)
+
+	for i := 0; i < len(x) && i < len(y); i++ {
+		m, errm := strconv.Atoi(x[i])
+		n, errn := strconv.Atoi(y[i])
+
+		switch {
+		case errm == nil && errn == nil && m != n:
+			if m < n {
+				return -1
+			}
+			return 1
+		case (errm != nil || errn != nil) && x[i] != y[i]:
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(x) < len(y):
+		return -1
+	case len(x) > len(y):
+		return 1
+	}
+
+	return 0
+}
+
+// config is the template.json describing a template.
+type config struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Category    string `json:"category"`
+	Version     string `json:"version"`
+}
+
+// read describes the template held by a directory entry, returning an empty entry for anything else.
+func read(name string, f os.FileInfo) (e Entry, err error) {
+	var c config
+	var format string
+
+	switch {
+	case f.IsDir():
+		if _, err = os.Stat(filepath.Join(name, "template.json")); os.IsNotExist(err) {
+			return Entry{}, nil
+		}
+		if c, err = readConfig(readFile(filepath.Join(name, "template.json"))); err != nil {
+			return
+		}
+		format = FormatDocx
+		if _, err = os.Stat(filepath.Join(name, "template.docx")); os.IsNotExist(err) {
+			format = FormatPackage
+		}
+		err = nil
+
+	case strings.EqualFold(filepath.Ext(name), ".zip"):
+		if c, err = readConfig(readZip(name, "template.json")); err != nil {
+			return
+		}
+		format = FormatPackage
+		if ok, _ := zipHas(name, "template.docx"); ok {
+			format = FormatDocx
+		}
+
+	case strings.EqualFold(filepath.Ext(name), ".json"):
+		if c, err = readPackage(name); err != nil {
+			return
+		}
+		format = FormatPackage
+
+	default:
+		return
+	}
+
+	if len(c.ID) == 0 {
+		return Entry{}, errors.New("template has no id")
+	}
+
+	return Entry{ID: c.ID, Title: c.Title, Description: c.Description, Author: c.Author,
+		Category: c.Category, Version: c.Version, Format: format, path: name}, nil
+}
+
+func readConfig(data []byte, err error) (c config, e error) {
+	if err != nil {
+		return c, err
+	}
+
+	e = json.Unmarshal(data, &c)
+	c.ID = strings.TrimSpace(c.ID)
+
+	return
+}
+
+// readPackage describes a document package by its template field,
+// falling back on the file name and the title and excerpt of the document.
+func readPackage(name string) (c config, err error) {
+	data, err := readFile(name)
+	if err != nil {
+		return
+	}
+
+	var pkg struct {
+		Template config `json:"template"`
+		Document struct {
+			Title   string `json:"name"`
+			Excerpt string `json:"excerpt"`
+		} `json:"document"`
+	}
+
+	if err = json.Unmarshal(data, &pkg); err != nil {
+		return
+	}
+
+	c = pkg.Template
+	if len(c.ID) == 0 {
+		c.ID = strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	}
+	if len(c.Title) == 0 {
+		c.Title = pkg.Document.Title
+	}
+	if len(c.Description) == 0 {
+		c.Description = pkg.Document.Excerpt
+	}
+
+	return
+}
+
+func readFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return limitedRead(f)
+}
+
+// readZip reads a file held anywhere within a zip.
+func readZip(name, file string) ([]byte, error) {
+	z, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if path.Base(f.Name) != file {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		return limitedRead(r)
+	}
+
+	return nil, fmt.Errorf("%s has no %s", filepath.Base(name), file)
+}
+
+// zipHas reports whether the zip holds the file, without reading it.
+func zipHas(name, file string) (bool, error) {
+	z, err := zip.OpenReader(name)
+	if err != nil {
+		return false, err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if path.Base(f.Name) == file {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func limitedRead(r io.Reader) ([]byte, error) {
+	data, err := ioutil.ReadAll(&io.LimitedReader{R: r, N: maxSize + 1})
+	if err == nil && len(data) > maxSize {
+		err = errors.New("template is too large")
+	}
+
+	return data, err
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package catalogue
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.2", "1.10", -1},
+		{"2", "1.9", 1},
+		{"1.0", "1.0", 0},
+		{"1.0", "1.0.1", -1},
+		{"", "1", -1},
+		{"1.0-beta", "1.0-rc", -1},
+	} {
+		if got := Compare(c.a, c.b); got != c.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalogue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		name = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("runbook/template.json", `{"id":"runbook","title":"Runbook","category":"Operations","version":"1.2"}`)
+	write("runbook/template.docx", "docx 1.2")
+	write("incident.json", `{"version":1,"document":{"id":"x","name":"Incident review","excerpt":"After an outage"}}`)
+	write("notes.txt", "not a template")
+	write("broken/template.json", `{`)
+
+	f, err := os.Create(filepath.Join(dir, "runbook-1.10.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"runbook/template.json": `{"id":"runbook","title":"Runbook","category":"Operations","version":"1.10"}`,
+		"runbook/document.json": `{"version":1}`,
+	} {
+		w, _ := z.Create(name)
+		w.Write([]byte(content))
+	}
+	z.Close()
+	f.Close()
+
+	entries, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 templates, got %+v", entries)
+	}
+
+	if e := entries[0]; e.ID != "incident" || e.Title != "Incident review" || e.Description != "After an outage" || e.Format != FormatPackage {
+		t.Errorf("unexpected package template %+v", e)
+	}
+
+	e := entries[1]
+	if e.Version != "1.10" || e.Category != "Operations" || e.Format != FormatPackage {
+		t.Errorf("expected the zipped version to be the latest, got %+v", e)
+	}
+
+	data, err := e.Content()
+	if err != nil || string(data) != `{"version":1}` {
+		t.Errorf("unexpected content %q %v", data, err)
+	}
+
+	if _, err = Find(dir, "missing"); err != ErrNotFound {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestLoadReadsChangedTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "catalogue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := os.Create(filepath.Join(dir, "runbook.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"template.json": `{"id":"runbook","title":"Runbook","version":"1"}`,
+		"template.docx": "docx",
+	} {
+		w, _ := z.Create(name)
+		w.Write([]byte(content))
+	}
+	z.Close()
+	f.Close()
+
+	name := filepath.Join(dir, "incident.json")
+	if err = ioutil.WriteFile(name, []byte(`{"version":1,"document":{"id":"x","name":"Incident"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := Find(dir, "runbook")
+	if err != nil || e.Format != FormatDocx {
+		t.Fatalf("expected a zipped Word template, got %+v %v", e, err)
+	}
+	if e, err = Find(dir, "incident"); err != nil || e.Title != "Incident" {
+		t.Fatalf("unexpected template %+v %v", e, err)
+	}
+
+	if err = ioutil.WriteFile(name, []byte(`{"version":1,"document":{"id":"x","name":"Incident review"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err = os.Chtimes(name, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if e, err = Find(dir, "incident"); err != nil || e.Title != "Incident review" {
+		t.Errorf("expected the changed template to be read again, got %+v %v", e, err)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package webpage fetches a public or intranet web page and extracts its main article content,
+// ready to be split into Documize pages by the html converter.
+package webpage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/api/convert/html"
+	api "github.com/documize/community/core/convapi"
+	"github.com/documize/community/core/stringutil"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// MaxSize is the largest web page, in bytes, that will be fetched.
+var MaxSize int64 = 5 * 1024 * 1024
+
+// Timeout is how long we wait for the remote server to deliver the page.
+var Timeout = 30 * time.Second
+
+// ErrTooLarge is returned when the web page exceeds MaxSize.
+var ErrTooLarge = errors.New("web page exceeds maximum import size")
+
+// Fetch retrieves the web page at the given URL, enforcing the size and time limits.
+func Fetch(pageURL string) (page []byte, err error) {
+	u, err := url.Parse(strings.TrimSpace(pageURL))
+	if err != nil {
+		return
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		err = fmt.Errorf("unsupported URL scheme '%s'", u.Scheme)
+		return
+	}
+
+	client := &http.Client{Timeout: Timeout}
+	res, err := client.Get(u.String())
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("web page returned status %d", res.StatusCode)
+		return
+	}
+
+	ct := strings.ToLower(res.Header.Get("Content-Type"))
+	if ct != "" && !strings.Contains(ct, "html") {
+		err = fmt.Errorf("web page has unsupported content type '%s'", ct)
+		return
+	}
+
+	page, err = ioutil.ReadAll(io.LimitReader(res.Body, MaxSize+1))
+	if err != nil {
+		return
+	}
+	if int64(len(page)) > MaxSize {
+		err = ErrTooLarge
+		page = nil
+	}
+
+	return
+}
+
+// Extract finds the main article content of a web page, removing navigation, scripts and other clutter.
+// Relative links and images are made absolute using the given base URL.
+func Extract(base string, page []byte) (title string, article []byte, err error) {
+	doc, err := nethtml.Parse(bytes.NewReader(page))
+	if err != nil {
+		return
+	}
+
+	baseURL, _ := url.Parse(base)
+
+	if t := findFirst(doc, func(n *nethtml.Node) bool { return n.DataAtom == atom.Title }); t != nil {
+		title = strings.TrimSpace(stringutil.NodeText(t))
+	}
+
+	content := findFirst(doc, func(n *nethtml.Node) bool { return n.DataAtom == atom.Article })
+	if content == nil {
+		content = findFirst(doc, func(n *nethtml.Node) bool {
+			return n.Data == "main" || stringutil.NodeAttr(n, "role") == "main"
+		})
+	}
+	if content == nil {
+		content = findFirst(doc, func(n *nethtml.Node) bool { return n.DataAtom == atom.Body })
+	}
+	if content == nil {
+		err = errors.New("no content found in web page")
+		return
+	}
+
+	clean(content, baseURL)
+
+	if len(title) == 0 {
+		if h := findFirst(content, func(n *nethtml.Node) bool { return n.DataAtom == atom.H1 }); h != nil {
+			title = strings.TrimSpace(stringutil.NodeText(h))
+		}
+	}
+
+	var b bytes.Buffer
+	for c := content.FirstChild; c != nil; c = c.NextSibling {
+		if err = nethtml.Render(&b, c); err != nil {
+			return
+		}
+	}
+	article = b.Bytes()
+
+	return
+}
+
+// Convert fetches the web page and splits the extracted article into pages,
+// with the first page titled using the web page title.
+func Convert(pageURL string) (*api.DocumentConversionResponse, error) {
+	page, err := Fetch(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	title, article, err := Extract(pageURL, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return Split(pageURL, title, article)
+}
+
+// Split runs extracted article content through the standard HTML page splitter.
+func Split(pageURL, title string, article []byte) (*api.DocumentConversionResponse, error) {
+	if len(title) == 0 {
+		title = pageURL
+	}
+
+	req := &api.DocumentConversionRequest{Filename: pageURL}
+	res := &api.DocumentConversionResponse{}
+	res.PagesHTML = append([]byte("<html><head></head><body>"), article...)
+	res.PagesHTML = append(res.PagesHTML, []byte("</body></html>")...)
+
+	err := html.SplitIfHTML(req, res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != "" {
+		return nil, errors.New(res.Err)
+	}
+	if len(res.Pages) == 0 {
+		return nil, errors.New("no pages in web page")
+	}
+
+	for i := range res.Pages {
+		res.Pages[i].Title = strings.TrimSpace(res.Pages[i].Title)
+	}
+	res.Pages[0].Title = title
+
+	if text, err := stringutil.HTML(string(article)).Text(false); err == nil {
+		words := strings.Fields(strings.Replace(text, "\u200B", "", -1)) // strip zero-width spaces
+		if len(words) > 50 {
+			words = words[:50]
+		}
+		res.Excerpt = strings.Join(words, " ")
+	}
+
+	return res, nil
+}
+
+// clutter holds the elements that never form part of the main article content.
+var clutter = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Nav:      true,
+	atom.Header:   true,
+	atom.Footer:   true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+func clean(n *nethtml.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type == nethtml.CommentNode:
+			n.RemoveChild(c)
+		case c.Type == nethtml.ElementNode && clutter[c.DataAtom]:
+			n.RemoveChild(c)
+		case c.Type == nethtml.ElementNode:
+			resolve(c, base)
+			clean(c, base)
+		}
+		c = next
+	}
+}
+
+// resolve makes link and image references absolute and drops inline event handlers.
+func resolve(n *nethtml.Node, base *url.URL) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		key := strings.ToLower(a.Key)
+		if strings.HasPrefix(key, "on") {
+			continue
+		}
+		if key == "href" || key == "src" {
+			ref, err := url.Parse(strings.TrimSpace(a.Val))
+			if err != nil || ref.Scheme == "javascript" {
+				continue
+			}
+			if base != nil {
+				a.Val = base.ResolveReference(ref).String()
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	n.Attr = attrs
+}
+
+func findFirst(n *nethtml.Node, match func(*nethtml.Node) bool) *nethtml.Node {
+	if n.Type == nethtml.ElementNode && match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if f := findFirst(c, match); f != nil {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package webpage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testPage = `<!DOCTYPE html>
+<html>
+<head><title>Release Notes</title><script>alert("x")</script></head>
+<body>
+<nav><a href="/home">Home</a></nav>
+<article>
+<h1>Version 2</h1>
+<p onclick="steal()">See the <a href="guide.html">guide</a>.</p>
+<img src="/img/logo.png">
+<h2>Fixes</h2>
+<p>Many bugs were fixed.</p>
+<aside>Advert</aside>
+</article>
+<footer>Copyright</footer>
+</body>
+</html>`
+
+func TestExtract(t *testing.T) {
+	title, article, err := Extract("https://example.com/docs/notes.html", []byte(testPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Release Notes" {
+		t.Errorf("wrong title: %s", title)
+	}
+
+	a := string(article)
+	for _, bad := range []string{"Home", "Advert", "Copyright", "alert", "onclick"} {
+		if strings.Contains(a, bad) {
+			t.Errorf("article should not contain %q: %s", bad, a)
+		}
+	}
+	for _, good := range []string{
+		`href="https://example.com/docs/guide.html"`,
+		`src="https://example.com/img/logo.png"`,
+		"Many bugs were fixed."} {
+		if !strings.Contains(a, good) {
+			t.Errorf("article should contain %q: %s", good, a)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(testPage))
+	}))
+	defer ts.Close()
+
+	res, err := Convert(ts.URL + "/notes.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Pages) != 3 {
+		t.Fatalf("expected 3 pages, got %d", len(res.Pages))
+	}
+	if res.Pages[0].Title != "Release Notes" || res.Pages[1].Title != "Version 2" || res.Pages[2].Title != "Fixes" {
+		t.Errorf("unexpected page titles %q %q %q", res.Pages[0].Title, res.Pages[1].Title, res.Pages[2].Title)
+	}
+	if res.Pages[2].Level != 3 {
+		t.Errorf("expected <h2> at level 3, got %d", res.Pages[2].Level)
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(strings.Repeat("x", 100)))
+		}
+	}))
+	defer ts.Close()
+
+	defer func(m int64) { MaxSize = m }(MaxSize)
+	MaxSize = 50
+
+	if _, err := Fetch(ts.URL + "/big"); err != ErrTooLarge {
+		t.Errorf("expected ErrTooLarge, got %v", err)
+	}
+	if _, err := Fetch(ts.URL + "/pdf"); err == nil {
+		t.Error("expected content type error")
+	}
+	if _, err := Fetch(ts.URL + "/missing"); err == nil {
+		t.Error("expected status error")
+	}
+	if _, err := Fetch("file:///etc/passwd"); err == nil {
+		t.Error("expected scheme error")
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package endpoint
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/api/util"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+	"github.com/documize/community/core/uniqueid"
+	"github.com/gorilla/mux"
+)
+
+// GetConnections returns the shared connections of the organization, without their secrets.
+// Editors use them to pick one for a section, given as ?section=, administrators to manage them.
+func GetConnections(w http.ResponseWriter, r *http.Request) {
+	method := "GetConnections"
+	p := request.GetPersister(r)
+
+	if !p.Context.Editor && !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	connections, err := p.GetConnections(r.URL.Query().Get("section"))
+
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if len(connections) == 0 {
+		connections = []entity.Connection{}
+	}
+
+	json, err := json.Marshal(connections)
+
+	if err != nil {
+		writeJSONMarshalError(w, method, "connection", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
+// readConnection decodes a connection posted by an administrator, the secrets must be a JSON object when given.
+func readConnection(w http.ResponseWriter, r *http.Request, method string) (c entity.Connection, ok bool) {
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	err = json.Unmarshal(body, &c)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	c.Name = strings.TrimSpace(c.Name)
+	c.Secrets = strings.TrimSpace(c.Secrets)
+
+	if len(c.Name) == 0 {
+		writeMissingDataError(w, method, "name")
+		return
+	}
+	if len(c.Name) > 100 {
+		c.Name = c.Name[0:100]
+	}
+
+	if len(c.Secrets) > 0 {
+		var m map[string]interface{}
+		if json.Unmarshal([]byte(c.Secrets), &m) != nil {
+			writeBadRequestError(w, method, "secrets must be a JSON object")
+			return
+		}
+	}
+
+	return c, true
+}
+
+// AddConnection saves a new shared connection for a section type.
+func AddConnection(w http.ResponseWriter, r *http.Request) {
+	method := "AddConnection"
+	p := request.GetPersister(r)
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	c, ok := readConnection(w, r, method)
+	if !ok {
+		return
+	}
+
+	if _, exists := provider.List()[c.ContentType]; !exists {
+		writeBadRequestError(w, method, "unknown section type "+c.ContentType)
+		return
+	}
+
+	if len(c.Secrets) == 0 {
+		writeMissingDataError(w, method, "secrets")
+		return
+	}
+
+	c.RefID = uniqueid.Generate()
+	c.OrgID = p.Context.OrgID
+	c.UserID = p.Context.UserID
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.AddConnection(c)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	p.RecordEvent(entity.EventTypeConnectionAdd)
+	p.LogConnection(c.RefID, "", "add")
+
+	writeConnection(w, p, method, c.RefID)
+}
+
+// UpdateConnection renames a shared connection, and replaces its secrets when new ones are given.
+func UpdateConnection(w http.ResponseWriter, r *http.Request) {
+	method := "UpdateConnection"
+	p := request.GetPersister(r)
+	connectionID := mux.Vars(r)["connectionID"]
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	c, ok := readConnection(w, r, method)
+	if !ok {
+		return
+	}
+
+	c.RefID = connectionID
+	c.UserID = p.Context.UserID
+
+	if _, err := p.GetConnection(connectionID); err != nil {
+		writeNotFoundError(w, method, connectionID)
+		return
+	}
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.UpdateConnection(c)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	p.RecordEvent(entity.EventTypeConnectionUpdate)
+	if len(c.Secrets) > 0 {
+		p.LogConnection(c.RefID, "", "update")
+	}
+
+	writeConnection(w, p, method, c.RefID)
+}
+
+// DeleteConnection removes a shared connection, sections that used it go back to the secrets of their owner.
+func DeleteConnection(w http.ResponseWriter, r *http.Request) {
+	method := "DeleteConnection"
+	p := request.GetPersister(r)
+	connectionID := mux.Vars(r)["connectionID"]
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	_, err = p.DeleteConnection(connectionID)
+
+	if err != nil && err != sql.ErrNoRows {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	p.RecordEvent(entity.EventTypeConnectionDelete)
+
+	util.WriteSuccessEmptyJSON(w)
+}
+
+// GetConnectionLog returns who used a shared connection, and when.
+func GetConnectionLog(w http.ResponseWriter, r *http.Request) {
+	method := "GetConnectionLog"
+	p := request.GetPersister(r)
+	connectionID := mux.Vars(r)["connectionID"]
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	uses, err := p.GetConnectionLog(connectionID)
+
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if len(uses) == 0 {
+		uses = []entity.ConnectionLog{}
+	}
+
+	json, err := json.Marshal(uses)
+
+	if err != nil {
+		writeJSONMarshalError(w, method, "connection log", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
+func writeConnection(w http.ResponseWriter, p request.Persister, method, id string) {
+	c, err := p.GetConnection(id)
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	util.WriteJSON(w, c)
+}
+
+// useConnection has the section use the shared connection, when it is one of the organization's for that section type,
+// and records the use. It reports false when the connection cannot be used.
+func useConnection(p request.Persister, ctx *provider.Context, connectionID, contentType, pageID, action string) bool {
+	if len(connectionID) == 0 {
+		return true
+	}
+
+	err := request.CheckConnection(p.Context.OrgID, connectionID, contentType)
+
+	if err != nil {
+		log.Error("unable to use connection "+connectionID, err)
+		return false
+	}
+
+	ctx.UseConnection(connectionID)
+	p.LogConnection(connectionID, pageID, action)
+
+	return true
+}
@@ -18,15 +18,18 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
 
+	"github.com/documize/community/core/api/convert/webpage"
 	"github.com/documize/community/core/api/endpoint/models"
 	"github.com/documize/community/core/api/entity"
 	"github.com/documize/community/core/api/request"
 	"github.com/documize/community/core/api/store"
 	api "github.com/documize/community/core/convapi"
 	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/streamutil"
 	"github.com/documize/community/core/uniqueid"
 	"github.com/gorilla/mux"
 	uuid "github.com/nu7hatch/gouuid"
@@ -46,6 +49,62 @@ func UploadConvertDocument(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// ImportWebPage is an endpoint that fetches a web page by URL and converts its main content into a new document.
+func ImportWebPage(w http.ResponseWriter, r *http.Request) {
+	method := "ImportWebPage"
+	p := request.GetPersister(r)
+	params := mux.Vars(r)
+	folderID := params["folderID"]
+
+	if !p.CanUploadDocument(folderID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	defer streamutil.Close(r.Body)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	model := models.WebPageImportModel{}
+	err = json.Unmarshal(body, &model)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	model.URL = strings.TrimSpace(model.URL)
+	if len(model.URL) == 0 {
+		writeMissingDataError(w, method, "url")
+		return
+	}
+
+	fileResult, err := webpage.Convert(model.URL)
+	if err != nil {
+		writeBadRequestError(w, method, err.Error())
+		return
+	}
+
+	log.Info(fmt.Sprintf("Org %s (%s) [Imported] %s", p.Context.OrgName, p.Context.OrgID, model.URL))
+
+	// the source URL is recorded as the document location
+	newDocument, err := processDocument(p, model.URL, "", folderID, fileResult)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	json, err := json.Marshal(newDocument)
+	if err != nil {
+		writeJSONMarshalError(w, method, "conversion", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
 func uploadDocument(w http.ResponseWriter, r *http.Request) (string, string, string) {
 	method := "uploadDocument"
 	p := request.GetPersister(r)
@@ -14,13 +14,13 @@ package endpoint
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/documize/community/core/api/entity"
-	"github.com/documize/community/core/api/plugins"
+	"github.com/documize/community/core/api/export"
 	"github.com/documize/community/core/api/request"
 	"github.com/documize/community/core/api/store"
 	"github.com/documize/community/core/api/util"
@@ -310,7 +310,7 @@ func DeleteDocument(w http.ResponseWriter, r *http.Request) {
 	writeSuccessEmptyJSON(w)
 }
 
-// GetDocumentAsDocx returns a Word document.
+// GetDocumentAsDocx returns a Word document, or the export format given by the format query parameter.
 func GetDocumentAsDocx(w http.ResponseWriter, r *http.Request) {
 	method := "GetDocumentAsDocx"
 	p := request.GetPersister(r)
@@ -347,39 +347,56 @@ func GetDocumentAsDocx(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	xtn := "html"
-	actions, err := plugins.Lib.Actions("Export")
-	if err == nil {
-		for _, x := range actions {
-			if x == "docx" { // only actually export a docx if we have the plugin
-				xtn = x
-				break
-			}
-		}
+	xtn := strings.ToLower(r.URL.Query().Get("format"))
+	if len(xtn) == 0 {
+		xtn = "docx"
+	}
+
+	if !canExportAs(xtn) {
+		writeBadRequestError(w, method, "unsupported export format "+xtn)
+		return
 	}
 
-	estLen := 0
-	for _, page := range pages {
-		estLen += len(page.Title) + len(page.Body)
+	images, files := exportEmbeds(xtn)
+
+	var attachments []entity.Attachment
+	if images {
+		attachments, err = p.GetAttachmentsWithData(documentID)
+	} else {
+		attachments, err = p.GetAttachments(documentID)
 	}
-	html := make([]byte, 0, estLen*2) // should be far bigger than we need
-	html = append(html, []byte("<html><head></head><body>")...)
-	for _, page := range pages {
-		html = append(html, []byte(fmt.Sprintf("<h%d>", page.Level))...)
-		html = append(html, stringutil.EscapeHTMLcomplexCharsByte([]byte(page.Title))...)
-		html = append(html, []byte(fmt.Sprintf("</h%d>", page.Level))...)
-		html = append(html, stringutil.EscapeHTMLcomplexCharsByte([]byte(page.Body))...)
+
+	if err != nil && err != sql.ErrNoRows {
+		writeServerError(w, method, err)
+		return
 	}
-	html = append(html, []byte("</body></html>")...)
 
-	export, err := store.ExportAs(xtn, string(html))
-	log.Error("store.ExportAs()", err)
+	slug := stringutil.MakeSlug(document.Title)
+	d := export.Document{Document: document, Pages: pages, Attachments: attachments}
+	exportMetadata(p, &d)
 
-	w.Header().Set("Content-Disposition", "attachment; filename="+stringutil.MakeSlug(document.Title)+"."+xtn)
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(export.File)))
+	var resolve export.Resolver
+	if images {
+		resolve = embedAttachments(nil, attachments, files)
+	} else {
+		// the download holds the document alone, so attachment links keep pointing at this server
+		resolve = func(l export.Link) string {
+			if len(l.AttachmentID) > 0 && strings.HasPrefix(l.URL, "/") {
+				return p.Context.GetAppURL(strings.TrimPrefix(l.URL, "/"))
+			}
+			return l.URL
+		}
+	}
+
+	html := d.HTML(resolve)
+
+	file, err := store.ExportAs(xtn, string(html))
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
 
-	writeSuccessBytes(w, export.File)
+	writeExport(w, slug+"."+exportExtension(xtn, file), file.File)
 }
 
 // UpdateDocument updates an existing document using the
@@ -0,0 +1,509 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package endpoint
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/export"
+	"github.com/documize/community/core/api/export/site"
+	"github.com/documize/community/core/api/plugins"
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/api/store"
+	api "github.com/documize/community/core/convapi"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/table"
+	"github.com/documize/community/core/stringutil"
+	"github.com/gorilla/mux"
+)
+
+// ExportFolder is an endpoint that exports every document in a folder,
+// returning a zip archive containing one file per document plus their attachments.
+// Book formats, such as epub, instead return a single file holding every document.
+func ExportFolder(w http.ResponseWriter, r *http.Request) {
+	method := "ExportFolder"
+	p := request.GetPersister(r)
+
+	params := mux.Vars(r)
+	folderID := params["folderID"]
+
+	if len(folderID) == 0 {
+		writeMissingDataError(w, method, "folderID")
+		return
+	}
+
+	xtn := strings.ToLower(r.URL.Query().Get("format"))
+	if len(xtn) == 0 {
+		xtn = "md"
+	}
+
+	if !canExportAs(xtn) {
+		writeBadRequestError(w, method, "unsupported export format "+xtn)
+		return
+	}
+
+	if !p.CanViewFolder(folderID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	folder, err := p.GetLabel(folderID)
+
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, folderID)
+		return
+	}
+
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	docs, err := getFolderExport(p, folder)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	names := export.Names{}
+
+	if exportAsBook(xtn) {
+		ids := make(map[string]bool)
+		for _, d := range docs {
+			ids[d.Document.RefID] = true
+		}
+
+		book := export.Book(folder.Name, docs, func(d export.Document) export.Resolver {
+			return embedAttachments(bookResolver(ids), d.Attachments, true)
+		})
+
+		file, err := store.ExportAs(xtn, string(book))
+		if err != nil {
+			writeServerError(w, method, err)
+			return
+		}
+
+		log.Info(fmt.Sprintf("Org %s (%s) [Exported] space %s as %s", p.Context.OrgName, p.Context.OrgID, folder.Name, xtn))
+
+		writeExport(w, names.Unique(folder.Name)+"."+exportExtension(xtn, file), file.File)
+		return
+	}
+
+	// name every document up-front so that content links between them can be resolved
+	docPaths := make(map[string]string)
+	links := make(map[string]string)
+	for _, d := range docs {
+		docPaths[d.Document.RefID] = names.Unique(d.Document.Title)
+		links[d.Document.RefID] = docPaths[d.Document.RefID] + "." + exportFileExtension(xtn)
+	}
+
+	var files []export.File
+
+	for _, d := range docs {
+		name := docPaths[d.Document.RefID]
+		attachments := export.AttachmentPaths(name, d.Attachments)
+
+		resolve := exportResolver(xtn, attachments, links)
+		if images, files := exportEmbeds(xtn); images {
+			resolve = embedAttachments(resolve, d.Attachments, files)
+		}
+
+		file, err := store.ExportAs(xtn, string(d.HTML(resolve)))
+		if err != nil {
+			writeServerError(w, method, err)
+			return
+		}
+
+		files = append(files, export.File{Path: name + "." + exportExtension(xtn, file), Data: file.File})
+
+		for _, a := range d.Attachments {
+			files = append(files, export.File{Path: attachments[a.RefID], Data: a.Data})
+		}
+	}
+
+	archive, err := export.Zip(files)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	log.Info(fmt.Sprintf("Org %s (%s) [Exported] space %s as %s", p.Context.OrgName, p.Context.OrgID, folder.Name, xtn))
+
+	writeExport(w, names.Unique(folder.Name)+".zip", archive)
+}
+
+// ExportFolderSite is an endpoint that exports a folder as a static web site,
+// returning a zip archive holding an index page, one HTML file per document, a shared stylesheet and attachments.
+func ExportFolderSite(w http.ResponseWriter, r *http.Request) {
+	method := "ExportFolderSite"
+	p := request.GetPersister(r)
+
+	params := mux.Vars(r)
+	folderID := params["folderID"]
+
+	if len(folderID) == 0 {
+		writeMissingDataError(w, method, "folderID")
+		return
+	}
+
+	if !p.CanViewFolder(folderID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	folder, err := p.GetLabel(folderID)
+
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, folderID)
+		return
+	}
+
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	docs, err := getFolderExport(p, folder)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	// the index page takes the name "index" so documents cannot use it
+	names := export.Names{"index": true}
+	links := make(map[string]string)
+	for _, d := range docs {
+		links[d.Document.RefID] = names.Unique(d.Document.Title) + ".html"
+	}
+
+	var pages []site.Document
+	var attachments []export.File
+
+	for _, d := range docs {
+		name := strings.TrimSuffix(links[d.Document.RefID], ".html")
+		paths := export.AttachmentPaths(name, d.Attachments)
+
+		outbound, err := p.GetDocumentOutboundLinks(d.Document.RefID)
+		if err != nil && err != sql.ErrNoRows {
+			writeServerError(w, method, err)
+			return
+		}
+
+		pages = append(pages, site.Document{Document: d, Name: name, Resolve: siteResolver(paths, links, outbound)})
+
+		for _, a := range d.Attachments {
+			attachments = append(attachments, export.File{Path: paths[a.RefID], Data: a.Data})
+		}
+	}
+
+	files, err := site.Build(folder.Name, pages)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	archive, err := export.Zip(append(files, attachments...))
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	log.Info(fmt.Sprintf("Org %s (%s) [Exported] space %s as site", p.Context.OrgName, p.Context.OrgID, folder.Name))
+
+	writeExport(w, stringutil.MakeSlug(folder.Name)+"-site.zip", archive)
+}
+
+// siteResolver rewrites attachment and content links into relative hrefs within the static site,
+// using the recorded outbound links to find link targets. Links to content outside of the site are removed.
+func siteResolver(attachments, documents map[string]string, outbound []entity.Link) export.Resolver {
+	recorded := make(map[string]entity.Link)
+	for _, l := range outbound {
+		recorded[l.RefID] = l
+	}
+
+	return func(l export.Link) string {
+		if p, ok := attachments[l.AttachmentID]; ok {
+			return p
+		}
+
+		if o, ok := recorded[l.LinkID]; ok {
+			if o.Orphan {
+				return "#"
+			}
+			l.TargetDocumentID, l.TargetID = o.TargetDocumentID, o.TargetID
+		}
+
+		switch l.LinkType {
+		case "document":
+			if p, ok := documents[l.TargetDocumentID]; ok {
+				return p
+			}
+			return "#"
+		case "section", "tab":
+			if p, ok := documents[l.TargetDocumentID]; ok {
+				return p + "#" + export.PageAnchor(l.TargetID)
+			}
+			return "#"
+		}
+
+		return l.URL
+	}
+}
+
+// getFolderExport loads the documents, pages and attachments (with data) for the folder.
+func getFolderExport(p request.Persister, folder entity.Label) (docs []export.Document, err error) {
+	documents, err := p.GetDocumentsByFolder(folder.RefID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	authors := make(map[string]string)
+
+	for _, document := range documents {
+		var d export.Document
+		d.Document = document
+		d.Space = folder.Name
+		d.Author = exportAuthor(p, document.UserID, authors)
+
+		d.Pages, err = p.GetPages(document.RefID)
+		if err != nil && err != sql.ErrNoRows {
+			return
+		}
+
+		d.Attachments, err = p.GetAttachmentsWithData(document.RefID)
+		if err != nil && err != sql.ErrNoRows {
+			return
+		}
+
+		docs = append(docs, d)
+	}
+
+	return docs, nil
+}
+
+// exportMetadata sets the author and space names carried by formats that show document metadata.
+func exportMetadata(p request.Persister, d *export.Document) {
+	d.Author = exportAuthor(p, d.Document.UserID, make(map[string]string))
+
+	folder, err := p.GetLabel(d.Document.LabelID)
+	if err == nil {
+		d.Space = folder.Name
+	}
+}
+
+// exportAuthor returns the full name of the user, remembering names already looked up.
+func exportAuthor(p request.Persister, userID string, authors map[string]string) string {
+	if name, ok := authors[userID]; ok {
+		return name
+	}
+
+	user, err := p.GetUser(userID)
+	if err != nil {
+		return ""
+	}
+
+	authors[userID] = user.Fullname()
+	return authors[userID]
+}
+
+// exportEmbeds tells us if the export format carries attachment images (pdf, docx), or every
+// attachment (epub), within the exported file rather than referring to attachments alongside it.
+func exportEmbeds(xtn string) (images, files bool) {
+	switch xtn {
+	case "pdf", "docx":
+		return true, false
+	case "epub":
+		return true, true
+	}
+	return false, false
+}
+
+// exportAsBook tells us if a folder exports as a single book rather than a file per document.
+func exportAsBook(xtn string) bool {
+	return xtn == "epub"
+}
+
+// embedAttachments wraps the resolver (which may be nil) so that attachment images, and optionally
+// every linked attachment, become data URIs carrying the attachment file name.
+func embedAttachments(resolve export.Resolver, attachments []entity.Attachment, files bool) export.Resolver {
+	uris := make(map[string]string)
+	images := make(map[string]bool)
+	for _, a := range attachments {
+		typ := http.DetectContentType(a.Data)
+		images[a.RefID] = strings.HasPrefix(typ, "image/")
+		if images[a.RefID] || files {
+			uris[a.RefID] = "data:" + strings.Split(typ, ";")[0] + ";name=" + url.QueryEscape(a.Filename) + ";base64," + base64.StdEncoding.EncodeToString(a.Data)
+		}
+	}
+
+	return func(l export.Link) string {
+		if uri, ok := uris[l.AttachmentID]; ok && (files || (l.Attribute == "src" && images[l.AttachmentID])) {
+			return uri
+		}
+		if resolve == nil {
+			return l.URL
+		}
+		return resolve(l)
+	}
+}
+
+// bookResolver rewrites content links between the documents of a book into links to their headings.
+func bookResolver(documents map[string]bool) export.Resolver {
+	return func(l export.Link) string {
+		if !documents[l.TargetDocumentID] {
+			return l.URL
+		}
+		switch l.LinkType {
+		case "document":
+			return "#" + export.DocumentAnchor(l.TargetDocumentID)
+		case "section", "tab":
+			return "#" + export.PageAnchor(l.TargetID)
+		}
+		return l.URL
+	}
+}
+
+// canExportAs tells us if there is a built-in or plugin exporter for the file extension.
+func canExportAs(xtn string) bool {
+	if xtn == "html" {
+		return true
+	}
+	if plugins.Lib == nil {
+		return false
+	}
+	actions, err := plugins.Lib.Actions("Export")
+	if err != nil {
+		return false
+	}
+	for _, x := range actions {
+		if x == xtn {
+			return true
+		}
+	}
+	return false
+}
+
+// exportFileExtension returns the canonical file extension for an export format.
+func exportFileExtension(xtn string) string {
+	if xtn == "markdown" {
+		return "md"
+	}
+	return xtn
+}
+
+// exportExtension returns the file extension for the exported file.
+func exportExtension(xtn string, file *api.DocumentExport) string {
+	if file != nil && len(file.Format) > 0 {
+		return file.Format
+	}
+	return exportFileExtension(xtn)
+}
+
+// exportResolver rewrites attachment and content links into the relative paths used by the export.
+// HTML exports retain the original links so that they continue to work against this server.
+func exportResolver(xtn string, attachments, documents map[string]string) export.Resolver {
+	if xtn == "html" {
+		return nil
+	}
+
+	return func(l export.Link) string {
+		if p, ok := attachments[l.AttachmentID]; ok {
+			return p
+		}
+		switch l.LinkType {
+		case "document", "section", "tab":
+			if p, ok := documents[l.TargetDocumentID]; ok {
+				return p
+			}
+		}
+		return l.URL
+	}
+}
+
+// ExportPageAsCSV is an endpoint that returns the table held by a document section as comma separated values.
+func ExportPageAsCSV(w http.ResponseWriter, r *http.Request) {
+	method := "ExportPageAsCSV"
+	p := request.GetPersister(r)
+
+	params := mux.Vars(r)
+	documentID := params["documentID"]
+	pageID := params["pageID"]
+
+	if len(documentID) == 0 {
+		writeMissingDataError(w, method, "documentID")
+		return
+	}
+
+	if len(pageID) == 0 {
+		writeMissingDataError(w, method, "pageID")
+		return
+	}
+
+	if !p.CanViewDocument(documentID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	page, err := p.GetPage(pageID)
+
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, pageID)
+		return
+	}
+
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if page.DocumentID != documentID {
+		writeBadRequestError(w, method, "documentID mismatch")
+		return
+	}
+
+	data, err := table.CSV(page.Body)
+	if err != nil {
+		writeBadRequestError(w, method, err.Error())
+		return
+	}
+
+	name := stringutil.MakeSlug(page.Title)
+	if len(name) == 0 {
+		name = "table"
+	}
+
+	writeExport(w, name+".csv", data)
+}
+
+// writeExport sends the exported file to the client as a download.
+func writeExport(w http.ResponseWriter, filename string, data []byte) {
+	typ := mime.TypeByExtension(path.Ext(filename))
+	if typ == "" {
+		typ = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+	w.Header().Set("Content-Type", typ)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+	w.WriteHeader(http.StatusOK)
+
+	_, err := w.Write(data)
+	log.IfErr(err)
+}
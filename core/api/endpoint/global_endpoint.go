@@ -23,6 +23,7 @@ import (
 	"github.com/documize/community/core/api/util"
 	"github.com/documize/community/core/event"
 	"github.com/documize/community/core/log"
+	sqlsection "github.com/documize/community/core/section/sql"
 )
 
 // GetSMTPConfig returns installation-wide SMTP settings
@@ -269,3 +270,116 @@ func GetAuthConfig(w http.ResponseWriter, r *http.Request) {
 
 	util.WriteJSON(w, org.AuthConfig)
 }
+
+// GetSQLSources returns the data sources available to SQL sections, without their connection strings.
+func GetSQLSources(w http.ResponseWriter, r *http.Request) {
+	p := request.GetPersister(r)
+
+	if !p.Context.Global {
+		writeForbiddenError(w)
+		return
+	}
+
+	util.WriteJSON(w, sqlData{Drivers: sqlsection.Drivers(), Sources: sqlsection.Masked(sqlsection.Sources())})
+}
+
+// SaveSQLSources replaces the data sources available to SQL sections.
+func SaveSQLSources(w http.ResponseWriter, r *http.Request) {
+	method := "SaveSQLSources"
+	p := request.GetPersister(r)
+
+	if !p.Context.Global {
+		writeForbiddenError(w)
+		return
+	}
+
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	var data sqlData
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	err = sqlsection.SaveSources(data.Sources)
+	if err != nil {
+		util.WriteBadRequestError(w, method, err.Error())
+		return
+	}
+
+	p.RecordEvent(entity.EventTypeSystemSQL)
+
+	util.WriteSuccessEmptyJSON(w)
+}
+
+type sqlData struct {
+	Drivers []string            `json:"drivers"`
+	Sources []sqlsection.Source `json:"sources"`
+}
+
+// GetSecretsConfig reports whether stored secrets are encrypted.
+func GetSecretsConfig(w http.ResponseWriter, r *http.Request) {
+	method := "GetSecretsConfig"
+	p := request.GetPersister(r)
+
+	if !p.Context.Global {
+		writeForbiddenError(w)
+		return
+	}
+
+	j, err := json.Marshal(secretsData{Encrypting: request.Keyring.Encrypting()})
+	if err != nil {
+		writeJSONMarshalError(w, method, "secrets", err)
+		return
+	}
+
+	util.WriteSuccessBytes(w, j)
+}
+
+// RotateSecrets encrypts stored secrets with the current master key,
+// including those encrypted with a previous one.
+func RotateSecrets(w http.ResponseWriter, r *http.Request) {
+	method := "RotateSecrets"
+	p := request.GetPersister(r)
+
+	if !p.Context.Global {
+		writeForbiddenError(w)
+		return
+	}
+
+	var data secretsData
+	var err error
+
+	data.Encrypting = request.Keyring.Encrypting()
+	data.Sealed, data.Failed, err = request.SealUserConfig(true)
+
+	if err != nil {
+		util.WriteBadRequestError(w, method, err.Error())
+		return
+	}
+
+	p.RecordEvent(entity.EventTypeSystemSecrets)
+
+	log.Info(fmt.Sprintf("Rotated %d stored secrets, %d could not be read", data.Sealed, data.Failed))
+
+	j, err := json.Marshal(data)
+	if err != nil {
+		writeJSONMarshalError(w, method, "secrets", err)
+		return
+	}
+
+	util.WriteSuccessBytes(w, j)
+}
+
+type secretsData struct {
+	Encrypting bool `json:"encrypting"`
+	Sealed     int  `json:"sealed"`
+	Failed     int  `json:"failed"` // sealed with a master key that is no longer given
+}
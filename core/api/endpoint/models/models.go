@@ -42,6 +42,11 @@ type DocumentUploadModel struct {
 	JobID string `json:"jobId"`
 }
 
+// WebPageImportModel details the web page to be imported as a new document.
+type WebPageImportModel struct {
+	URL string `json:"url"`
+}
+
 // FolderInvitationModel details which users have been invited to a folder.
 type FolderInvitationModel struct {
 	Message    string
@@ -80,6 +80,34 @@ func AddDocumentPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(model.Page.BlockID) > 0 {
+		block, err := p.GetBlock(model.Page.BlockID)
+		if err == nil {
+			model.Page.BlockVersion = block.Version
+		}
+
+		// a linked page takes the content of the block, so that later changes to the block reach it
+		if model.Page.BlockLinked {
+			if err != nil {
+				writeBadRequestError(w, method, "unknown block")
+				return
+			}
+
+			if !canViewBlock(p, block) {
+				writeForbiddenError(w)
+				return
+			}
+
+			model.Page.ContentType = block.ContentType
+			model.Page.PageType = block.PageType
+			model.Meta.RawBody = block.RawBody
+			model.Meta.Config = block.Config
+			model.Meta.ExternalSource = block.ExternalSource
+		}
+	} else {
+		model.Page.BlockLinked = false
+	}
+
 	pageID := uniqueid.Generate()
 	model.Page.RefID = pageID
 	model.Meta.PageID = pageID
@@ -89,6 +117,11 @@ func AddDocumentPage(w http.ResponseWriter, r *http.Request) {
 	model.Meta.SetDefaults()
 	// page.Title = template.HTMLEscapeString(page.Title)
 
+	if len(model.Meta.ConnectionID) > 0 && request.CheckConnection(p.Context.OrgID, model.Meta.ConnectionID, model.Page.ContentType) != nil {
+		writeBadRequestError(w, method, "unknown connection")
+		return
+	}
+
 	doc, err := p.GetDocument(documentID)
 	if err != nil {
 		writeGeneralSQLError(w, method, err)
@@ -103,7 +136,10 @@ func AddDocumentPage(w http.ResponseWriter, r *http.Request) {
 
 	p.Context.Transaction = tx
 
-	output, ok := provider.Render(model.Page.ContentType, provider.NewContext(model.Meta.OrgID, model.Meta.UserID), model.Meta.Config, model.Meta.RawBody)
+	pcontext := provider.NewContext(model.Meta.OrgID, model.Meta.UserID)
+	pcontext.UseConnection(model.Meta.ConnectionID)
+
+	output, ok := provider.Render(model.Page.ContentType, pcontext, model.Meta.Config, model.Meta.RawBody)
 	if !ok {
 		log.ErrorString("provider.Render could not find: " + model.Page.ContentType)
 	}
@@ -499,6 +535,11 @@ func UpdateDocumentPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(model.Meta.ConnectionID) > 0 && request.CheckConnection(p.Context.OrgID, model.Meta.ConnectionID, model.Page.ContentType) != nil {
+		writeBadRequestError(w, method, "unknown connection")
+		return
+	}
+
 	doc, err := p.GetDocument(documentID)
 	if err != nil {
 		writeGeneralSQLError(w, method, err)
@@ -522,7 +563,21 @@ func UpdateDocumentPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	output, ok := provider.Render(model.Page.ContentType, provider.NewContext(model.Meta.OrgID, oldPageMeta.UserID), model.Meta.Config, model.Meta.RawBody)
+	// editing the content of a page linked to a block makes it a copy, so the edit is not undone by the next change to the block
+	oldPage, err := p.GetPage(pageID)
+	if err == nil && oldPage.BlockLinked && (model.Meta.RawBody != oldPageMeta.RawBody || model.Meta.Config != oldPageMeta.Config) {
+		err = p.DetachPage(pageID)
+		if err != nil {
+			log.IfErr(p.Context.Transaction.Rollback())
+			writeGeneralSQLError(w, method, err)
+			return
+		}
+	}
+
+	pcontext := provider.NewContext(model.Meta.OrgID, oldPageMeta.UserID)
+	pcontext.UseConnection(model.Meta.ConnectionID)
+
+	output, ok := provider.Render(model.Page.ContentType, pcontext, model.Meta.Config, model.Meta.RawBody)
 	if !ok {
 		log.ErrorString("provider.Render could not find: " + model.Page.ContentType)
 	}
@@ -743,6 +798,77 @@ func GetDocumentPageMeta(w http.ResponseWriter, r *http.Request) {
 	writeSuccessBytes(w, json)
 }
 
+// DetachDocumentPage makes a page linked to a content block a copy of it, which later changes to the block leave alone.
+func DetachDocumentPage(w http.ResponseWriter, r *http.Request) {
+	method := "DetachDocumentPage"
+	p := request.GetPersister(r)
+
+	params := mux.Vars(r)
+	documentID := params["documentID"]
+	pageID := params["pageID"]
+
+	if len(documentID) == 0 {
+		writeMissingDataError(w, method, "documentID")
+		return
+	}
+
+	if len(pageID) == 0 {
+		writeMissingDataError(w, method, "pageID")
+		return
+	}
+
+	if !p.CanChangeDocument(documentID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	page, err := p.GetPage(pageID)
+
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, pageID)
+		return
+	}
+
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if page.DocumentID != documentID {
+		writeBadRequestError(w, method, "documentID mismatch")
+		return
+	}
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.DetachPage(pageID)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	p.RecordEvent(entity.EventTypeSectionUpdate)
+
+	log.IfErr(tx.Commit())
+
+	page.BlockLinked = false
+
+	json, err := json.Marshal(page)
+	if err != nil {
+		writeJSONMarshalError(w, method, "page", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
 // GetPageMoveCopyTargets returns available documents for page copy/move axction.
 func GetPageMoveCopyTargets(w http.ResponseWriter, r *http.Request) {
 	method := "GetPageMoveCopyTargets"
@@ -909,10 +1035,18 @@ func GetDocumentPageDiff(w http.ResponseWriter, r *http.Request) {
 
 	revision, _ := p.GetPageRevision(revisionID)
 
-	latestHTML := page.Body
-	previousHTML := revision.Body
-	var result []byte
+	result, err := diffHTML(page.Body, revision.Body)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	_, err = w.Write(result)
+	log.IfErr(err)
+}
 
+// diffHTML marks up what changed between the latest and a previous version of some content.
+func diffHTML(latestHTML, previousHTML string) ([]byte, error) {
 	var cfg = &htmldiff.Config{
 		Granularity:  5,
 		InsertedSpan: []htmldiff.Attribute{{Key: "style", Val: "background-color: palegreen;"}},
@@ -922,14 +1056,10 @@ func GetDocumentPageDiff(w http.ResponseWriter, r *http.Request) {
 	}
 	res, err := cfg.HTMLdiff([]string{latestHTML, previousHTML})
 	if err != nil {
-		writeServerError(w, method, err)
-		return
+		return nil, err
 	}
 
-	result = []byte(res[0])
-
-	_, err = w.Write(result)
-	log.IfErr(err)
+	return []byte(res[0]), nil
 }
 
 // RollbackDocumentPage rolls-back to a specific page revision.
@@ -1022,6 +1152,17 @@ func RollbackDocumentPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// an older revision is no longer the content of the block
+	if page.BlockLinked {
+		err = p.DetachPage(pageID)
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			writeGeneralSQLError(w, method, err)
+			return
+		}
+		page.BlockLinked = false
+	}
+
 	_ = p.RecordUserActivity(entity.UserActivity{
 		LabelID:      doc.LabelID,
 		SourceID:     page.DocumentID,
@@ -0,0 +1,207 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package endpoint
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+	"github.com/documize/community/core/uniqueid"
+)
+
+const (
+	refreshDefault  = 60   // minutes between refreshes of sections without their own interval
+	refreshMinimum  = 5    // minutes, so external services are not asked too often
+	refreshBatch    = 100  // sections refreshed on each pass
+	refreshMaxError = 1000 // characters of the failure reason that are kept
+)
+
+// refreshWake asks the refresher to look for due sections without waiting for the next pass.
+var refreshWake = make(chan struct{}, 1)
+
+// refreshQueue holds the sections webhook events asked to refresh, each once however many events concern it.
+var refreshQueue = struct {
+	sync.Mutex
+	pending map[string]entity.PageMeta
+	order   []string
+}{pending: make(map[string]entity.PageMeta)}
+
+// startRefresher refreshes external data sections in the background, each on its own interval,
+// using the secrets of the user who owns the section.
+func startRefresher() {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			refreshQueued()
+			refreshDue()
+
+			select {
+			case <-ticker.C:
+			case <-refreshWake:
+			}
+		}
+	}()
+}
+
+// wakeRefresher starts a refresh pass soon, unless one is already waiting.
+func wakeRefresher() {
+	select {
+	case refreshWake <- struct{}{}:
+	default:
+	}
+}
+
+// queueRefresh asks the refresher to refresh a section soon, keeping a revision of any change.
+// Refreshing on the refresher alone keeps two refreshes of one section from racing each other.
+func queueRefresh(pm entity.PageMeta) {
+	refreshQueue.Lock()
+	if _, ok := refreshQueue.pending[pm.PageID]; !ok {
+		refreshQueue.order = append(refreshQueue.order, pm.PageID)
+	}
+	refreshQueue.pending[pm.PageID] = pm
+	refreshQueue.Unlock()
+
+	wakeRefresher()
+}
+
+// refreshQueued refreshes the sections queued since the last pass.
+func refreshQueued() {
+	refreshQueue.Lock()
+	pending, order := refreshQueue.pending, refreshQueue.order
+	refreshQueue.pending, refreshQueue.order = make(map[string]entity.PageMeta), nil
+	refreshQueue.Unlock()
+
+	for _, id := range order {
+		refreshSection(pending[id], true)
+	}
+}
+
+// refreshDue refreshes the sections that have not been tried within their interval.
+func refreshDue() {
+	p := request.Persister{}
+
+	meta, err := p.GetPageMetaToRefresh(time.Now().UTC(), refreshDefault, refreshMinimum, refreshBatch)
+
+	if err != nil {
+		return
+	}
+
+	for _, pm := range meta {
+		refreshSection(pm, false)
+	}
+}
+
+// refreshSection fetches the latest data for one section, and records whether that worked.
+// The data is kept as it was when the refresh fails. A revision is kept of changes when revise is true.
+func refreshSection(pm entity.PageMeta, revise bool) {
+	// a provider failing on its data must not take the refresher or the server down with it
+	defer func() {
+		if r := recover(); r != nil {
+			log.ErrorString(fmt.Sprintf("unable to refresh section %s: %v", pm.PageID, r))
+		}
+	}()
+
+	p := request.Persister{Context: request.Context{OrgID: pm.OrgID, UserID: pm.UserID, Authenticated: true}}
+
+	pm.RefreshAttempted = time.Now().UTC()
+	pm.RefreshError = ""
+
+	page, err := p.GetPage(pm.PageID)
+
+	if err != nil && err != sql.ErrNoRows {
+		return // try again on the next pass
+	}
+
+	var body string
+	pcontext := provider.NewContext(pm.OrgID, pm.UserID)
+
+	if err == sql.ErrNoRows {
+		pm.RefreshError = "the section no longer exists"
+	} else if !useConnection(p, pcontext, pm.ConnectionID, page.ContentType, pm.PageID, "refresh") {
+		pm.RefreshError = "the connection for this section no longer exists"
+	} else if data, ok := provider.Refresh(page.ContentType, pcontext, pm.Config, pm.RawBody); !ok {
+		pm.RefreshError = "unknown section type " + page.ContentType
+	} else if pcontext.RefreshError() != nil {
+		pm.RefreshError = pcontext.RefreshError().Error()
+	} else if len(data) == 0 {
+		pm.RefreshError = "no data was returned"
+	} else {
+		pm.RawBody = data
+		pm.Refreshed = pm.RefreshAttempted
+
+		body, ok = provider.Render(page.ContentType, pcontext, pm.Config, data)
+		if !ok {
+			log.ErrorString("provider.Render could not find: " + page.ContentType)
+		}
+	}
+
+	if r := []rune(pm.RefreshError); len(r) > refreshMaxError {
+		pm.RefreshError = string(r[:refreshMaxError])
+	}
+
+	tx, err := request.Db.Beginx()
+
+	if err != nil {
+		log.Error("unable to start transaction to refresh section "+pm.PageID, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	// the page is read again as fetching can take a while, so its title, level or sequence may have been edited since
+	if len(pm.RefreshError) == 0 {
+		page, err = p.GetPage(pm.PageID)
+
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			return
+		}
+	}
+
+	// scheduled refreshes are not edits, so no revision is kept of them
+	if len(pm.RefreshError) == 0 && body != page.Body {
+		page.Body = body
+
+		refID := ""
+		if revise {
+			refID = uniqueid.Generate()
+		}
+
+		err = p.UpdatePage(page, refID, pm.UserID, !revise)
+
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			return
+		}
+	}
+
+	err = p.UpdatePageMetaRefresh(pm)
+
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	if len(pm.RefreshError) > 0 {
+		log.Info(fmt.Sprintf("unable to refresh section %s: %s", pm.PageID, pm.RefreshError))
+	}
+}
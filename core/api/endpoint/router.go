@@ -139,6 +139,7 @@ func init() {
 	log.IfErr(Add(RoutePrefixPublic, "reset/{token}", []string{"POST", "OPTIONS"}, nil, ResetUserPassword))
 	log.IfErr(Add(RoutePrefixPublic, "share/{folderID}", []string{"POST", "OPTIONS"}, nil, AcceptSharedFolder))
 	log.IfErr(Add(RoutePrefixPublic, "attachments/{orgID}/{attachmentID}", []string{"GET", "OPTIONS"}, nil, AttachmentDownload))
+	log.IfErr(Add(RoutePrefixPublic, "webhooks/{webhookID}", []string{"POST", "OPTIONS"}, nil, ReceiveWebhook))
 	log.IfErr(Add(RoutePrefixPublic, "version", []string{"GET", "OPTIONS"}, nil, version))
 
 	//**************************************************
@@ -147,9 +148,12 @@ func init() {
 
 	// Import & Convert Document
 	log.IfErr(Add(RoutePrefixPrivate, "import/folder/{folderID}", []string{"POST", "OPTIONS"}, nil, UploadConvertDocument))
+	log.IfErr(Add(RoutePrefixPrivate, "import/folder/{folderID}/url", []string{"POST", "OPTIONS"}, nil, ImportWebPage))
+	log.IfErr(Add(RoutePrefixPrivate, "import/folder/{folderID}/json", []string{"POST", "OPTIONS"}, nil, ImportDocumentPackage))
 
 	// Document
 	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/export", []string{"GET", "OPTIONS"}, nil, GetDocumentAsDocx))
+	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/export/json", []string{"GET", "OPTIONS"}, nil, ExportDocumentPackage))
 	log.IfErr(Add(RoutePrefixPrivate, "documents", []string{"GET", "OPTIONS"}, []string{"filter", "tag"}, GetDocumentsByTag))
 	log.IfErr(Add(RoutePrefixPrivate, "documents", []string{"GET", "OPTIONS"}, nil, GetDocumentsByFolder))
 	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}", []string{"GET", "OPTIONS"}, nil, GetDocument))
@@ -176,7 +180,9 @@ func init() {
 	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/attachments/{attachmentID}", []string{"DELETE", "OPTIONS"}, nil, DeleteAttachment))
 	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/attachments", []string{"POST", "OPTIONS"}, nil, AddAttachments))
 	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/pages/{pageID}/meta", []string{"GET", "OPTIONS"}, nil, GetDocumentPageMeta))
+	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/pages/{pageID}/export/csv", []string{"GET", "OPTIONS"}, nil, ExportPageAsCSV))
 	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/pages/{pageID}/copy/{targetID}", []string{"POST", "OPTIONS"}, nil, CopyPage))
+	log.IfErr(Add(RoutePrefixPrivate, "documents/{documentID}/pages/{pageID}/detach", []string{"POST", "OPTIONS"}, nil, DetachDocumentPage))
 
 	// Organization
 	log.IfErr(Add(RoutePrefixPrivate, "organizations/{orgID}", []string{"GET", "OPTIONS"}, nil, GetOrganization))
@@ -187,6 +193,8 @@ func init() {
 	log.IfErr(Add(RoutePrefixPrivate, "folders/{folderID}/move/{moveToId}", []string{"DELETE", "OPTIONS"}, nil, RemoveFolder))
 	log.IfErr(Add(RoutePrefixPrivate, "folders/{folderID}/permissions", []string{"PUT", "OPTIONS"}, nil, SetFolderPermissions))
 	log.IfErr(Add(RoutePrefixPrivate, "folders/{folderID}/permissions", []string{"GET", "OPTIONS"}, nil, GetFolderPermissions))
+	log.IfErr(Add(RoutePrefixPrivate, "folders/{folderID}/export", []string{"GET", "OPTIONS"}, nil, ExportFolder))
+	log.IfErr(Add(RoutePrefixPrivate, "folders/{folderID}/export/site", []string{"GET", "OPTIONS"}, nil, ExportFolderSite))
 	log.IfErr(Add(RoutePrefixPrivate, "folders/{folderID}/invitation", []string{"POST", "OPTIONS"}, nil, InviteToFolder))
 	log.IfErr(Add(RoutePrefixPrivate, "folders", []string{"GET", "OPTIONS"}, []string{"filter", "viewers"}, GetFolderVisibility))
 	log.IfErr(Add(RoutePrefixPrivate, "folders", []string{"POST", "OPTIONS"}, nil, AddFolder))
@@ -212,6 +220,10 @@ func init() {
 	log.IfErr(Add(RoutePrefixPrivate, "templates", []string{"POST", "OPTIONS"}, nil, SaveAsTemplate))
 	log.IfErr(Add(RoutePrefixPrivate, "templates", []string{"GET", "OPTIONS"}, nil, GetSavedTemplates))
 	log.IfErr(Add(RoutePrefixPrivate, "templates/stock", []string{"GET", "OPTIONS"}, nil, GetStockTemplates))
+	log.IfErr(Add(RoutePrefixPrivate, "templates/source", []string{"GET", "OPTIONS"}, nil, GetTemplateSource))
+	log.IfErr(Add(RoutePrefixPrivate, "templates/source", []string{"PUT", "OPTIONS"}, nil, SetTemplateSource))
+	log.IfErr(Add(RoutePrefixPrivate, "templates/{templateID}/variables", []string{"GET", "OPTIONS"}, nil, GetTemplateVariables))
+	log.IfErr(Add(RoutePrefixPrivate, "templates/{templateID}/variables", []string{"PUT", "OPTIONS"}, nil, SetTemplateVariables))
 	log.IfErr(Add(RoutePrefixPrivate, "templates/{templateID}/folder/{folderID}", []string{"POST", "OPTIONS"}, []string{"type", "stock"}, StartDocumentFromStockTemplate))
 	log.IfErr(Add(RoutePrefixPrivate, "templates/{templateID}/folder/{folderID}", []string{"POST", "OPTIONS"}, []string{"type", "saved"}, StartDocumentFromSavedTemplate))
 
@@ -224,7 +236,19 @@ func init() {
 	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks/{blockID}", []string{"PUT", "OPTIONS"}, nil, UpdateBlock))
 	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks/{blockID}", []string{"DELETE", "OPTIONS"}, nil, DeleteBlock))
 	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks", []string{"POST", "OPTIONS"}, nil, AddBlock))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks/{blockID}/revisions", []string{"GET", "OPTIONS"}, nil, GetBlockRevisions))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks/{blockID}/revisions/{revisionID}", []string{"GET", "OPTIONS"}, nil, GetBlockDiff))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks/{blockID}/revisions/{revisionID}", []string{"POST", "OPTIONS"}, nil, RollbackBlock))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/blocks/{blockID}/usage", []string{"GET", "OPTIONS"}, nil, GetBlockUsage))
 	log.IfErr(Add(RoutePrefixPrivate, "sections/targets", []string{"GET", "OPTIONS"}, nil, GetPageMoveCopyTargets))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/connections", []string{"GET", "OPTIONS"}, nil, GetConnections))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/connections", []string{"POST", "OPTIONS"}, nil, AddConnection))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/connections/{connectionID}", []string{"PUT", "OPTIONS"}, nil, UpdateConnection))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/connections/{connectionID}", []string{"DELETE", "OPTIONS"}, nil, DeleteConnection))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/connections/{connectionID}/log", []string{"GET", "OPTIONS"}, nil, GetConnectionLog))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/webhooks", []string{"GET", "OPTIONS"}, nil, GetWebhooks))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/webhooks/{section}", []string{"POST", "OPTIONS"}, nil, AddWebhook))
+	log.IfErr(Add(RoutePrefixPrivate, "sections/webhooks/{section}", []string{"DELETE", "OPTIONS"}, nil, DeleteWebhook))
 
 	// Links
 	log.IfErr(Add(RoutePrefixPrivate, "links/{folderID}/{documentID}/{pageID}", []string{"GET", "OPTIONS"}, nil, GetLinkCandidates))
@@ -238,6 +262,10 @@ func init() {
 	log.IfErr(Add(RoutePrefixPrivate, "global/license", []string{"PUT", "OPTIONS"}, nil, SaveLicense))
 	log.IfErr(Add(RoutePrefixPrivate, "global/auth", []string{"GET", "OPTIONS"}, nil, GetAuthConfig))
 	log.IfErr(Add(RoutePrefixPrivate, "global/auth", []string{"PUT", "OPTIONS"}, nil, SaveAuthConfig))
+	log.IfErr(Add(RoutePrefixPrivate, "global/sql", []string{"GET", "OPTIONS"}, nil, GetSQLSources))
+	log.IfErr(Add(RoutePrefixPrivate, "global/sql", []string{"PUT", "OPTIONS"}, nil, SaveSQLSources))
+	log.IfErr(Add(RoutePrefixPrivate, "global/secrets", []string{"GET", "OPTIONS"}, nil, GetSecretsConfig))
+	log.IfErr(Add(RoutePrefixPrivate, "global/secrets/rotate", []string{"POST", "OPTIONS"}, nil, RotateSecrets))
 
 	// Pinned items
 	log.IfErr(Add(RoutePrefixPrivate, "pin/{userID}", []string{"POST", "OPTIONS"}, nil, AddPin))
@@ -75,13 +75,21 @@ func RunSectionCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !provider.Command(sectionName, provider.NewContext(p.Context.OrgID, p.Context.UserID), w, r) {
+	pcontext := provider.NewContext(p.Context.OrgID, p.Context.UserID)
+
+	if !useConnection(p, pcontext, query.Get("connection"), sectionName, query.Get("pageID"), "command") {
+		writeForbiddenError(w)
+		return
+	}
+
+	if !provider.Command(sectionName, pcontext, w, r) {
 		log.ErrorString("Unable to run provider.Command() for: " + sectionName)
 		writeNotFoundError(w, "RunSectionCommand", sectionName)
 	}
 }
 
-// RefreshSections updates document sections where the data is externally sourced.
+// RefreshSections returns the document sections where the data is externally sourced,
+// as last refreshed in the background, and asks for any that are due to be refreshed soon.
 func RefreshSections(w http.ResponseWriter, r *http.Request) {
 	method := "RefreshSections"
 	p := request.GetPersister(r)
@@ -100,7 +108,7 @@ func RefreshSections(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Return payload
-	var pages []entity.Page
+	pages := []entity.Page{}
 
 	// Let's see what sections are reliant on external sources
 	meta, err := p.GetDocumentPageMeta(documentID, true)
@@ -110,17 +118,7 @@ func RefreshSections(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := request.Db.Beginx()
-
-	if err != nil {
-		writeTransactionError(w, method, err)
-		return
-	}
-
-	p.Context.Transaction = tx
-
 	for _, pm := range meta {
-		// Grab the page because we need content type and
 		page, err2 := p.GetPage(pm.PageID)
 
 		if err2 == sql.ErrNoRows {
@@ -129,51 +127,15 @@ func RefreshSections(w http.ResponseWriter, r *http.Request) {
 
 		if err2 != nil {
 			writeGeneralSQLError(w, method, err2)
-			log.IfErr(tx.Rollback())
 			return
 		}
 
-		pcontext := provider.NewContext(pm.OrgID, pm.UserID)
-
-		// Ask for data refresh
-		data, ok := provider.Refresh(page.ContentType, pcontext, pm.Config, pm.RawBody)
-		if !ok {
-			log.ErrorString("provider.Refresh could not find: " + page.ContentType)
-		}
-
-		// Render again
-		body, ok := provider.Render(page.ContentType, pcontext, pm.Config, data)
-		if !ok {
-			log.ErrorString("provider.Render could not find: " + page.ContentType)
-		}
-
-		// Compare to stored render
-		if body != page.Body {
-
-			// Persist latest data
-			page.Body = body
-			pages = append(pages, page)
-
-			refID := uniqueid.Generate()
-			err = p.UpdatePage(page, refID, p.Context.UserID, false)
-
-			if err != nil {
-				writeGeneralSQLError(w, method, err)
-				log.IfErr(tx.Rollback())
-				return
-			}
-
-			err = p.UpdatePageMeta(pm, false) // do not change the UserID on this PageMeta
-
-			if err != nil {
-				writeGeneralSQLError(w, method, err)
-				log.IfErr(tx.Rollback())
-				return
-			}
-		}
+		pages = append(pages, page)
 	}
 
-	log.IfErr(tx.Commit())
+	if len(meta) > 0 {
+		wakeRefresher()
+	}
 
 	json, err := json.Marshal(pages)
 
@@ -219,6 +181,12 @@ func AddBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// offering a block to every space is for administrators to decide
+	if b.Shared && !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
 	b.RefID = uniqueid.Generate()
 
 	tx, err := request.Db.Beginx()
@@ -345,12 +313,33 @@ func UpdateBlock(w http.ResponseWriter, r *http.Request) {
 	}
 
 	b.RefID = blockID
+	b.OrgID = p.Context.OrgID
 
-	if !p.CanUploadDocument(b.LabelID) {
+	current, err := p.GetBlock(blockID)
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, blockID)
+		return
+	}
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if !p.CanUploadDocument(current.LabelID) {
 		writeForbiddenError(w)
 		return
 	}
 
+	// offering a block to every space is for administrators to decide,
+	// as is changing a shared block which rewrites pages in spaces the user may not see
+	if (current.Shared || b.Shared) && !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	b.LabelID = current.LabelID
+	b.Version = current.Version
+
 	tx, err := request.Db.Beginx()
 	if err != nil {
 		writeTransactionError(w, method, err)
@@ -359,7 +348,7 @@ func UpdateBlock(w http.ResponseWriter, r *http.Request) {
 
 	p.Context.Transaction = tx
 
-	err = p.UpdateBlock(b)
+	err = changeBlock(p, b)
 	if err != nil {
 		log.IfErr(tx.Rollback())
 		writeGeneralSQLError(w, method, err)
@@ -373,6 +362,244 @@ func UpdateBlock(w http.ResponseWriter, r *http.Request) {
 	writeSuccessEmptyJSON(w)
 }
 
+// changeBlock saves new content for a block as its next version, keeping the previous one as a revision,
+// and gives it to the pages linked to the block.
+func changeBlock(p request.Persister, b entity.Block) (err error) {
+	pages, err := p.GetLinkedPages(b.RefID)
+	if err != nil && err != sql.ErrNoRows {
+		return
+	}
+
+	err = p.UpdateBlock(b, uniqueid.Generate())
+	if err != nil {
+		return
+	}
+
+	b.Version++
+
+	for _, page := range pages {
+		err = updateLinkedPage(p, page, b)
+		if err != nil {
+			return
+		}
+	}
+
+	return nil
+}
+
+// updateLinkedPage gives a page linked to a content block the content of the block, as a new revision of the page,
+// rendered with the secrets of the page owner.
+func updateLinkedPage(p request.Persister, page entity.Page, b entity.Block) (err error) {
+	meta, err := p.GetPageMeta(page.RefID)
+	if err != nil {
+		return
+	}
+
+	meta.RawBody = b.RawBody
+	meta.Config = b.Config
+	meta.SetDefaults()
+
+	pcontext := provider.NewContext(meta.OrgID, meta.UserID)
+	pcontext.UseConnection(meta.ConnectionID)
+
+	output, ok := provider.Render(page.ContentType, pcontext, meta.Config, meta.RawBody)
+	if !ok {
+		log.ErrorString("provider.Render could not find: " + page.ContentType)
+	}
+
+	page.Body = output
+
+	err = p.UpdatePage(page, uniqueid.Generate(), p.Context.UserID, false)
+	if err != nil {
+		return
+	}
+
+	err = p.UpdatePageMeta(meta, false)
+	if err != nil {
+		return
+	}
+
+	return p.SetPageBlockVersion(page.RefID, b.Version)
+}
+
+// canViewBlock tells if the user can see a block, which is so for shared blocks and those of spaces they can see.
+func canViewBlock(p request.Persister, b entity.Block) bool {
+	return b.Shared || p.CanViewDocumentInFolder(b.LabelID)
+}
+
+// GetBlockRevisions returns the previous versions of a reusable content block.
+func GetBlockRevisions(w http.ResponseWriter, r *http.Request) {
+	method := "GetBlockRevisions"
+	p := request.GetPersister(r)
+	blockID := mux.Vars(r)["blockID"]
+
+	b, err := p.GetBlock(blockID)
+	if err != nil {
+		writeNotFoundError(w, method, blockID)
+		return
+	}
+
+	if !canViewBlock(p, b) {
+		writeForbiddenError(w)
+		return
+	}
+
+	revisions, err := p.GetBlockRevisions(blockID)
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if len(revisions) == 0 {
+		revisions = []entity.BlockRevision{}
+	}
+
+	json, err := json.Marshal(revisions)
+	if err != nil {
+		writeJSONMarshalError(w, method, "block revisions", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
+// GetBlockDiff returns the differences between the latest and a previous version of a reusable content block.
+func GetBlockDiff(w http.ResponseWriter, r *http.Request) {
+	method := "GetBlockDiff"
+	p := request.GetPersister(r)
+	params := mux.Vars(r)
+	blockID := params["blockID"]
+	revisionID := params["revisionID"]
+
+	b, err := p.GetBlock(blockID)
+	if err != nil {
+		writeNotFoundError(w, method, blockID)
+		return
+	}
+
+	if !canViewBlock(p, b) {
+		writeForbiddenError(w)
+		return
+	}
+
+	revision, err := p.GetBlockRevision(revisionID)
+	if err != nil || revision.BlockID != blockID {
+		writeNotFoundError(w, method, revisionID)
+		return
+	}
+
+	result, err := diffHTML(b.Body, revision.Body)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	_, err = w.Write(result)
+	log.IfErr(err)
+}
+
+// RollbackBlock makes a previous version of a reusable content block the latest, as a new version,
+// which pages linked to the block follow.
+func RollbackBlock(w http.ResponseWriter, r *http.Request) {
+	method := "RollbackBlock"
+	p := request.GetPersister(r)
+	params := mux.Vars(r)
+	blockID := params["blockID"]
+	revisionID := params["revisionID"]
+
+	b, err := p.GetBlock(blockID)
+	if err != nil {
+		writeNotFoundError(w, method, blockID)
+		return
+	}
+
+	if !p.CanUploadDocument(b.LabelID) || (b.Shared && !p.Context.Administrator) {
+		writeForbiddenError(w)
+		return
+	}
+
+	revision, err := p.GetBlockRevision(revisionID)
+	if err != nil || revision.BlockID != blockID {
+		writeNotFoundError(w, method, revisionID)
+		return
+	}
+
+	b.Title = revision.Title
+	b.Body = revision.Body
+	b.Excerpt = revision.Excerpt
+	b.RawBody = revision.RawBody
+	b.Config = revision.Config
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = changeBlock(p, b)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	p.RecordEvent(entity.EventTypeBlockRollback)
+
+	log.IfErr(tx.Commit())
+
+	b, err = p.GetBlock(blockID)
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	json, err := json.Marshal(b)
+	if err != nil {
+		writeJSONMarshalError(w, method, "block", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
+// GetBlockUsage returns the pages inserted from a reusable content block, and the version of the block each has.
+func GetBlockUsage(w http.ResponseWriter, r *http.Request) {
+	method := "GetBlockUsage"
+	p := request.GetPersister(r)
+	blockID := mux.Vars(r)["blockID"]
+
+	b, err := p.GetBlock(blockID)
+	if err != nil {
+		writeNotFoundError(w, method, blockID)
+		return
+	}
+
+	if !canViewBlock(p, b) {
+		writeForbiddenError(w)
+		return
+	}
+
+	usage, err := p.GetBlockUsage(blockID)
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if len(usage) == 0 {
+		usage = []entity.BlockUsage{}
+	}
+
+	json, err := json.Marshal(usage)
+	if err != nil {
+		writeJSONMarshalError(w, method, "block usage", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
 // DeleteBlock removes requested reusable content block.
 func DeleteBlock(w http.ResponseWriter, r *http.Request) {
 	method := "DeleteBlock"
@@ -386,6 +613,18 @@ func DeleteBlock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	b, err := p.GetBlock(blockID)
+	if err != nil {
+		writeNotFoundError(w, method, blockID)
+		return
+	}
+
+	// deleting unlinks pages in every space using the block, so shared blocks are for administrators to remove
+	if !p.CanUploadDocument(b.LabelID) || (b.Shared && !p.Context.Administrator) {
+		writeForbiddenError(w)
+		return
+	}
+
 	tx, err := request.Db.Beginx()
 	if err != nil {
 		writeTransactionError(w, method, err)
@@ -20,6 +20,7 @@ import (
 	"github.com/codegangsta/negroni"
 	"github.com/documize/community/core/api"
 	"github.com/documize/community/core/api/plugins"
+	"github.com/documize/community/core/api/request"
 	"github.com/documize/community/core/database"
 	"github.com/documize/community/core/log"
 	"github.com/documize/community/core/web"
@@ -52,6 +53,24 @@ import (
 
 var testHost string // used during automated testing
 
+// sealSecrets encrypts secrets stored before there was a master key.
+func sealSecrets() {
+	if !request.Keyring.Encrypting() {
+		return
+	}
+
+	sealed, failed, err := request.SealUserConfig(false)
+
+	if err != nil {
+		log.Error("unable to encrypt stored secrets", err)
+		return
+	}
+
+	if sealed > 0 || failed > 0 {
+		log.Info(fmt.Sprintf("Encrypted %d stored secrets, %d could not be read", sealed, failed))
+	}
+}
+
 // Serve the Documize endpoint.
 func Serve(ready chan struct{}) {
 	err := plugins.LibSetup()
@@ -73,6 +92,8 @@ func Serve(ready chan struct{}) {
 		log.Info("Serving BAD DATABASE web app")
 	default:
 		log.Info("Starting web app")
+		sealSecrets()
+		startRefresher()
 	}
 
 	router := mux.NewRouter()
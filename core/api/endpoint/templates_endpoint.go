@@ -12,16 +12,24 @@
 package endpoint
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/documize/community/core/api/catalogue"
 	"github.com/documize/community/core/api/convert"
 	"github.com/documize/community/core/api/endpoint/models"
 	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/export/transfer"
 	"github.com/documize/community/core/api/request"
 	"github.com/documize/community/core/api/util"
 	api "github.com/documize/community/core/convapi"
@@ -169,6 +177,19 @@ func SaveAsTemplate(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// a template saved from another template asks for the same values
+	vars, _ := p.GetTemplateVariables(model.DocumentID)
+	for i := range vars {
+		vars[i].RefID = uniqueid.Generate()
+	}
+
+	err = p.SetTemplateVariables(docID, vars)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
 	p.RecordEvent(entity.EventTypeTemplateAdd)
 
 	// Commit and return new document template
@@ -202,6 +223,40 @@ func GetSavedTemplates(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// documents in the template space are offered to those who can see the space
+	org, err := p.GetOrganization(p.Context.OrgID)
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if len(org.TemplateFolderID) > 0 && p.CanViewFolder(org.TemplateFolderID) {
+		shared, err := p.GetDocumentsByFolder(org.TemplateFolderID)
+		if err != nil && err != sql.ErrNoRows {
+			writeGeneralSQLError(w, method, err)
+			return
+		}
+
+		documents = append(documents, shared...)
+	}
+
+	changes, err := p.GetTemplateChanges(org.TemplateFolderID)
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	vars, err := p.GetOrgTemplateVariables()
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	byTemplate := make(map[string][]entity.TemplateVariable)
+	for _, v := range vars {
+		byTemplate[v.DocumentID] = append(byTemplate[v.DocumentID], v)
+	}
+
 	templates := []entity.Template{}
 
 	for _, d := range documents {
@@ -214,6 +269,17 @@ func GetSavedTemplates(w http.ResponseWriter, r *http.Request) {
 		template.Author = ""
 		template.Dated = d.Created
 		template.Type = entity.TemplateTypePrivate
+		template.Category = templateCategory(d.Tags)
+		template.Version = templateVersion(changes[d.RefID])
+		template.Variables = byTemplate[d.RefID]
+
+		if d.LabelID == org.TemplateFolderID && !d.Template {
+			template.Type = entity.TemplateTypeRestricted
+		}
+
+		if len(template.Variables) == 0 {
+			template.Variables = []entity.TemplateVariable{}
+		}
 
 		templates = append(templates, template)
 	}
@@ -228,11 +294,31 @@ func GetSavedTemplates(w http.ResponseWriter, r *http.Request) {
 	writeSuccessBytes(w, data)
 }
 
-// GetStockTemplates returns available templates from the public Documize repository.
+// GetStockTemplates returns the latest version of each template in the catalogue of the installation.
 func GetStockTemplates(w http.ResponseWriter, r *http.Request) {
 	method := "GetStockTemplates"
 
-	var templates = fetchStockTemplates()
+	entries, err := catalogue.Load(templateDirectory())
+	if err != nil {
+		log.Error("unable to read template directory", err)
+	}
+
+	templates := []entity.Template{}
+
+	for _, e := range entries {
+		var template = entity.Template{}
+
+		template.ID = e.ID
+		template.Title = e.Title
+		template.Description = e.Description
+		template.Author = e.Author
+		template.Category = e.Category
+		template.Version = e.Version
+		template.Type = entity.TemplateTypePublic
+		template.Variables = []entity.TemplateVariable{}
+
+		templates = append(templates, template)
+	}
 
 	json, err := json.Marshal(templates)
 
@@ -244,7 +330,8 @@ func GetStockTemplates(w http.ResponseWriter, r *http.Request) {
 	writeSuccessBytes(w, json)
 }
 
-// StartDocumentFromStockTemplate creates new document using one of the stock templates
+// StartDocumentFromStockTemplate creates new document using the latest version of a template in the catalogue,
+// converting its Word document or copying its document package.
 func StartDocumentFromStockTemplate(w http.ResponseWriter, r *http.Request) {
 	method := "StartDocumentFromStockTemplate"
 	p := request.GetPersister(r)
@@ -269,7 +356,19 @@ func StartDocumentFromStockTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filename, template, err := fetchStockTemplate(templateID)
+	entry, err := catalogue.Find(templateDirectory(), templateID)
+
+	if err == catalogue.ErrNotFound {
+		writeNotFoundError(w, method, templateID)
+		return
+	}
+
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	template, err := entry.Content()
 
 	if err != nil {
 		writeServerError(w, method, err)
@@ -278,30 +377,70 @@ func StartDocumentFromStockTemplate(w http.ResponseWriter, r *http.Request) {
 
 	if len(template) == 0 {
 		writeBadRequestError(w, method, "No data found in template")
+		return
 	}
 
-	fileRequest := api.DocumentConversionRequest{}
-	fileRequest.Filedata = template
-	fileRequest.Filename = fmt.Sprintf("%s.docx", filename)
-	fileRequest.PageBreakLevel = 4
-	//fileRequest.Job = templateID
-	//fileRequest.OrgID = p.Context.OrgID
+	var model entity.Document
+
+	if entry.Format == catalogue.FormatPackage {
+		pkg, err := transfer.Unmarshal(template)
+		if err != nil {
+			writeServerError(w, method, err)
+			return
+		}
+
+		pkg.Document.Template = false
+
+		model, err = importPackage(p, pkg, folderID)
+		if err != nil {
+			writeGeneralSQLError(w, method, err)
+			return
+		}
+	} else {
+		fileRequest := api.DocumentConversionRequest{}
+		fileRequest.Filedata = template
+		fileRequest.Filename = fmt.Sprintf("%s.docx", entry.ID)
+		fileRequest.PageBreakLevel = 4
+		//fileRequest.Job = templateID
+		//fileRequest.OrgID = p.Context.OrgID
+
+		//	fileResult, err := store.RunConversion(fileRequest)
+		//fileResultI, err := plugins.Lib.Run(nil, "Convert", "docx", fileRequest)
+		fileResult, err := convert.Convert(nil, "docx", &fileRequest)
+		if err != nil {
+			writeServerError(w, method, err)
+			return
+		}
+
+		model, err = processDocument(p, fileRequest.Filename, templateID, folderID, fileResult)
 
-	//	fileResult, err := store.RunConversion(fileRequest)
-	//fileResultI, err := plugins.Lib.Run(nil, "Convert", "docx", fileRequest)
-	fileResult, err := convert.Convert(nil, "docx", &fileRequest)
+		if err != nil {
+			writeServerError(w, method, err)
+			return
+		}
+	}
+
+	// remember the version so documents started from older versions can be found
+	tx, err := request.Db.Beginx()
 	if err != nil {
-		writeServerError(w, method, err)
+		writeTransactionError(w, method, err)
 		return
 	}
 
-	model, err := processDocument(p, fileRequest.Filename, templateID, folderID, fileResult)
+	p.Context.Transaction = tx
 
+	err = p.SetDocumentTemplate(model.RefID, entry.ID, entry.Version)
 	if err != nil {
-		writeServerError(w, method, err)
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
 		return
 	}
 
+	log.IfErr(tx.Commit())
+
+	model.TemplateID = entry.ID
+	model.TemplateVersion = entry.Version
+
 	json, err := json.Marshal(model)
 
 	if err != nil {
@@ -338,7 +477,19 @@ func StartDocumentFromSavedTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	docTitle := string(body)
+	// the body is the title of the new document, or an object with the title and the values of the template variables
+	var start struct {
+		Title  string            `json:"title"`
+		Values map[string]string `json:"values"`
+	}
+
+	// a plain title such as null or {"a":1} is still a title, so only an object naming the title or values is read
+	isObject := bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+	if !isObject || json.Unmarshal(body, &start) != nil || (len(start.Title) == 0 && len(start.Values) == 0) {
+		start.Title, start.Values = string(body), nil
+	}
+
+	docTitle := start.Title
 
 	// Define an empty document just in case user wanted one.
 	var d = entity.Document{}
@@ -372,6 +523,21 @@ func StartDocumentFromSavedTemplate(w http.ResponseWriter, r *http.Request) {
 		attachments, _ = p.GetAttachmentsWithData(templateID)
 	}
 
+	// values for the template variables, filled in below wherever the template has {{name}}
+	values := map[string]string{}
+
+	if templateID != "0" {
+		vars, _ := p.GetTemplateVariables(templateID)
+
+		for _, v := range vars {
+			values[v.Name], err = templateValue(p, v, start.Values[v.Name])
+			if err != nil {
+				writeBadRequestError(w, method, err.Error())
+				return
+			}
+		}
+	}
+
 	// create new document
 	tx, err := request.Db.Beginx()
 
@@ -388,7 +554,22 @@ func StartDocumentFromSavedTemplate(w http.ResponseWriter, r *http.Request) {
 	d.Template = false
 	d.LabelID = folderID
 	d.UserID = p.Context.UserID
-	d.Title = docTitle
+	d.Title = stringutil.Fill(docTitle, values, nil)
+	d.Excerpt = stringutil.Fill(d.Excerpt, values, nil)
+	d.TemplateID = ""
+	d.TemplateVersion = ""
+
+	if templateID != "0" {
+		changed := d.Revised
+		for _, page := range pages {
+			if page.Revised.After(changed) {
+				changed = page.Revised
+			}
+		}
+
+		d.TemplateID = templateID
+		d.TemplateVersion = templateVersion(changed)
+	}
 
 	err = p.AddDocument(d)
 	if err != nil {
@@ -409,6 +590,16 @@ func StartDocumentFromSavedTemplate(w http.ResponseWriter, r *http.Request) {
 		pageID := uniqueid.Generate()
 		page.RefID = pageID
 
+		// the rendered body is HTML, as is the raw body of the HTML editor, and the config is JSON
+		page.Title = stringutil.Fill(page.Title, values, nil)
+		page.Body = stringutil.Fill(page.Body, values, html.EscapeString)
+		if page.ContentType == "wysiwyg" {
+			meta.RawBody = stringutil.Fill(meta.RawBody, values, html.EscapeString)
+		} else {
+			meta.RawBody = stringutil.Fill(meta.RawBody, values, nil)
+		}
+		meta.Config = stringutil.Fill(meta.Config, values, jsonEscape)
+
 		// meta := entity.PageMeta{}
 		meta.PageID = pageID
 		meta.DocumentID = documentID
@@ -474,99 +665,318 @@ func StartDocumentFromSavedTemplate(w http.ResponseWriter, r *http.Request) {
 	writeSuccessBytes(w, data)
 }
 
-type templateConfig struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	Author      string `json:"author"`
-	Title       string `json:"title"`
+// GetTemplateVariables returns the variables a template asks for.
+func GetTemplateVariables(w http.ResponseWriter, r *http.Request) {
+	method := "GetTemplateVariables"
+	p := request.GetPersister(r)
+	templateID := mux.Vars(r)["templateID"]
+
+	if !p.CanViewDocument(templateID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	vars, err := p.GetTemplateVariables(templateID)
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	if len(vars) == 0 {
+		vars = []entity.TemplateVariable{}
+	}
+
+	util.WriteJSON(w, vars)
 }
 
-func fetchStockTemplates() (templates []entity.Template) {
-	path := "./templates"
-	templates = make([]entity.Template, 0)
-
-	folders, err := ioutil.ReadDir(path)
-	log.IfErr(err)
-	for _, folder := range folders {
-
-		if folder.IsDir() {
-			files, err := ioutil.ReadDir(path + "/" + folder.Name())
-			log.IfErr(err)
-
-			for _, file := range files {
-				if !file.IsDir() && file.Name() == "template.json" {
-					data, err := ioutil.ReadFile(path + "/" + folder.Name() + "/" + file.Name())
-
-					if err != nil {
-						log.Error("error reading template.json", err)
-					} else {
-						var config = templateConfig{}
-						err = json.Unmarshal(data, &config)
-
-						if err != nil {
-							log.Error("error parsing template.json", err)
-						} else {
-							var template = entity.Template{}
-
-							template.ID = config.ID
-							template.Title = config.Title
-							template.Description = config.Description
-							template.Author = config.Author
-							template.Type = entity.TemplateTypePublic
-
-							templates = append(templates, template)
-						}
-					}
-				}
-			}
+// SetTemplateVariables replaces the variables a template asks for.
+func SetTemplateVariables(w http.ResponseWriter, r *http.Request) {
+	method := "SetTemplateVariables"
+	p := request.GetPersister(r)
+	templateID := mux.Vars(r)["templateID"]
+
+	if !p.CanChangeDocument(templateID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	doc, err := p.GetDocument(templateID)
+	if err != nil {
+		writeNotFoundError(w, method, templateID)
+		return
+	}
+
+	org, err := p.GetOrganization(p.Context.OrgID)
+	if err != nil || (!doc.Template && doc.LabelID != org.TemplateFolderID) {
+		writeNotFoundError(w, method, templateID)
+		return
+	}
+
+	defer streamutil.Close(r.Body)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeBadRequestError(w, method, "Bad payload")
+		return
+	}
+
+	var vars []entity.TemplateVariable
+	err = json.Unmarshal(body, &vars)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	vars, err = cleanTemplateVariables(vars)
+	if err != nil {
+		writeBadRequestError(w, method, err.Error())
+		return
+	}
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.SetTemplateVariables(templateID, vars)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	util.WriteJSON(w, vars)
+}
+
+// cleanTemplateVariables checks the variables declared for a template.
+func cleanTemplateVariables(vars []entity.TemplateVariable) (clean []entity.TemplateVariable, err error) {
+	if len(vars) > 50 {
+		return nil, errors.New("a template can have up to 50 variables")
+	}
+
+	clean = []entity.TemplateVariable{}
+	names := make(map[string]bool)
+
+	for _, v := range vars {
+		v.Name = strings.TrimSpace(v.Name)
+		v.Label = strings.TrimSpace(v.Label)
+		v.DefaultValue = strings.TrimSpace(v.DefaultValue)
+
+		if !stringutil.IsPlaceholderName(v.Name) {
+			return nil, fmt.Errorf("%q cannot be a variable, use letters, digits and underscores", v.Name)
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("%s is declared twice", v.Name)
+		}
+		names[v.Name] = true
+
+		switch v.Type {
+		case "text", "date", "number", "user":
+		case "":
+			v.Type = "text"
+		default:
+			return nil, fmt.Errorf("%s has unknown type %s", v.Name, v.Type)
+		}
+
+		if len(v.Label) > 200 || len(v.DefaultValue) > 1000 {
+			return nil, fmt.Errorf("the label or default of %s is too long", v.Name)
+		}
+
+		if err = checkTemplateValue(v, v.DefaultValue); err != nil {
+			return nil, err
+		}
+
+		v.RefID = uniqueid.Generate()
+		clean = append(clean, v)
+	}
+
+	return clean, nil
+}
+
+// checkTemplateValue tells if a value suits the type of the variable.
+func checkTemplateValue(v entity.TemplateVariable, value string) error {
+	label := v.Label
+	if len(label) == 0 {
+		label = v.Name
+	}
+
+	switch v.Type {
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); len(value) > 0 && value != "today" && err != nil {
+			return fmt.Errorf("%s must be a date such as 2017-03-31", label)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); len(value) > 0 && err != nil {
+			return fmt.Errorf("%s must be a number", label)
+		}
+	}
+
+	return nil
+}
+
+// templateValue returns the value given for a variable, or its default, with "today" and "me" filled in.
+func templateValue(p request.Persister, v entity.TemplateVariable, given string) (value string, err error) {
+	value = strings.TrimSpace(given)
+	if len(value) == 0 {
+		value = v.DefaultValue
+	}
+
+	if err = checkTemplateValue(v, value); err != nil {
+		return
+	}
+
+	switch {
+	case v.Type == "date" && value == "today":
+		value = time.Now().UTC().Format("2006-01-02")
+	case v.Type == "user" && value == "me":
+		user, err := p.GetUser(p.Context.UserID)
+		if err == nil {
+			value = user.Fullname()
+		}
+	}
+
+	return value, nil
+}
+
+// jsonEscape escapes a value for use within a JSON string.
+func jsonEscape(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
+
+// templateDirectory is where the installation keeps its catalogue of templates.
+func templateDirectory() string {
+	var source templateSource
+	json.Unmarshal([]byte(request.ConfigString("TEMPLATES", "")), &source)
+
+	if len(source.Directory) == 0 {
+		return "./templates"
+	}
+
+	return source.Directory
+}
+
+// templateVersion tells apart the versions of a saved template by when it last changed.
+func templateVersion(changed time.Time) string {
+	return changed.UTC().Format("2006.01.02.1504")
+}
+
+// templateCategory files a saved template under its first tag.
+func templateCategory(tags string) string {
+	for _, t := range strings.Split(tags, "#") {
+		if len(t) > 0 {
+			return t
 		}
 	}
 
-	return
+	return ""
 }
 
-func fetchStockTemplate(ID string) (filename string, template []byte, err error) {
+// templateSource is where templates come from besides those saved in spaces.
+type templateSource struct {
+	Directory string `json:"directory"` // the installation catalogue, set by global administrators
+	FolderID  string `json:"folderId"`  // the space of the organization whose documents are templates
+}
 
-	path := "./templates"
-	folders, err := ioutil.ReadDir(path)
+// GetTemplateSource returns where the catalogue of templates comes from.
+func GetTemplateSource(w http.ResponseWriter, r *http.Request) {
+	method := "GetTemplateSource"
+	p := request.GetPersister(r)
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	org, err := p.GetOrganization(p.Context.OrgID)
 	if err != nil {
-		log.Error("error reading template directory", err)
-		return "", nil, err
+		writeGeneralSQLError(w, method, err)
+		return
 	}
 
-	for _, folder := range folders {
-		if folder.IsDir() {
-			files, err := ioutil.ReadDir(path + "/" + folder.Name())
-			if err != nil {
-				log.Error("error reading template sub-dir", err)
-				return "", nil, err
-			}
+	source := templateSource{FolderID: org.TemplateFolderID}
 
-			for _, file := range files {
-				if !file.IsDir() && file.Name() == "template.json" {
-					data, err := ioutil.ReadFile(path + "/" + folder.Name() + "/template.json")
+	// the directory is on the server, which only global administrators are told about
+	if p.Context.Global {
+		source.Directory = templateDirectory()
+	}
 
-					if err != nil {
-						log.Error("error reading template.json", err)
-						return "", nil, err
-					}
+	util.WriteJSON(w, source)
+}
 
-					var config = templateConfig{}
-					err = json.Unmarshal(data, &config)
+// SetTemplateSource changes where the catalogue of templates comes from.
+func SetTemplateSource(w http.ResponseWriter, r *http.Request) {
+	method := "SetTemplateSource"
+	p := request.GetPersister(r)
 
-					if err != nil {
-						log.Error("error parsing template.json", err)
-						return "", nil, err
-					}
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
 
-					if config.ID == ID {
-						template, err = ioutil.ReadFile(path + "/" + folder.Name() + "/template.docx")
-						return folder.Name(), template, err
-					}
-				}
-			}
+	defer streamutil.Close(r.Body)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	var source templateSource
+	err = json.Unmarshal(body, &source)
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	source.FolderID = strings.TrimSpace(source.FolderID)
+	source.Directory = strings.TrimSpace(source.Directory)
+
+	if len(source.FolderID) > 0 {
+		if _, err = p.GetLabel(source.FolderID); err != nil {
+			writeNotFoundError(w, method, source.FolderID)
+			return
+		}
+	}
+
+	if p.Context.Global && len(source.Directory) > 0 {
+		if info, err := os.Stat(source.Directory); err != nil || !info.IsDir() {
+			writeBadRequestError(w, method, "template directory cannot be read")
+			return
+		}
+	}
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.UpdateTemplateFolder(source.FolderID)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	if p.Context.Global {
+		config, _ := json.Marshal(templateSource{Directory: source.Directory})
+
+		err = request.ConfigSet("TEMPLATES", string(config))
+		if err != nil {
+			writeServerError(w, method, err)
+			return
 		}
+	} else {
+		source.Directory = ""
 	}
 
-	return
+	util.WriteJSON(w, source)
 }
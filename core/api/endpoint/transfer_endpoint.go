@@ -0,0 +1,310 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package endpoint
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/documize/community/core/api/endpoint/models"
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/export/transfer"
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/streamutil"
+	"github.com/documize/community/core/stringutil"
+	"github.com/documize/community/core/uniqueid"
+	"github.com/gorilla/mux"
+)
+
+// ExportDocumentPackage is an endpoint that returns a document as a lossless JSON package,
+// holding its pages, section data, revision history, attachments and content links.
+// The package can be imported into another Documize instance using ImportDocumentPackage.
+func ExportDocumentPackage(w http.ResponseWriter, r *http.Request) {
+	method := "ExportDocumentPackage"
+	p := request.GetPersister(r)
+
+	params := mux.Vars(r)
+	documentID := params["documentID"]
+
+	if len(documentID) == 0 {
+		writeMissingDataError(w, method, "documentID")
+		return
+	}
+
+	document, err := p.GetDocument(documentID)
+
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, documentID)
+		return
+	}
+
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	if !p.CanViewDocumentInFolder(document.LabelID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	pkg := transfer.Package{Document: document}
+
+	pages, err := p.GetPages(documentID)
+	if err != nil {
+		writeServerError(w, method, err)
+		return
+	}
+
+	meta, err := p.GetDocumentPageMeta(documentID, false)
+	if err != nil && err != sql.ErrNoRows {
+		writeServerError(w, method, err)
+		return
+	}
+
+	pageMeta := make(map[string]entity.PageMeta)
+	for _, m := range meta {
+		pageMeta[m.PageID] = m
+	}
+
+	for _, page := range pages {
+		m, ok := pageMeta[page.RefID]
+		if !ok {
+			m = entity.PageMeta{PageID: page.RefID, DocumentID: documentID, RawBody: page.Body}
+		}
+		m.SetDefaults()
+		pkg.Pages = append(pkg.Pages, transfer.Page{Page: page, Meta: m})
+	}
+
+	pkg.Revisions, err = p.GetDocumentRevisionsWithContent(documentID)
+	if err != nil && err != sql.ErrNoRows {
+		writeServerError(w, method, err)
+		return
+	}
+
+	attachments, err := p.GetAttachmentsWithData(documentID)
+	if err != nil && err != sql.ErrNoRows {
+		writeServerError(w, method, err)
+		return
+	}
+
+	for _, a := range attachments {
+		pkg.Attachments = append(pkg.Attachments, transfer.Attachment{Attachment: a, Data: a.Data})
+	}
+
+	pkg.Links, err = p.GetDocumentOutboundLinks(documentID)
+	if err != nil && err != sql.ErrNoRows {
+		writeServerError(w, method, err)
+		return
+	}
+
+	data, err := transfer.Marshal(pkg)
+	if err != nil {
+		writeJSONMarshalError(w, method, "document package", err)
+		return
+	}
+
+	writeExport(w, stringutil.MakeSlug(document.Title)+".json", data)
+}
+
+// ImportDocumentPackage is an endpoint that recreates a document exported by ExportDocumentPackage within the given folder.
+// Everything imported is given new identifiers, with references between them rewritten to match.
+// The package is sent as the request body or as an uploaded file named "attachment".
+func ImportDocumentPackage(w http.ResponseWriter, r *http.Request) {
+	method := "ImportDocumentPackage"
+	p := request.GetPersister(r)
+
+	params := mux.Vars(r)
+	folderID := params["folderID"]
+
+	if len(folderID) == 0 {
+		writeMissingDataError(w, method, "folderID")
+		return
+	}
+
+	if !p.CanUploadDocument(folderID) {
+		writeForbiddenError(w)
+		return
+	}
+
+	var body []byte
+	var err error
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		filedata, _, err2 := r.FormFile("attachment")
+		if err2 != nil {
+			writeMissingDataError(w, method, "attachment")
+			return
+		}
+		defer streamutil.Close(filedata)
+		body, err = ioutil.ReadAll(filedata)
+	} else {
+		defer streamutil.Close(r.Body)
+		body, err = ioutil.ReadAll(r.Body)
+	}
+
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	pkg, err := transfer.Unmarshal(body)
+	if err != nil {
+		writeBadRequestError(w, method, err.Error())
+		return
+	}
+
+	newDocument, err := importPackage(p, pkg, folderID)
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	json, err := json.Marshal(newDocument)
+	if err != nil {
+		writeJSONMarshalError(w, method, "document", err)
+		return
+	}
+
+	writeSuccessBytes(w, json)
+}
+
+// importPackage recreates the document held by a package within the given folder, with new identifiers.
+func importPackage(p request.Persister, pkg transfer.Package, folderID string) (newDocument entity.Document, err error) {
+	source := pkg.Document.RefID
+
+	pkg.Rekey(folderID, uniqueid.Generate, func(attachmentID string) string {
+		return p.Context.GetAppURL(fmt.Sprintf("api/public/attachments/%s/%s", p.Context.OrgID, attachmentID))
+	})
+
+	documentID := pkg.Document.RefID
+
+	d := pkg.Document
+	d.UserID = p.Context.UserID
+	d.Slug = stringutil.MakeSlug(d.Title)
+	d.SetDefaults()
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.AddDocument(d)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		return
+	}
+
+	for _, pg := range pkg.Pages {
+		// reusable blocks belong to the instance they were published in,
+		// and pages only stay linked to those the importer can see
+		if len(pg.Page.BlockID) > 0 {
+			block, err := p.GetBlock(pg.Page.BlockID)
+			if err != nil {
+				pg.Page.BlockID = ""
+				pg.Page.BlockLinked = false
+			} else if !canViewBlock(p, block) {
+				pg.Page.BlockLinked = false
+			}
+		}
+
+		pg.Meta.SetDefaults()
+
+		err = p.AddPage(models.PageModel{Page: pg.Page, Meta: pg.Meta})
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			return
+		}
+	}
+
+	for _, rv := range pkg.Revisions {
+		// authors are not carried between instances
+		rv.OwnerID = p.Context.UserID
+		rv.UserID = p.Context.UserID
+
+		err = p.AddRevision(rv)
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			return
+		}
+	}
+
+	for _, a := range pkg.Attachments {
+		a.Attachment.Data = a.Data
+
+		err = p.AddAttachment(a.Attachment)
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			return
+		}
+	}
+
+	for _, l := range pkg.Links {
+		l.OrgID = p.Context.OrgID
+		l.UserID = p.Context.UserID
+
+		// links to other documents only survive when the target exists here
+		if !pkg.Internal(l) && len(l.TargetDocumentID) > 0 {
+			if _, err = p.GetDocument(l.TargetDocumentID); err != nil {
+				l.Orphan = true
+			}
+		}
+
+		err = p.AddContentLink(l)
+		if err != nil {
+			log.IfErr(tx.Rollback())
+			return
+		}
+	}
+
+	log.IfErr(tx.Commit())
+
+	newDocument, err = p.GetDocument(documentID)
+	if err != nil {
+		return
+	}
+
+	tx, err = request.Db.Beginx()
+	if err != nil {
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	// reindex the document for searching
+	err = p.UpdateDocument(newDocument)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		return
+	}
+
+	p.RecordUserActivity(entity.UserActivity{
+		LabelID:      newDocument.LabelID,
+		SourceID:     newDocument.RefID,
+		SourceType:   entity.ActivitySourceTypeDocument,
+		ActivityType: entity.ActivityTypeCreated})
+
+	p.RecordEvent(entity.EventTypeDocumentUpload)
+
+	log.IfErr(tx.Commit())
+
+	log.Info(fmt.Sprintf("Org %s (%s) [Imported] document %s as %s", p.Context.OrgName, p.Context.OrgID, source, documentID))
+
+	return
+}
@@ -0,0 +1,225 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package endpoint
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/api/util"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/secrets"
+	"github.com/documize/community/core/section/provider"
+	"github.com/documize/community/core/uniqueid"
+	"github.com/gorilla/mux"
+)
+
+// webhookMaxBytes caps the size of an event we are prepared to read.
+const webhookMaxBytes = 5 << 20
+
+// webhookData describes the webhook of a section type, which has no ID until one is generated.
+type webhookData struct {
+	ContentType string `json:"contentType"`
+	Title       string `json:"title"`
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Secret      string `json:"secret,omitempty"`
+}
+
+// GetWebhooks returns the section types that can be refreshed by a webhook, with the organization's webhook for each.
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	method := "GetWebhooks"
+	p := request.GetPersister(r)
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	hooks, err := p.GetWebhooks()
+
+	if err != nil && err != sql.ErrNoRows {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	data := []webhookData{}
+
+	for _, m := range provider.GetSectionMeta() {
+		if !provider.HasWebhook(m.ContentType) {
+			continue
+		}
+
+		d := webhookData{ContentType: m.ContentType, Title: m.Title}
+
+		for _, h := range hooks {
+			if h.ContentType == m.ContentType {
+				d.ID = h.RefID
+				d.URL = webhookURL(p, h.RefID)
+			}
+		}
+
+		data = append(data, d)
+	}
+
+	util.WriteJSON(w, data)
+}
+
+// AddWebhook generates a new webhook for a section type, replacing any it had.
+// The secret is only returned now, for pasting into the settings of the source.
+func AddWebhook(w http.ResponseWriter, r *http.Request) {
+	method := "AddWebhook"
+	p := request.GetPersister(r)
+	section := mux.Vars(r)["section"]
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	if !provider.HasWebhook(section) {
+		writeNotFoundError(w, method, section)
+		return
+	}
+
+	hook := entity.Webhook{ContentType: section, Secret: secrets.GenerateRandom(20)}
+	hook.RefID = uniqueid.Generate()
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	err = p.SetWebhook(hook)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	p.RecordEvent(entity.EventTypeWebhookAdd)
+
+	util.WriteJSON(w, webhookData{ContentType: section, ID: hook.RefID, URL: webhookURL(p, hook.RefID), Secret: hook.Secret})
+}
+
+// DeleteWebhook removes the webhook of a section type, events posted to it are then refused.
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	method := "DeleteWebhook"
+	p := request.GetPersister(r)
+	section := mux.Vars(r)["section"]
+
+	if !p.Context.Administrator {
+		writeForbiddenError(w)
+		return
+	}
+
+	tx, err := request.Db.Beginx()
+	if err != nil {
+		writeTransactionError(w, method, err)
+		return
+	}
+
+	p.Context.Transaction = tx
+
+	_, err = p.DeleteWebhook(section)
+	if err != nil {
+		log.IfErr(tx.Rollback())
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	log.IfErr(tx.Commit())
+
+	p.RecordEvent(entity.EventTypeWebhookDelete)
+
+	util.WriteSuccessEmptyJSON(w)
+}
+
+// ReceiveWebhook is posted events by the source of a section type, such as GitHub, and refreshes the sections
+// they concern in the background, keeping revisions of any changes. The section checks the event is signed
+// with the webhook secret.
+func ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
+	method := "ReceiveWebhook"
+	id := mux.Vars(r)["webhookID"]
+
+	hook, err := request.GetWebhook(id)
+
+	if err == sql.ErrNoRows {
+		writeNotFoundError(w, method, id)
+		return
+	}
+
+	if err != nil {
+		writeGeneralSQLError(w, method, err)
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, webhookMaxBytes))
+	if err != nil {
+		writePayloadError(w, method, err)
+		return
+	}
+
+	match, err := provider.Webhook(hook.ContentType, r, body, hook.Secret)
+
+	if err == provider.ErrWebhookSignature {
+		log.Info(fmt.Sprintf("refused %s webhook event for org %s: %s", hook.ContentType, hook.OrgID, err))
+		writeForbiddenError(w)
+		return
+	}
+
+	if err != nil {
+		writeBadRequestError(w, method, err.Error())
+		return
+	}
+
+	p := request.Persister{Context: request.Context{OrgID: hook.OrgID, Authenticated: true}}
+	due := []entity.PageMeta{}
+
+	if match != nil {
+		meta, err := p.GetExternalPageMeta(hook.ContentType)
+
+		if err != nil && err != sql.ErrNoRows {
+			writeGeneralSQLError(w, method, err)
+			return
+		}
+
+		for _, pm := range meta {
+			if match(pm.Config) {
+				due = append(due, pm)
+			}
+		}
+	}
+
+	for _, pm := range due {
+		queueRefresh(pm)
+	}
+
+	util.WriteJSON(w, struct {
+		Sections int `json:"sections"`
+	}{len(due)})
+}
+
+func webhookURL(p request.Persister, id string) string {
+	return p.Context.GetAppURL(fmt.Sprintf("api/public/webhooks/%s", id))
+}
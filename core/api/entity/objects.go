@@ -88,6 +88,7 @@ type Organization struct {
 	AuthProvider         string `json:"authProvider"`
 	AuthConfig           string `json:"authConfig"`
 	ConversionEndpoint   string `json:"conversionEndpoint"`
+	TemplateFolderID     string `json:"templateFolderId"` // the space whose documents are offered as templates
 	Serial               string `json:"-"`
 	Active               bool   `json:"-"`
 }
@@ -168,6 +169,10 @@ type Document struct {
 	Tags     string `json:"tags"`
 	Template bool   `json:"template"`
 	Layout   string `json:"layout"`
+
+	// the template and version the document was started from, empty when it was not
+	TemplateID      string `json:"templateId"`
+	TemplateVersion string `json:"templateVersion"`
 }
 
 // SetDefaults ensures on blanks and cleans.
@@ -194,17 +199,19 @@ type Attachment struct {
 // Page represents a section within a document.
 type Page struct {
 	BaseEntity
-	OrgID       string  `json:"orgId"`
-	DocumentID  string  `json:"documentId"`
-	UserID      string  `json:"userId"`
-	ContentType string  `json:"contentType"`
-	PageType    string  `json:"pageType"`
-	BlockID     string  `json:"blockId"`
-	Level       uint64  `json:"level"`
-	Sequence    float64 `json:"sequence"`
-	Title       string  `json:"title"`
-	Body        string  `json:"body"`
-	Revisions   uint64  `json:"revisions"`
+	OrgID        string  `json:"orgId"`
+	DocumentID   string  `json:"documentId"`
+	UserID       string  `json:"userId"`
+	ContentType  string  `json:"contentType"`
+	PageType     string  `json:"pageType"`
+	BlockID      string  `json:"blockId"`
+	BlockLinked  bool    `json:"blockLinked"`  // follows changes to the block rather than being a copy of it
+	BlockVersion uint64  `json:"blockVersion"` // version of the block the page has, 0 when not known
+	Level        uint64  `json:"level"`
+	Sequence     float64 `json:"sequence"`
+	Title        string  `json:"title"`
+	Body         string  `json:"body"`
+	Revisions    uint64  `json:"revisions"`
 }
 
 // SetDefaults ensures no blank values.
@@ -239,6 +246,12 @@ type PageMeta struct {
 	RawBody        string    `json:"rawBody"`        // a blob of data
 	Config         string    `json:"config"`         // JSON based custom config for this type
 	ExternalSource bool      `json:"externalSource"` // true indicates data sourced externally
+	ConnectionID   string    `json:"connectionId"`   // shared connection whose secrets are used, empty for the owner's own
+
+	RefreshInterval  int       `json:"refreshInterval"`  // minutes between background refreshes, 0 uses the default
+	Refreshed        time.Time `json:"refreshed"`        // when the data was last fetched
+	RefreshAttempted time.Time `json:"refreshAttempted"` // when a background refresh was last tried
+	RefreshError     string    `json:"refreshError"`     // why the last attempt failed, empty when it worked
 }
 
 // SetDefaults ensures no blank values.
@@ -274,6 +287,7 @@ type Block struct {
 	BaseEntity
 	OrgID          string `json:"orgId"`
 	LabelID        string `json:"folderId"`
+	Shared         bool   `json:"shared"` // offered to every space of the organization, not only its own
 	UserID         string `json:"userId"`
 	ContentType    string `json:"contentType"`
 	PageType       string `json:"pageType"`
@@ -284,10 +298,38 @@ type Block struct {
 	Config         string `json:"config"`         // JSON based custom config for this type
 	ExternalSource bool   `json:"externalSource"` // true indicates data sourced externally
 	Used           uint64 `json:"used"`
+	Version        uint64 `json:"version"` // counts up from 1 each time the block is changed
 	Firstname      string `json:"firstname"`
 	Lastname       string `json:"lastname"`
 }
 
+// BlockRevision holds a previous version of a Block.
+type BlockRevision struct {
+	BaseEntity
+	OrgID     string `json:"orgId"`
+	BlockID   string `json:"blockId"`
+	UserID    string `json:"userId"` // who replaced this version
+	Version   uint64 `json:"version"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	Excerpt   string `json:"excerpt"`
+	RawBody   string `json:"rawBody"`
+	Config    string `json:"config"`
+	Firstname string `json:"firstname"`
+	Lastname  string `json:"lastname"`
+}
+
+// BlockUsage is a page that was inserted from a block, and the version of the block it has.
+type BlockUsage struct {
+	DocumentID    string `json:"documentId"`
+	DocumentTitle string `json:"documentTitle"`
+	LabelID       string `json:"folderId"`
+	PageID        string `json:"pageId"`
+	PageTitle     string `json:"pageTitle"`
+	Linked        bool   `json:"linked"`
+	Version       uint64 `json:"version"`
+}
+
 // DocumentMeta details who viewed the document.
 type DocumentMeta struct {
 	Viewers []DocumentMetaViewer `json:"viewers"`
@@ -361,12 +403,28 @@ type SiteMeta struct {
 // have associated meta data indentifying author, version
 // contact details and more.
 type Template struct {
-	ID          string       `json:"id"`
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	Author      string       `json:"author"`
-	Type        TemplateType `json:"type"`
-	Dated       time.Time    `json:"dated"`
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Author      string             `json:"author"`
+	Type        TemplateType       `json:"type"`
+	Dated       time.Time          `json:"dated"`
+	Category    string             `json:"category"`
+	Version     string             `json:"version"`
+	Variables   []TemplateVariable `json:"variables"`
+}
+
+// TemplateVariable is asked for when starting a document from a template,
+// and fills in each {{name}} in the titles, sections and section settings of the template.
+type TemplateVariable struct {
+	BaseEntity
+	OrgID        string `json:"orgId"`
+	DocumentID   string `json:"documentId"` // the template
+	Name         string `json:"name"`
+	Label        string `json:"label"`        // the prompt, the name when empty
+	Type         string `json:"type"`         // text, date, number or user
+	DefaultValue string `json:"defaultValue"` // "today" for dates and "me" for users fill in the day and the author
+	Sequence     int    `json:"sequence"`
 }
 
 // TemplateType determines who can see a template.
@@ -452,6 +510,41 @@ type Pin struct {
 	Sequence   int    `json:"sequence"`
 }
 
+// Connection holds the secrets for a section type, such as an API token, that an administrator
+// shares with everyone in the organization so sections do not depend on the secrets of their author.
+type Connection struct {
+	BaseEntity
+	OrgID       string    `json:"orgId"`
+	UserID      string    `json:"userId"` // who last set the secrets
+	ContentType string    `json:"contentType"`
+	Name        string    `json:"name"`
+	Secrets     string    `json:"secrets,omitempty"` // JSON object, only ever written, never returned
+	Uses        int       `json:"uses"`
+	LastUsed    time.Time `json:"lastUsed"`
+}
+
+// ConnectionLog records a use of a connection.
+type ConnectionLog struct {
+	ID           uint64    `json:"-"`
+	OrgID        string    `json:"orgId"`
+	ConnectionID string    `json:"connectionId"`
+	UserID       string    `json:"userId"`
+	Firstname    string    `json:"firstname"`
+	Lastname     string    `json:"lastname"`
+	PageID       string    `json:"pageId"`
+	Action       string    `json:"action"` // command, refresh, add, update
+	Created      time.Time `json:"created"`
+}
+
+// Webhook receives events from the source of a section type, such as pushes to a GitHub repository,
+// so the sections concerned are refreshed straight away.
+type Webhook struct {
+	BaseEntity
+	OrgID       string `json:"orgId"`
+	ContentType string `json:"contentType"`
+	Secret      string `json:"secret,omitempty"` // only returned when generated
+}
+
 // UserActivity represents an activity undertaken by a user.
 type UserActivity struct {
 	ID           uint64             `json:"-"`
@@ -552,6 +645,7 @@ const (
 	EventTypeBlockAdd           EventType = "added-reusable-block"
 	EventTypeBlockUpdate        EventType = "updated-reusable-block"
 	EventTypeBlockDelete        EventType = "removed-reusable-block"
+	EventTypeBlockRollback      EventType = "rolled-back-reusable-block"
 	EventTypeTemplateAdd        EventType = "added-document-template"
 	EventTypeTemplateUse        EventType = "used-document-template"
 	EventTypeUserAdd            EventType = "added-user"
@@ -562,6 +656,13 @@ const (
 	EventTypeSystemLicense      EventType = "changed-system-license"
 	EventTypeSystemAuth         EventType = "changed-system-auth"
 	EventTypeSystemSMTP         EventType = "changed-system-smtp"
+	EventTypeSystemSQL          EventType = "changed-system-sql"
+	EventTypeSystemSecrets      EventType = "rotated-system-secrets"
+	EventTypeConnectionAdd      EventType = "added-connection"
+	EventTypeConnectionUpdate   EventType = "updated-connection"
+	EventTypeConnectionDelete   EventType = "removed-connection"
+	EventTypeWebhookAdd         EventType = "added-webhook"
+	EventTypeWebhookDelete      EventType = "removed-webhook"
 	EventTypeSessionStart       EventType = "started-session"
 	EventTypeSearch             EventType = "searched"
 )
@@ -0,0 +1,486 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package docx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF decoding
+	_ "image/jpeg" // register JPEG decoding
+	_ "image/png"  // register PNG decoding
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	textWidth = 9026    // twips between the page margins
+	maxImage  = 5731510 // EMUs between the page margins
+	emuPerPx  = 9525    // EMUs in a 96 DPI pixel
+	indent    = 720     // twips for each level of nesting
+)
+
+// run holds the character formatting of text.
+type run struct {
+	bold, italic, strike, mono bool
+	link                       string
+	color                      string // hex RGB, as set by highlighted code
+}
+
+// rel is a relationship from the document part to a hyperlink or image.
+type rel struct {
+	id, kind, target string
+	external         bool
+}
+
+// media is an image packaged within the document.
+type media struct {
+	path, contentType string
+	data              []byte
+}
+
+// list is a numbering instance, each ordered list restarts its numbering.
+type list struct {
+	ordered bool
+	level   int
+	start   int
+}
+
+// paragraph is the paragraph under construction.
+type paragraph struct {
+	props string
+	runs  bytes.Buffer
+}
+
+type converter struct {
+	body   *bytes.Buffer
+	para   *paragraph
+	rels   []rel
+	media  []media
+	lists  []list
+	links  map[string]string // hyperlink target to relationship ID
+	nested []int             // numbering IDs of the enclosing lists
+	quote  int
+	pre    int
+}
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+	colorStyle = regexp.MustCompile(`(?:^|;)\s*(background-color|color)\s*:\s*#([0-9a-fA-F]{6})`)
+)
+
+func newConverter() *converter {
+	return &converter{body: &bytes.Buffer{}, links: make(map[string]string)}
+}
+
+// blocks converts the children of n, completing any open paragraph.
+func (c *converter) blocks(n *html.Node) {
+	c.children(n, run{})
+	c.close()
+}
+
+func (c *converter) children(n *html.Node, r run) {
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		c.node(ch, r)
+	}
+}
+
+func (c *converter) node(n *html.Node, r run) {
+	if n.Type == html.TextNode {
+		c.text(n.Data, r)
+		return
+	}
+	if n.Type != html.ElementNode {
+		c.children(n, r)
+		return
+	}
+
+	if color := styleColor(n, "color"); len(color) > 0 {
+		r.color = color
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head:
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		c.close()
+		c.open(fmt.Sprintf(`<w:pStyle w:val="Heading%c"/>`, n.Data[1]))
+		c.children(n, r)
+		c.close()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Dl, atom.Dt, atom.Dd, atom.Figure, atom.Figcaption:
+		c.close()
+		c.children(n, r)
+		c.close()
+	case atom.Br:
+		c.ensure()
+		c.para.runs.WriteString("<w:r><w:br/></w:r>")
+	case atom.B, atom.Strong:
+		r.bold = true
+		c.children(n, r)
+	case atom.I, atom.Em, atom.Cite, atom.Var:
+		r.italic = true
+		c.children(n, r)
+	case atom.S, atom.Strike, atom.Del:
+		r.strike = true
+		c.children(n, r)
+	case atom.Code, atom.Tt, atom.Kbd, atom.Samp:
+		r.mono = true
+		c.children(n, r)
+	case atom.A:
+		href := stringutil.NodeAttr(n, "href")
+		if len(href) > 0 && !strings.HasPrefix(href, "javascript:") && !strings.HasPrefix(href, "#") {
+			r.link = c.hyperlink(href)
+		}
+		c.children(n, r)
+	case atom.Ul, atom.Ol:
+		c.close()
+		c.list(n, r)
+	case atom.Blockquote:
+		c.close()
+		c.quote++
+		c.children(n, r)
+		c.close()
+		c.quote--
+	case atom.Pre:
+		c.close()
+		props := `<w:pStyle w:val="Code"/>`
+		if fill := styleColor(n, "background-color"); len(fill) > 0 {
+			props += `<w:shd w:val="clear" w:color="auto" w:fill="` + fill + `"/>`
+		}
+		c.open(props)
+		c.pre++
+		c.children(n, r)
+		c.pre--
+		c.close()
+	case atom.Hr:
+		c.close()
+		c.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+	case atom.Img:
+		if !c.image(stringutil.NodeAttr(n, "src")) {
+			if alt := stringutil.NodeAttr(n, "alt"); len(alt) > 0 {
+				r.italic = true
+				c.text("["+alt+"]", r)
+			}
+		}
+	case atom.Table:
+		c.close()
+		c.table(n)
+	default:
+		c.children(n, r)
+	}
+}
+
+// open starts a new paragraph with the given properties.
+func (c *converter) open(props string) {
+	c.close()
+	c.para = &paragraph{props: props}
+}
+
+// ensure starts a paragraph if none is open, styled for the enclosing quotation or list.
+func (c *converter) ensure() {
+	if c.para != nil {
+		return
+	}
+	switch {
+	case c.quote > 0:
+		c.open(`<w:pStyle w:val="Quote"/>`)
+	case len(c.nested) > 0:
+		c.open(fmt.Sprintf(`<w:ind w:left="%d"/>`, indent*len(c.nested)))
+	default:
+		c.open("")
+	}
+}
+
+// close writes the open paragraph, unless it is empty.
+func (c *converter) close() {
+	p := c.para
+	c.para = nil
+	if p == nil || p.runs.Len() == 0 {
+		return
+	}
+	c.body.WriteString("<w:p>")
+	if len(p.props) > 0 {
+		c.body.WriteString("<w:pPr>" + p.props + "</w:pPr>")
+	}
+	c.body.Write(p.runs.Bytes())
+	c.body.WriteString("</w:p>")
+}
+
+// text adds a run of text to the current paragraph.
+func (c *converter) text(s string, r run) {
+	if c.pre > 0 {
+		r.mono = true
+		c.ensure()
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				c.para.runs.WriteString("<w:r><w:br/></w:r>")
+			}
+			if len(line) > 0 {
+				c.run(strings.Replace(line, "\t", "    ", -1), r)
+			}
+		}
+		return
+	}
+
+	s = whitespace.ReplaceAllString(s, " ")
+	if c.para == nil || c.para.runs.Len() == 0 {
+		s = strings.TrimLeft(s, " ")
+	}
+	if len(s) == 0 {
+		return
+	}
+	c.ensure()
+	c.run(s, r)
+}
+
+// run writes formatted text into the current paragraph.
+func (c *converter) run(s string, r run) {
+	var props bytes.Buffer
+	if len(r.link) > 0 {
+		props.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	}
+	if r.mono {
+		props.WriteString(`<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas" w:cs="Consolas"/>`)
+	}
+	if r.bold {
+		props.WriteString("<w:b/>")
+	}
+	if r.italic {
+		props.WriteString("<w:i/>")
+	}
+	if r.strike {
+		props.WriteString("<w:strike/>")
+	}
+	if len(r.color) > 0 {
+		props.WriteString(`<w:color w:val="` + r.color + `"/>`)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<w:r>")
+	if props.Len() > 0 {
+		b.WriteString("<w:rPr>" + props.String() + "</w:rPr>")
+	}
+	b.WriteString(`<w:t xml:space="preserve">` + escape(s) + "</w:t></w:r>")
+
+	if len(r.link) > 0 {
+		c.para.runs.WriteString(`<w:hyperlink r:id="` + r.link + `">` + b.String() + "</w:hyperlink>")
+		return
+	}
+	c.para.runs.Write(b.Bytes())
+}
+
+// hyperlink returns the relationship ID for the link target.
+func (c *converter) hyperlink(target string) string {
+	if id, ok := c.links[target]; ok {
+		return id
+	}
+	id := c.relate(relHyperlink, target, true)
+	c.links[target] = id
+	return id
+}
+
+// relate adds a relationship from the document, returning its ID.
+func (c *converter) relate(kind, target string, external bool) string {
+	// rId1 and rId2 are taken by the styles and numbering parts
+	id := "rId" + strconv.Itoa(len(c.rels)+3)
+	c.rels = append(c.rels, rel{id: id, kind: kind, target: target, external: external})
+	return id
+}
+
+// list converts an ordered or unordered list into numbered paragraphs.
+func (c *converter) list(n *html.Node, r run) {
+	ordered := n.DataAtom == atom.Ol
+	start := 1
+	if v, err := strconv.Atoi(stringutil.NodeAttr(n, "start")); err == nil {
+		start = v
+	}
+
+	level := len(c.nested)
+	if level > 8 {
+		level = 8
+	}
+	c.lists = append(c.lists, list{ordered: ordered, level: level, start: start})
+	numID := len(c.lists)
+
+	c.nested = append(c.nested, numID)
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		c.open(fmt.Sprintf(`<w:pStyle w:val="ListParagraph"/><w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, level, numID))
+		c.children(li, r)
+		c.close()
+	}
+	c.nested = c.nested[:len(c.nested)-1]
+}
+
+// table converts an HTML table into a Word table with equal column widths.
+func (c *converter) table(n *html.Node) {
+	var rows [][]*html.Node
+	var walk func(*html.Node)
+	walk = func(t *html.Node) {
+		for ch := t.FirstChild; ch != nil; ch = ch.NextSibling {
+			if ch.Type != html.ElementNode {
+				continue
+			}
+			switch ch.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(ch)
+			case atom.Tr:
+				var row []*html.Node
+				for td := ch.FirstChild; td != nil; td = td.NextSibling {
+					if td.Type == html.ElementNode && (td.DataAtom == atom.Td || td.DataAtom == atom.Th) {
+						row = append(row, td)
+					}
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	walk(n)
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return
+	}
+	width := textWidth / columns
+
+	c.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr><w:tblGrid>`)
+	for i := 0; i < columns; i++ {
+		c.body.WriteString(fmt.Sprintf(`<w:gridCol w:w="%d"/>`, width))
+	}
+	c.body.WriteString("</w:tblGrid>")
+
+	for _, row := range rows {
+		header := true
+		for _, td := range row {
+			header = header && td.DataAtom == atom.Th
+		}
+
+		c.body.WriteString("<w:tr>")
+		if header {
+			c.body.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+		for i := 0; i < columns; i++ {
+			c.body.WriteString(fmt.Sprintf(`<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, width))
+			if i < len(row) && row[i].DataAtom == atom.Th {
+				c.body.WriteString(`<w:shd w:val="clear" w:color="auto" w:fill="EDEDED"/>`)
+			}
+			c.body.WriteString("</w:tcPr>")
+			if i < len(row) {
+				c.body.WriteString(c.cell(row[i]))
+			} else {
+				c.body.WriteString("<w:p/>")
+			}
+			c.body.WriteString("</w:tc>")
+		}
+		c.body.WriteString("</w:tr>")
+	}
+
+	c.body.WriteString("</w:tbl>")
+}
+
+// cell converts the content of a table cell, which must end with a paragraph.
+func (c *converter) cell(td *html.Node) string {
+	body, nested, quote := c.body, c.nested, c.quote
+	c.body, c.nested, c.quote = &bytes.Buffer{}, nil, 0
+
+	c.children(td, run{bold: td.DataAtom == atom.Th})
+	c.close()
+	content := c.body.String()
+
+	c.body, c.nested, c.quote = body, nested, quote
+
+	if !strings.HasSuffix(content, "</w:p>") {
+		content += "<w:p/>"
+	}
+	return content
+}
+
+// image embeds an image held in a data URI, returning false if it cannot be embedded.
+func (c *converter) image(src string) bool {
+	if !strings.HasPrefix(src, "data:") {
+		return false
+	}
+	comma := strings.Index(src, ",")
+	if comma < 0 || !strings.HasSuffix(src[:comma], ";base64") {
+		return false
+	}
+	data, err := base64.StdEncoding.DecodeString(src[comma+1:])
+	if err != nil {
+		return false
+	}
+
+	typ := http.DetectContentType(data)
+	xtn, ok := imageTypes[typ]
+	if !ok {
+		return false
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return false
+	}
+
+	n := len(c.media) + 1
+	path := fmt.Sprintf("media/image%d.%s", n, xtn)
+	c.media = append(c.media, media{path: path, contentType: typ, data: data})
+	id := c.relate(relImage, path, false)
+
+	cx, cy := cfg.Width*emuPerPx, cfg.Height*emuPerPx
+	if cx > maxImage {
+		cx, cy = maxImage, int(int64(cy)*maxImage/int64(cx))
+	}
+
+	c.ensure()
+	c.para.runs.WriteString(fmt.Sprintf(drawing, cx, cy, n, n, n, n, id, cx, cy))
+	return true
+}
+
+// styleColor returns the hex RGB value of a colour property set in the style attribute of n.
+func styleColor(n *html.Node, property string) string {
+	for _, m := range colorStyle.FindAllStringSubmatch(stringutil.NodeAttr(n, "style"), -1) {
+		if m[1] == property {
+			return strings.ToUpper(m[2])
+		}
+	}
+	return ""
+}
+
+var imageTypes = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpeg",
+	"image/gif":  "gif",
+	"image/bmp":  "bmp",
+}
+
+// escape returns text made safe for XML content and attributes.
+func escape(s string) string {
+	var b bytes.Buffer
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return ""
+	}
+	return b.String()
+}
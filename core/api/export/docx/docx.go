@@ -0,0 +1,119 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package docx provides the export of Documize HTML into a Word (Office Open XML) document,
+// keeping headings, paragraphs, lists, tables, code blocks, hyperlinks and embedded images.
+package docx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/api/export"
+	api "github.com/documize/community/core/convapi"
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/context"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Export provides the "Export" plugin for the docx format.
+func Export(ctx context.Context, in interface{}) (interface{}, error) {
+	h, ok := in.([]byte)
+	if !ok {
+		return nil, errors.New("expected []byte to export as docx")
+	}
+
+	file, err := FromHTML(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.DocumentExport{Format: "docx", File: file}, nil
+}
+
+// FromHTML converts exported document HTML into a DOCX file.
+func FromHTML(h []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(h))
+	if err != nil {
+		return nil, err
+	}
+
+	props := properties(doc)
+
+	body := stringutil.FindElement(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+
+	c := newConverter()
+	c.blocks(body)
+
+	files := []export.File{
+		{Path: "[Content_Types].xml", Data: []byte(contentTypes(c.media))},
+		{Path: "_rels/.rels", Data: []byte(packageRels)},
+		{Path: "docProps/core.xml", Data: []byte(props)},
+		{Path: "word/document.xml", Data: []byte(documentXML(c.body.String()))},
+		{Path: "word/styles.xml", Data: []byte(styles)},
+		{Path: "word/numbering.xml", Data: []byte(numbering(c.lists))},
+		{Path: "word/_rels/document.xml.rels", Data: []byte(documentRels(c.rels))},
+	}
+	for _, m := range c.media {
+		files = append(files, export.File{Path: "word/" + m.path, Data: m.data})
+	}
+
+	return export.Zip(files)
+}
+
+// properties returns the core document properties taken from the HTML head.
+func properties(doc *html.Node) string {
+	var title string
+	meta := make(map[string]string)
+
+	if head := stringutil.FindElement(doc, atom.Head); head != nil {
+		if t := stringutil.FindElement(head, atom.Title); t != nil {
+			title = strings.TrimSpace(stringutil.NodeText(t))
+		}
+		for c := head.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Meta {
+				meta[stringutil.NodeAttr(c, "name")] = stringutil.NodeAttr(c, "content")
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString(xmlHeader)
+	b.WriteString(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`)
+	b.WriteString("<dc:title>" + escape(title) + "</dc:title>")
+	if len(meta["author"]) > 0 {
+		b.WriteString("<dc:creator>" + escape(meta["author"]) + "</dc:creator>")
+	}
+	if len(meta["description"]) > 0 {
+		b.WriteString("<dc:description>" + escape(meta["description"]) + "</dc:description>")
+	}
+	if len(meta["keywords"]) > 0 {
+		b.WriteString("<cp:keywords>" + escape(meta["keywords"]) + "</cp:keywords>")
+	}
+	if len(meta["space"]) > 0 {
+		b.WriteString("<cp:category>" + escape(meta["space"]) + "</cp:category>")
+	}
+	for _, d := range []struct{ name, element string }{{"created", "dcterms:created"}, {"revised", "dcterms:modified"}} {
+		if t, err := time.Parse(time.RFC3339, meta[d.name]); err == nil {
+			b.WriteString(fmt.Sprintf(`<%s xsi:type="dcterms:W3CDTF">%s</%s>`, d.element, t.UTC().Format(time.RFC3339), d.element))
+		}
+	}
+	b.WriteString("</cp:coreProperties>")
+
+	return b.String()
+}
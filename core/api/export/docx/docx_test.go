@@ -0,0 +1,128 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package docx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/api/export/docx"
+	api "github.com/documize/community/core/convapi"
+)
+
+func TestExport(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2000, 100))
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes())
+
+	in := `<html><head><title>Policy &amp; Rules</title><meta name="author" content="Jane Doe"></head><body>` +
+		`<h1 id="page-1">Intro</h1><p>Read <a href="https://documize.com/?a=1&amp;b=2">this</a> <b>now</b>.</p>` +
+		`<ul><li>one<ol start="4"><li>four</li></ol></li></ul>` +
+		`<table><tr><th>Name</th><th>Value</th></tr><tr><td><p>a &lt; b</p></td></tr></table>` +
+		`<pre>line 1
+	line 2</pre><pre class="code-highlight" style="background-color: #002b36; color: #93a1a1;"><span style="color: #859900;">func</span> main()</pre><img src="` + src + `"><img src="https://example.com/x.png" alt="remote"></body></html>`
+
+	out, err := docx.Export(nil, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, ok := out.(*api.DocumentExport)
+	if !ok || exp.Format != "docx" {
+		t.Fatal("wrong export returned")
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(exp.File), int64(len(exp.File)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			if err := wellFormed(data); err != nil {
+				t.Errorf("%s is not well formed: %v", f.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/document.xml", "word/styles.xml", "word/numbering.xml", "word/_rels/document.xml.rels", "docProps/core.xml", "word/media/image1.png"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	document := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Intro</w:t>`,
+		`<w:hyperlink r:id="rId3"><w:r><w:rPr><w:rStyle w:val="Hyperlink"/></w:rPr><w:t xml:space="preserve">this</w:t>`,
+		`<w:rPr><w:b/></w:rPr><w:t xml:space="preserve">now</w:t>`,
+		`<w:ilvl w:val="0"/><w:numId w:val="1"/>`,
+		`<w:ilvl w:val="1"/><w:numId w:val="2"/>`,
+		`<w:tblHeader/>`,
+		`a &lt; b`,
+		`<w:pStyle w:val="Code"/>`,
+		`<w:t xml:space="preserve">    line 2</w:t>`,
+		`<w:pStyle w:val="Code"/><w:shd w:val="clear" w:color="auto" w:fill="002B36"/>`,
+		`<w:color w:val="859900"/></w:rPr><w:t xml:space="preserve">func</w:t>`,
+		`<w:color w:val="93A1A1"/></w:rPr><w:t xml:space="preserve"> main()</w:t>`,
+		`<wp:extent cx="5731510" cy="286575"/>`,
+		`[remote]`,
+	} {
+		if !strings.Contains(document, want) {
+			t.Errorf("expected %s in document", want)
+		}
+	}
+
+	if !strings.Contains(parts["word/_rels/document.xml.rels"], `Target="https://documize.com/?a=1&amp;b=2" TargetMode="External"`) {
+		t.Error("hyperlink relationship missing")
+	}
+	if !strings.Contains(parts["word/numbering.xml"], `<w:lvlOverride w:ilvl="1"><w:startOverride w:val="4"/>`) {
+		t.Error("ordered list does not start at 4")
+	}
+	if !strings.Contains(parts["docProps/core.xml"], "<dc:title>Policy &amp; Rules</dc:title><dc:creator>Jane Doe</dc:creator>") {
+		t.Error("document properties missing")
+	}
+}
+
+func wellFormed(data []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
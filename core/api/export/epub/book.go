@@ -0,0 +1,353 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package epub
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// book is the parsed content, split into chapters.
+type book struct {
+	title     string
+	meta      map[string]string
+	chapters  []*chapter
+	headings  []heading
+	resources []resource
+	files     map[string]string // element ID to chapter file
+	names     map[string]bool   // resource paths in use
+}
+
+type chapter struct {
+	file  string
+	title string
+	nodes []*html.Node
+}
+
+type heading struct {
+	level int
+	id    string
+	title string
+	file  string
+}
+
+type resource struct {
+	path      string
+	mediaType string
+	data      []byte
+}
+
+// parse splits the HTML body into chapters at each top level heading,
+// extracting embedded resources and pointing internal links at the right chapter.
+func parse(h []byte) (*book, error) {
+	doc, err := html.Parse(bytes.NewReader(h))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &book{meta: make(map[string]string), files: make(map[string]string), names: make(map[string]bool)}
+
+	if head := stringutil.FindElement(doc, atom.Head); head != nil {
+		if t := stringutil.FindElement(head, atom.Title); t != nil {
+			b.title = strings.TrimSpace(stringutil.NodeText(t))
+		}
+		for c := head.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Meta {
+				b.meta[stringutil.NodeAttr(c, "name")] = stringutil.NodeAttr(c, "content")
+			}
+		}
+	}
+	if len(b.title) == 0 {
+		b.title = "Untitled"
+	}
+
+	body := stringutil.FindElement(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+
+	clean(body)
+
+	var current *chapter
+	for n := body.FirstChild; n != nil; {
+		next := n.NextSibling
+		body.RemoveChild(n)
+
+		if current == nil || (n.Type == html.ElementNode && n.DataAtom == atom.H1 && len(current.nodes) > 0) {
+			current = &chapter{file: fmt.Sprintf("chapter-%d.xhtml", len(b.chapters)+1)}
+			b.chapters = append(b.chapters, current)
+		}
+		current.nodes = append(current.nodes, n)
+		b.walk(n, current)
+
+		n = next
+	}
+
+	if len(b.chapters) == 0 {
+		b.chapters = append(b.chapters, &chapter{file: "chapter-1.xhtml"})
+	}
+	for _, c := range b.chapters {
+		if len(c.title) == 0 {
+			c.title = b.title
+		}
+		for _, n := range c.nodes {
+			b.relink(n, c)
+		}
+	}
+
+	return b, nil
+}
+
+// walk records headings and identifiers within the chapter and extracts embedded resources.
+func (b *book) walk(n *html.Node, c *chapter) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			id := stringutil.NodeAttr(n, "id")
+			if len(id) == 0 {
+				id = fmt.Sprintf("heading-%d", len(b.headings)+1)
+				n.Attr = append(n.Attr, html.Attribute{Key: "id", Val: id})
+			}
+			title := strings.TrimSpace(stringutil.NodeText(n))
+			if len(c.title) == 0 {
+				c.title = title
+			}
+			b.headings = append(b.headings, heading{level: int(n.Data[1] - '0'), id: id, title: title, file: c.file})
+		}
+
+		for i, a := range n.Attr {
+			switch a.Key {
+			case "id":
+				b.files[a.Val] = c.file
+			case "src", "href":
+				if strings.HasPrefix(a.Val, "data:") {
+					if p, ok := b.resource(a.Val); ok {
+						n.Attr[i].Val = p
+					}
+				}
+			}
+		}
+	}
+
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		b.walk(ch, c)
+	}
+}
+
+// relink points fragment links at the chapter holding the target.
+func (b *book) relink(n *html.Node, c *chapter) {
+	if n.Type == html.ElementNode && n.DataAtom == atom.A {
+		for i, a := range n.Attr {
+			if a.Key != "href" || !strings.HasPrefix(a.Val, "#") {
+				continue
+			}
+			if file, ok := b.files[a.Val[1:]]; ok && file != c.file {
+				n.Attr[i].Val = file + a.Val
+			}
+		}
+	}
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		b.relink(ch, c)
+	}
+}
+
+// resource packages a base64 data URI, which may carry the original file name as a name parameter.
+func (b *book) resource(uri string) (string, bool) {
+	comma := strings.Index(uri, ",")
+	if comma < 0 {
+		return "", false
+	}
+	params := strings.Split(uri[len("data:"):comma], ";")
+	if params[len(params)-1] != "base64" {
+		return "", false
+	}
+	data, err := base64.StdEncoding.DecodeString(uri[comma+1:])
+	if err != nil {
+		return "", false
+	}
+
+	mediaType := params[0]
+	if len(mediaType) == 0 {
+		mediaType = "application/octet-stream"
+	}
+
+	name := ""
+	for _, p := range params[1 : len(params)-1] {
+		if strings.HasPrefix(p, "name=") {
+			name, _ = url.QueryUnescape(p[len("name="):])
+		}
+	}
+	name = unsafeChars.ReplaceAllString(path.Base(strings.Replace(name, `\`, "/", -1)), "-")
+	if name == "." || name == "/" || name == ".." || len(name) == 0 {
+		name = fmt.Sprintf("resource-%d%s", len(b.resources)+1, extension(mediaType))
+	}
+
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; b.names[name]; i++ {
+		name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+	}
+	b.names[name] = true
+
+	p := "resources/" + name
+	b.resources = append(b.resources, resource{path: p, mediaType: mediaType, data: data})
+	return p, true
+}
+
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// extension returns a file extension for the media type.
+func extension(mediaType string) string {
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/svg+xml":
+		return ".svg"
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// xhtml renders a chapter as an XHTML content document.
+func (b *book) xhtml(c *chapter) ([]byte, error) {
+	var o bytes.Buffer
+	o.WriteString(xmlHeader)
+	o.WriteString(`<!DOCTYPE html>` + "\n")
+	o.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="en" lang="en">`)
+	o.WriteString(`<head><meta charset="utf-8"/><title>` + escape(c.title) + `</title><link rel="stylesheet" type="text/css" href="style.css"/></head><body>`)
+	for _, n := range c.nodes {
+		if err := html.Render(&o, n); err != nil {
+			return nil, err
+		}
+	}
+	o.WriteString(`</body></html>`)
+	return o.Bytes(), nil
+}
+
+// nav renders the navigation document, nesting entries by heading level.
+func (b *book) nav() []byte {
+	var o bytes.Buffer
+	o.WriteString(xmlHeader)
+	o.WriteString(`<!DOCTYPE html>` + "\n")
+	o.WriteString(`<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="en" lang="en">`)
+	o.WriteString(`<head><meta charset="utf-8"/><title>` + escape(b.title) + `</title><link rel="stylesheet" type="text/css" href="style.css"/></head><body>`)
+	o.WriteString(`<nav epub:type="toc" id="toc"><h1>Contents</h1>`)
+
+	headings := b.headings
+	if len(headings) == 0 {
+		for _, c := range b.chapters {
+			headings = append(headings, heading{level: 1, title: c.title, file: c.file})
+		}
+	}
+
+	var stack []int
+	for i, h := range headings {
+		switch {
+		case i == 0:
+			o.WriteString("<ol>")
+			stack = []int{h.level}
+		case h.level > stack[len(stack)-1]:
+			o.WriteString("<ol>")
+			stack = append(stack, h.level)
+		default:
+			o.WriteString("</li>")
+			for len(stack) > 1 && h.level <= stack[len(stack)-2] {
+				o.WriteString("</ol></li>")
+				stack = stack[:len(stack)-1]
+			}
+			stack[len(stack)-1] = h.level
+		}
+
+		href := h.file
+		if len(h.id) > 0 {
+			href += "#" + h.id
+		}
+		title := h.title
+		if len(title) == 0 {
+			title = "Untitled"
+		}
+		o.WriteString(`<li><a href="` + escape(href) + `">` + escape(title) + `</a>`)
+	}
+	o.WriteString("</li>")
+	for len(stack) > 1 {
+		o.WriteString("</ol></li>")
+		stack = stack[:len(stack)-1]
+	}
+	o.WriteString("</ol></nav></body></html>")
+
+	return o.Bytes()
+}
+
+// clean removes content that cannot be used within a book.
+func clean(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Script, atom.Style, atom.Iframe, atom.Object, atom.Embed, atom.Form, atom.Input, atom.Button:
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+			// drop event handlers and attributes that are not valid XML names
+			var attrs []html.Attribute
+			for _, a := range c.Attr {
+				if !strings.HasPrefix(a.Key, "on") && xmlName(a.Key) {
+					attrs = append(attrs, a)
+				}
+			}
+			c.Attr = attrs
+		}
+		clean(c)
+		c = next
+	}
+}
+
+func xmlName(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+	if err := xml.EscapeText(&b, []byte(s)); err != nil {
+		return ""
+	}
+	return b.String()
+}
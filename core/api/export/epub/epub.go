@@ -0,0 +1,164 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package epub provides the export of Documize HTML into an EPUB 3 book for offline reading.
+// Top level headings start new chapters, the navigation document is built from every heading,
+// and attachments given as data URIs are packaged as resources within the book.
+package epub
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/documize/community/core/api/export"
+	api "github.com/documize/community/core/convapi"
+	"golang.org/x/net/context"
+)
+
+// Export provides the "Export" plugin for the epub format.
+func Export(ctx context.Context, in interface{}) (interface{}, error) {
+	h, ok := in.([]byte)
+	if !ok {
+		return nil, errors.New("expected []byte to export as epub")
+	}
+
+	file, err := FromHTML(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.DocumentExport{Format: "epub", File: file}, nil
+}
+
+// FromHTML converts exported document HTML into an EPUB file.
+func FromHTML(h []byte) ([]byte, error) {
+	b, err := parse(h)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	z := zip.NewWriter(&out)
+
+	// the mimetype must come first and be stored without compression
+	w, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write([]byte("application/epub+zip")); err != nil {
+		return nil, err
+	}
+
+	files := []export.File{
+		{Path: "META-INF/container.xml", Data: []byte(container)},
+		{Path: "OEBPS/content.opf", Data: b.opf(h)},
+		{Path: "OEBPS/nav.xhtml", Data: b.nav()},
+		{Path: "OEBPS/style.css", Data: []byte(stylesheet)},
+	}
+	for _, c := range b.chapters {
+		x, err := b.xhtml(c)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, export.File{Path: "OEBPS/" + c.file, Data: x})
+	}
+	for _, r := range b.resources {
+		files = append(files, export.File{Path: "OEBPS/" + r.path, Data: r.data})
+	}
+
+	for _, f := range files {
+		w, err = z.Create(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(f.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = z.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// opf returns the package document describing the metadata, content and reading order of the book.
+func (b *book) opf(h []byte) []byte {
+	modified := time.Now().UTC()
+	if t, err := time.Parse(time.RFC3339, b.meta["revised"]); err == nil {
+		modified = t.UTC()
+	}
+
+	var o bytes.Buffer
+	o.WriteString(xmlHeader)
+	o.WriteString(`<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid" xml:lang="en">`)
+	o.WriteString(`<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+	o.WriteString(`<dc:identifier id="uid">` + identifier(h) + `</dc:identifier>`)
+	o.WriteString(`<dc:title>` + escape(b.title) + `</dc:title>`)
+	o.WriteString(`<dc:language>en</dc:language>`)
+	if len(b.meta["author"]) > 0 {
+		o.WriteString(`<dc:creator>` + escape(b.meta["author"]) + `</dc:creator>`)
+	}
+	if len(b.meta["description"]) > 0 {
+		o.WriteString(`<dc:description>` + escape(b.meta["description"]) + `</dc:description>`)
+	}
+	if len(b.meta["keywords"]) > 0 {
+		o.WriteString(`<dc:subject>` + escape(b.meta["keywords"]) + `</dc:subject>`)
+	}
+	if t, err := time.Parse(time.RFC3339, b.meta["created"]); err == nil {
+		o.WriteString(`<dc:date>` + t.UTC().Format(time.RFC3339) + `</dc:date>`)
+	}
+	o.WriteString(`<meta property="dcterms:modified">` + modified.Format("2006-01-02T15:04:05Z") + `</meta>`)
+	o.WriteString(`</metadata><manifest>`)
+	o.WriteString(`<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>`)
+	o.WriteString(`<item id="style" href="style.css" media-type="text/css"/>`)
+	for i, c := range b.chapters {
+		o.WriteString(fmt.Sprintf(`<item id="chapter-%d" href="%s" media-type="application/xhtml+xml"/>`, i+1, c.file))
+	}
+	for i, r := range b.resources {
+		o.WriteString(fmt.Sprintf(`<item id="resource-%d" href="%s" media-type="%s"/>`, i+1, escape(r.path), escape(r.mediaType)))
+	}
+	o.WriteString(`</manifest><spine>`)
+	for i := range b.chapters {
+		o.WriteString(fmt.Sprintf(`<itemref idref="chapter-%d"/>`, i+1))
+	}
+	o.WriteString(`</spine></package>`)
+
+	return o.Bytes()
+}
+
+// identifier derives a stable UUID URN from the content.
+func identifier(h []byte) string {
+	s := sha1.Sum(h)
+	s[6] = (s[6] & 0x0f) | 0x50 // version 5
+	s[8] = (s[8] & 0x3f) | 0x80 // variant
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", s[0:4], s[4:6], s[6:8], s[8:10], s[10:16])
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+const container = xmlHeader + `<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">` +
+	`<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`
+
+const stylesheet = `body { font-family: serif; line-height: 1.5; }
+h1, h2, h3, h4, h5, h6 { font-family: sans-serif; line-height: 1.25; }
+img { max-width: 100%; }
+pre { white-space: pre-wrap; font-size: 0.85em; background: #f4f4f4; padding: 0.5em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 0.25em 0.5em; }
+blockquote { margin-left: 1em; padding-left: 1em; border-left: 3px solid #ccc; }
+nav ol { list-style: none; }
+`
@@ -0,0 +1,106 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package epub_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/api/export/epub"
+	api "github.com/documize/community/core/convapi"
+)
+
+func TestExport(t *testing.T) {
+	pdf := "data:application/pdf;name=wiring+diagram.pdf;base64," + base64.StdEncoding.EncodeToString([]byte("%PDF-1.4"))
+
+	in := `<html><head><title>Field Ops</title><meta name="author" content="Jane Doe"><meta name="revised" content="2016-05-01T10:00:00Z"></head><body>` +
+		`<h1 id="document-d1">Runbook</h1><h2 id="page-p1">Start</h2><p onclick="x()">Open <a href="` + pdf + `">the diagram</a><br></p>` +
+		`<h3>Detail</h3><p>x</p><h2 id="page-p2">Stop</h2><script>alert(1)</script>` +
+		`<h1 id="document-d2">Faults</h1><p>See <a href="#page-p1">start</a>.</p></body></html>`
+
+	out, err := epub.Export(nil, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, ok := out.(*api.DocumentExport)
+	if !ok || exp.Format != "epub" {
+		t.Fatal("wrong export returned")
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(exp.File), int64(len(exp.File)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if z.File[0].Name != "mimetype" || z.File[0].Method != zip.Store {
+		t.Error("mimetype must be first and stored")
+	}
+
+	parts := make(map[string]string)
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[f.Name] = string(data)
+
+		if strings.HasSuffix(f.Name, ".xhtml") || strings.HasSuffix(f.Name, ".opf") || strings.HasSuffix(f.Name, ".xml") {
+			if err := wellFormed(data); err != nil {
+				t.Errorf("%s is not well formed: %v", f.Name, err)
+			}
+		}
+	}
+
+	checks := []struct{ part, want string }{
+		{"OEBPS/content.opf", `<dc:title>Field Ops</dc:title>`},
+		{"OEBPS/content.opf", `<dc:creator>Jane Doe</dc:creator>`},
+		{"OEBPS/content.opf", `<meta property="dcterms:modified">2016-05-01T10:00:00Z</meta>`},
+		{"OEBPS/content.opf", `<item id="resource-1" href="resources/wiring-diagram.pdf" media-type="application/pdf"/>`},
+		{"OEBPS/content.opf", `<itemref idref="chapter-2"/>`},
+		{"OEBPS/nav.xhtml", `<ol><li><a href="chapter-1.xhtml#document-d1">Runbook</a><ol><li><a href="chapter-1.xhtml#page-p1">Start</a><ol><li><a href="chapter-1.xhtml#heading-3">Detail</a></li></ol></li><li><a href="chapter-1.xhtml#page-p2">Stop</a></li></ol></li><li><a href="chapter-2.xhtml#document-d2">Faults</a></li></ol>`},
+		{"OEBPS/chapter-1.xhtml", `<a href="resources/wiring-diagram.pdf">the diagram</a><br/>`},
+		{"OEBPS/chapter-2.xhtml", `<a href="chapter-1.xhtml#page-p1">start</a>`},
+		{"OEBPS/resources/wiring-diagram.pdf", `%PDF-1.4`},
+	}
+	for _, c := range checks {
+		if !strings.Contains(parts[c.part], c.want) {
+			t.Errorf("expected %s in %s", c.want, c.part)
+		}
+	}
+
+	if strings.Contains(parts["OEBPS/chapter-1.xhtml"], "onclick") || strings.Contains(parts["OEBPS/chapter-1.xhtml"], "alert") {
+		t.Error("scripts not removed")
+	}
+}
+
+func wellFormed(data []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package export assembles documents into the HTML consumed by the "Export" plugins,
+// resolving attachment and content links, and packages multiple exported files into a zip archive.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Document holds everything required to export a single document.
+type Document struct {
+	Document    entity.Document
+	Pages       []entity.Page
+	Attachments []entity.Attachment
+	Author      string // optional, full name of the document owner
+	Space       string // optional, name of the containing space
+}
+
+// Link describes a reference found in page content, as passed to a Resolver.
+type Link struct {
+	Attribute        string // "href" or "src"
+	URL              string // value as found in the content
+	LinkType         string // Documize content link type: section, tab, document or file
+	LinkID           string // identifies the link record for Documize content links
+	TargetDocumentID string
+	TargetID         string
+	AttachmentID     string // set when URL refers to a document attachment
+}
+
+// Resolver returns the replacement URL for a link in exported content.
+// Returning the original URL leaves the link untouched.
+type Resolver func(l Link) string
+
+// HTML builds the complete HTML for the document, with page titles as headings
+// taken from the page level and links passed through the resolver (which may be nil).
+// Document metadata is written as meta tags within the head.
+func (d Document) HTML(resolve Resolver) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("<html><head><title>")
+	b.WriteString(html.EscapeString(d.Document.Title))
+	b.WriteString("</title>")
+
+	writeMeta(&b, "author", d.Author)
+	writeMeta(&b, "space", d.Space)
+	writeMeta(&b, "description", d.Document.Excerpt)
+	writeMeta(&b, "keywords", strings.TrimSpace(strings.Replace(d.Document.Tags, "#", " ", -1)))
+	if !d.Document.Created.IsZero() {
+		writeMeta(&b, "created", d.Document.Created.UTC().Format(time.RFC3339))
+	}
+	if !d.Document.Revised.IsZero() {
+		writeMeta(&b, "revised", d.Document.Revised.UTC().Format(time.RFC3339))
+	}
+
+	b.WriteString("</head><body>")
+	d.writePages(&b, 0, resolve)
+	b.WriteString("</body></html>")
+
+	return b.Bytes()
+}
+
+// Book builds the HTML for a collection of documents, such as a space, as a single book.
+// Each document starts with its title as a top level heading, with its pages nested beneath it.
+// The resolver used for each document is returned by resolve (which may return nil).
+func Book(title string, docs []Document, resolve func(d Document) Resolver) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("<html><head><title>")
+	b.WriteString(html.EscapeString(title))
+	b.WriteString("</title>")
+
+	writeMeta(&b, "space", title)
+
+	var revised time.Time
+	for _, d := range docs {
+		if d.Document.Revised.After(revised) {
+			revised = d.Document.Revised
+		}
+	}
+	if !revised.IsZero() {
+		writeMeta(&b, "revised", revised.UTC().Format(time.RFC3339))
+	}
+
+	b.WriteString("</head><body>")
+
+	for _, d := range docs {
+		b.WriteString(fmt.Sprintf(`<h1 id="%s">`, DocumentAnchor(d.Document.RefID)))
+		b.Write(stringutil.EscapeHTMLcomplexCharsByte([]byte(html.EscapeString(d.Document.Title))))
+		b.WriteString("</h1>")
+		d.writePages(&b, 1, resolve(d))
+	}
+
+	b.WriteString("</body></html>")
+
+	return b.Bytes()
+}
+
+// writePages writes each page as a heading followed by its content, with heading levels moved down by offset.
+func (d Document) writePages(b *bytes.Buffer, offset uint64, resolve Resolver) {
+	for _, page := range d.Pages {
+		level := HeadingLevel(page.Level + offset)
+		body := page.Body
+		if resolve != nil {
+			body = ResolveLinks(body, resolve)
+		}
+		if len(page.RefID) > 0 {
+			b.WriteString(fmt.Sprintf(`<h%d id="%s">`, level, PageAnchor(page.RefID)))
+		} else {
+			b.WriteString(fmt.Sprintf("<h%d>", level))
+		}
+		b.Write(stringutil.EscapeHTMLcomplexCharsByte([]byte(page.Title)))
+		b.WriteString(fmt.Sprintf("</h%d>", level))
+		b.Write(stringutil.EscapeHTMLcomplexCharsByte([]byte(body)))
+	}
+}
+
+func writeMeta(b *bytes.Buffer, name, content string) {
+	if len(content) == 0 {
+		return
+	}
+	b.WriteString(fmt.Sprintf(`<meta name="%s" content="%s">`, name, html.EscapeString(content)))
+}
+
+// DocumentAnchor returns the HTML identifier given to the heading of a document within a book.
+func DocumentAnchor(documentID string) string {
+	return "document-" + documentID
+}
+
+// PageAnchor returns the HTML identifier given to the heading of the page.
+func PageAnchor(pageID string) string {
+	return "page-" + pageID
+}
+
+// HeadingLevel maps a page level onto an HTML heading level between 1 and 6.
+func HeadingLevel(level uint64) int {
+	if level < 1 {
+		return 1
+	}
+	if level > 6 {
+		return 6
+	}
+	return int(level)
+}
+
+var attachmentURL = regexp.MustCompile(`/attachments/[^/]+/([^/?#]+)`)
+
+// AttachmentID returns the attachment identifier from an attachment download URL, or "" if none.
+func AttachmentID(url string) string {
+	m := attachmentURL.FindStringSubmatch(url)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+// ResolveLinks passes every href and src attribute found in the HTML body through the resolver.
+func ResolveLinks(body string, resolve Resolver) string {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(body), context)
+	if err != nil {
+		return body
+	}
+
+	var b bytes.Buffer
+	for _, n := range nodes {
+		resolveNode(n, resolve)
+		if err = html.Render(&b, n); err != nil {
+			return body
+		}
+	}
+
+	return b.String()
+}
+
+func resolveNode(n *html.Node, resolve Resolver) {
+	if n.Type == html.ElementNode {
+		var l Link
+		for _, a := range n.Attr {
+			switch a.Key {
+			case "data-link-type":
+				l.LinkType = a.Val
+			case "data-link-id":
+				l.LinkID = a.Val
+			case "data-link-target-document-id":
+				l.TargetDocumentID = a.Val
+			case "data-link-target-id":
+				l.TargetID = a.Val
+			}
+		}
+		for i, a := range n.Attr {
+			if a.Key != "href" && a.Key != "src" {
+				continue
+			}
+			l.Attribute = a.Key
+			l.URL = a.Val
+			l.AttachmentID = AttachmentID(a.Val)
+			if l.LinkType == "file" && len(l.TargetID) > 0 {
+				l.AttachmentID = l.TargetID
+			}
+			n.Attr[i].Val = resolve(l)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		resolveNode(c, resolve)
+	}
+}
+
+// AttachmentPaths returns the relative path of each attachment within the given directory, keyed by attachment ID.
+func AttachmentPaths(dir string, attachments []entity.Attachment) map[string]string {
+	paths := make(map[string]string)
+	used := make(map[string]bool)
+
+	for _, a := range attachments {
+		name := path.Base(strings.Replace(a.Filename, `\`, "/", -1))
+		if name == "." || name == "/" || name == ".." {
+			name = a.RefID
+		}
+		ext := path.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		used[name] = true
+		paths[a.RefID] = path.Join(dir, name)
+	}
+
+	return paths
+}
+
+// File is an entry within a zip archive.
+type File struct {
+	Path string
+	Data []byte
+}
+
+// Zip packages the files into a zip archive.
+func Zip(files []File) ([]byte, error) {
+	var b bytes.Buffer
+	z := zip.NewWriter(&b)
+
+	for _, f := range files {
+		w, err := z.Create(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(f.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// Names hands out unique, URL and file system safe names.
+type Names map[string]bool
+
+// Unique returns a slug of the given title that has not been handed out before.
+func (n Names) Unique(title string) string {
+	base := stringutil.MakeSlug(title)
+	if len(base) == 0 {
+		base = "untitled"
+	}
+	name := base
+	for i := 2; n[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	n[name] = true
+	return name
+}
@@ -0,0 +1,107 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/api/entity"
+)
+
+func TestDocumentHTML(t *testing.T) {
+	d := Document{
+		Document: entity.Document{Title: "Policies & Rules"},
+		Pages: []entity.Page{
+			{Level: 1, Title: "Intro", Body: `<p>See <a href="https://x.com/api/public/attachments/org1/att1">file</a></p>`},
+			{Level: 9, Title: "Deep", Body: `<p><a data-documize="true" data-link-type="document" data-link-target-document-id="doc2" href="/link/document/l1">other</a></p>`},
+		},
+	}
+
+	resolve := func(l Link) string {
+		if l.AttachmentID == "att1" {
+			return "policies/file.pdf"
+		}
+		if l.LinkType == "document" && l.TargetDocumentID == "doc2" {
+			return "other.md"
+		}
+		return l.URL
+	}
+
+	h := string(d.HTML(resolve))
+
+	for _, want := range []string{
+		"<title>Policies &amp; Rules</title>",
+		"<h1>Intro</h1>",
+		"<h6>Deep</h6>",
+		`href="policies/file.pdf"`,
+		`href="other.md"`,
+	} {
+		if !strings.Contains(h, want) {
+			t.Errorf("expected %q in %s", want, h)
+		}
+	}
+}
+
+func TestAttachmentPaths(t *testing.T) {
+	paths := AttachmentPaths("doc", []entity.Attachment{
+		{BaseEntity: entity.BaseEntity{RefID: "a"}, Filename: "report.pdf"},
+		{BaseEntity: entity.BaseEntity{RefID: "b"}, Filename: "report.pdf"},
+		{BaseEntity: entity.BaseEntity{RefID: "c"}, Filename: `..\..\etc\passwd`},
+	})
+
+	if paths["a"] != "doc/report.pdf" || paths["b"] != "doc/report-2.pdf" || paths["c"] != "doc/passwd" {
+		t.Errorf("unexpected paths %v", paths)
+	}
+}
+
+func TestNamesAndZip(t *testing.T) {
+	n := Names{}
+	a, b := n.Unique("Read Me"), n.Unique("Read Me")
+	if a == b {
+		t.Errorf("names not unique: %s %s", a, b)
+	}
+
+	data, err := Zip([]File{{Path: a + ".md", Data: []byte("hello")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(z.File) != 1 || z.File[0].Name != a+".md" {
+		t.Error("unexpected zip content")
+	}
+}
+
+func TestBook(t *testing.T) {
+	docs := []Document{
+		{Document: entity.Document{BaseEntity: entity.BaseEntity{RefID: "d1"}, Title: "Runbook"},
+			Pages: []entity.Page{{BaseEntity: entity.BaseEntity{RefID: "p1"}, Level: 1, Title: "Start", Body: `<p>go</p>`}}},
+		{Document: entity.Document{BaseEntity: entity.BaseEntity{RefID: "d2"}, Title: "Faults"}},
+	}
+
+	h := string(Book("Field Ops", docs, func(d Document) Resolver { return nil }))
+
+	for _, want := range []string{
+		"<title>Field Ops</title>",
+		`<h1 id="document-d1">Runbook</h1><h2 id="page-p1">Start</h2><p>go</p>`,
+		`<h1 id="document-d2">Faults</h1>`,
+	} {
+		if !strings.Contains(h, want) {
+			t.Errorf("expected %q in %s", want, h)
+		}
+	}
+}
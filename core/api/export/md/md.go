@@ -0,0 +1,333 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package md provides the export of Documize HTML into Markdown.
+package md
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+
+	api "github.com/documize/community/core/convapi"
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/context"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Export provides the standard interface for the export of a document,
+// the given HTML bytes are converted to Markdown in the returned *api.DocumentExport.
+func Export(ctx context.Context, in interface{}) (interface{}, error) {
+	md, err := FromHTML(in.([]byte))
+	if err != nil {
+		return nil, err
+	}
+	return &api.DocumentExport{Format: "md", File: md}, nil
+}
+
+// FromHTML converts HTML into Markdown, keeping headings, lists, tables, code blocks, links and images.
+func FromHTML(h []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(h))
+	if err != nil {
+		return nil, err
+	}
+
+	root := stringutil.FindElement(doc, atom.Body)
+	if root == nil {
+		root = doc
+	}
+
+	var c converter
+	md := c.children(root)
+
+	return []byte(tidy(md)), nil
+}
+
+type converter struct {
+	pre int // > 0 when inside <pre>
+}
+
+func (c *converter) children(n *html.Node) string {
+	var b bytes.Buffer
+	for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+		b.WriteString(c.node(ch))
+	}
+	return b.String()
+}
+
+func (c *converter) node(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		if c.pre > 0 {
+			return n.Data
+		}
+		return escape(collapseSpace(n.Data))
+	case html.ElementNode:
+	default:
+		return c.children(n)
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Title:
+		return ""
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		return block(strings.Repeat("#", level) + " " + oneLine(c.children(n)))
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer:
+		return block(strings.TrimSpace(c.children(n)))
+	case atom.Br:
+		if c.pre > 0 {
+			return "\n"
+		}
+		return "  \n"
+	case atom.Hr:
+		return block("---")
+	case atom.Strong, atom.B:
+		return wrap("**", c.children(n))
+	case atom.Em, atom.I:
+		return wrap("*", c.children(n))
+	case atom.Del, atom.S, atom.Strike:
+		return wrap("~~", c.children(n))
+	case atom.Code:
+		if c.pre > 0 {
+			return c.children(n)
+		}
+		return inlineCode(stringutil.NodeText(n))
+	case atom.Pre:
+		c.pre++
+		code := strings.TrimRight(c.children(n), "\n")
+		c.pre--
+		return block("```" + language(n) + "\n" + code + "\n```")
+	case atom.A:
+		label := strings.TrimSpace(c.children(n))
+		href := stringutil.NodeAttr(n, "href")
+		if len(href) == 0 || strings.HasPrefix(href, "javascript:") {
+			return label
+		}
+		if len(label) == 0 {
+			label = escape(href)
+		}
+		if title := stringutil.NodeAttr(n, "title"); len(title) > 0 {
+			return "[" + label + "](" + destination(href) + ` "` + strings.Replace(title, `"`, `\"`, -1) + `")`
+		}
+		return "[" + label + "](" + destination(href) + ")"
+	case atom.Img:
+		return "![" + escape(oneLine(stringutil.NodeAttr(n, "alt"))) + "](" + destination(stringutil.NodeAttr(n, "src")) + ")"
+	case atom.Ul, atom.Ol:
+		return block(c.list(n))
+	case atom.Blockquote:
+		inner := tidy(c.children(n))
+		lines := strings.Split(inner, "\n")
+		for i := range lines {
+			lines[i] = strings.TrimRight("> "+lines[i], " ")
+		}
+		return block(strings.Join(lines, "\n"))
+	case atom.Table:
+		return block(c.table(n))
+	}
+
+	return c.children(n)
+}
+
+func (c *converter) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if s, err := strconv.Atoi(stringutil.NodeAttr(n, "start")); err == nil {
+		number = s
+	}
+
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		content := tidy(c.children(li))
+		content = blankLines.ReplaceAllString(content, "\n")
+		lines := strings.Split(content, "\n")
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) > 0 {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func (c *converter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(*html.Node)
+	walk = func(t *html.Node) {
+		for ch := t.FirstChild; ch != nil; ch = ch.NextSibling {
+			if ch.Type != html.ElementNode {
+				continue
+			}
+			switch ch.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(ch)
+			case atom.Tr:
+				var row []string
+				for td := ch.FirstChild; td != nil; td = td.NextSibling {
+					if td.Type == html.ElementNode && (td.DataAtom == atom.Td || td.DataAtom == atom.Th) {
+						cell := oneLine(c.children(td))
+						row = append(row, strings.Replace(cell, "|", `\|`, -1))
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	walk(n)
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, r := range rows {
+		if len(r) > cols {
+			cols = len(r)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	var b bytes.Buffer
+	for i, r := range rows {
+		for len(r) < cols {
+			r = append(r, "")
+		}
+		b.WriteString("| " + strings.Join(r, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// language reads the code language from a class such as "language-go" or "lang-go",
+// or from the editor mode saved with code sections.
+func language(n *html.Node) string {
+	switch mode := stringutil.NodeAttr(n, "data-lang"); mode {
+	case "htmlmixed":
+		return "html"
+	case "clike", "text/plain":
+	default:
+		if len(mode) > 0 {
+			return mode
+		}
+	}
+
+	classes := stringutil.NodeAttr(n, "class")
+	if code := firstChildElement(n, atom.Code); code != nil {
+		classes += " " + stringutil.NodeAttr(code, "class")
+	}
+	for _, cl := range strings.Fields(classes) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(cl, prefix) {
+				return strings.TrimPrefix(cl, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+var (
+	spaces     = regexp.MustCompile(`\s+`)
+	blankLines = regexp.MustCompile(`\n{2,}`)
+	tooMany    = regexp.MustCompile(`\n{3,}`)
+	angles     = strings.NewReplacer("<", `\<`, ">", `\>`, "\n", "%0A")
+	specials   = strings.NewReplacer(`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`)
+)
+
+func block(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return "\n\n" + s + "\n\n"
+}
+
+func wrap(marker, s string) string {
+	t := strings.TrimSpace(s)
+	if len(t) == 0 {
+		return s
+	}
+	// keep surrounding whitespace outside of the emphasis markers
+	lead := s[:strings.Index(s, t)]
+	trail := s[len(lead)+len(t):]
+	return lead + marker + t + marker + trail
+}
+
+func inlineCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func oneLine(s string) string {
+	return strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+}
+
+func collapseSpace(s string) string {
+	return spaces.ReplaceAllString(s, " ")
+}
+
+func escape(s string) string {
+	return specials.Replace(s)
+}
+
+// destination writes a link target between angle brackets when it holds spaces or parentheses,
+// as attachment file names often do.
+func destination(url string) string {
+	if !strings.ContainsAny(url, " ()<>\t\n") {
+		return url
+	}
+	return "<" + angles.Replace(url) + ">"
+}
+
+// tidy removes trailing spaces from blank lines and collapses runs of blank lines.
+func tidy(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if len(strings.TrimSpace(l)) == 0 {
+			lines[i] = ""
+		}
+	}
+	s = strings.Join(lines, "\n")
+	s = tooMany.ReplaceAllString(s, "\n\n")
+	return strings.Trim(s, "\n")
+}
+
+func firstChildElement(n *html.Node, a atom.Atom) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			return c
+		}
+	}
+	return nil
+}
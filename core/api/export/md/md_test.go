@@ -0,0 +1,73 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package md_test
+
+import (
+	"testing"
+
+	"github.com/documize/community/core/api/export/md"
+	api "github.com/documize/community/core/convapi"
+)
+
+func TestFromHTML(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{`<h1>Title</h1><p>Some <b>bold</b> and <em>italic</em> text.</p>`,
+			"# Title\n\nSome **bold** and *italic* text."},
+		{`<h3>Deep</h3>`, "### Deep"},
+		{`<p>See <a href="guide/intro.md">the guide</a> and <img src="doc/logo.png" alt="logo"></p>`,
+			"See [the guide](guide/intro.md) and ![logo](doc/logo.png)"},
+		{`<p><a href="files/Q1 report (final).pdf">[draft] notes</a> <img src="files/a&lt;b&gt; c.png" alt="x]y"></p>`,
+			"[\\[draft\\] notes](<files/Q1 report (final).pdf>) ![x\\]y](<files/a\\<b\\> c.png>)"},
+		{`<ul><li>one</li><li>two<ul><li>nested</li></ul></li></ul>`,
+			"- one\n- two\n  - nested"},
+		{`<ol start="3"><li>three</li><li>four</li></ol>`,
+			"3. three\n4. four"},
+		{`<pre><code class="language-go">func main() {
+	fmt.Println("*hi*")
+}</code></pre>`,
+			"```go\nfunc main() {\n\tfmt.Println(\"*hi*\")\n}\n```"},
+		{`<pre class="code-highlight" data-lang="go"><span class="cm-keyword" style="color: #859900;">var</span> x = <span class="cm-number" style="color: #d33682;">1</span></pre>`,
+			"```go\nvar x = 1\n```"},
+		{`<p>Use <code>a*b</code> here</p>`, "Use `a*b` here"},
+		{`<table><thead><tr><th>Name</th><th>Value</th></tr></thead><tbody><tr><td>a|b</td><td>1</td></tr><tr><td>c</td></tr></tbody></table>`,
+			"| Name | Value |\n| --- | --- |\n| a\\|b | 1 |\n| c |  |"},
+		{`<blockquote><p>quoted</p><p>twice</p></blockquote>`, "> quoted\n>\n> twice"},
+		{`<p>2 * 3_4</p><hr><p>end</p>`, "2 \\* 3\\_4\n\n---\n\nend"},
+	}
+
+	for _, tt := range tests {
+		got, err := md.FromHTML([]byte(tt.in))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if string(got) != tt.out {
+			t.Errorf("for %s\nexpected:\n%s\ngot:\n%s", tt.in, tt.out, got)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	out, err := md.Export(nil, []byte(`<html><head><title>Doc</title></head><body><h1>Doc</h1></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, ok := out.(*api.DocumentExport)
+	if !ok {
+		t.Fatal("wrong type returned")
+	}
+	if exp.Format != "md" || string(exp.File) != "# Doc" {
+		t.Errorf("unexpected export %s %q", exp.Format, exp.File)
+	}
+}
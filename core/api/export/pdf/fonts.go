@@ -0,0 +1,124 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package pdf
+
+// style is a bit mask of text attributes.
+type style int
+
+const (
+	bold style = 1 << iota
+	italic
+	mono
+)
+
+// font describes one of the standard 14 PDF fonts, which every PDF reader provides.
+type font struct {
+	name   string // resource name used in content streams
+	base   string // PDF BaseFont
+	widths *[95]int
+}
+
+var fonts = []font{
+	{"F1", "Helvetica", &helvetica},
+	{"F2", "Helvetica-Bold", &helveticaBold},
+	{"F3", "Helvetica-Oblique", &helvetica},
+	{"F4", "Helvetica-BoldOblique", &helveticaBold},
+	{"F5", "Courier", nil},
+	{"F6", "Courier-Bold", nil},
+}
+
+// fontFor returns the font used to render the given style.
+func fontFor(s style) *font {
+	switch {
+	case s&mono != 0 && s&bold != 0:
+		return &fonts[5]
+	case s&mono != 0:
+		return &fonts[4]
+	case s&bold != 0 && s&italic != 0:
+		return &fonts[3]
+	case s&bold != 0:
+		return &fonts[1]
+	case s&italic != 0:
+		return &fonts[2]
+	}
+	return &fonts[0]
+}
+
+// width returns the width of the WinAnsi encoded text in points.
+func (f *font) width(text []byte, size float64) float64 {
+	total := 0
+	for _, c := range text {
+		switch {
+		case f.widths == nil:
+			total += 600 // Courier is fixed pitch
+		case c >= 32 && c <= 126:
+			total += f.widths[c-32]
+		case c == 149: // bullet
+			total += 350
+		case c == 150: // en dash
+			total += 556
+		case c == 151, c == 133: // em dash, ellipsis
+			total += 1000
+		default:
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// winAnsi holds the WinAnsiEncoding code points outside of Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 128, '‚': 130, 'ƒ': 131, '„': 132, '…': 133, '†': 134, '‡': 135, 'ˆ': 136, '‰': 137,
+	'Š': 138, '‹': 139, 'Œ': 140, 'Ž': 142, '‘': 145, '’': 146, '“': 147, '”': 148, '•': 149,
+	'–': 150, '—': 151, '˜': 152, '™': 153, 'š': 154, '›': 155, 'œ': 156, 'ž': 158, 'Ÿ': 159,
+}
+
+// encode converts text into WinAnsiEncoding, replacing characters that cannot be represented.
+func encode(s string) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r == '\t':
+			b = append(b, ' ', ' ', ' ', ' ')
+		case r == 0xA0:
+			b = append(b, ' ')
+		case r >= 32 && r <= 126, r >= 0xA1 && r <= 0xFF:
+			b = append(b, byte(r))
+		default:
+			if c, ok := winAnsi[r]; ok {
+				b = append(b, c)
+			} else if r >= 32 {
+				b = append(b, '?')
+			}
+		}
+	}
+	return b
+}
+
+// Glyph widths, in thousandths of a point, for characters 32 to 126.
+var helvetica = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBold = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
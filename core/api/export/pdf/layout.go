@@ -0,0 +1,483 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 page geometry in points.
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	marginLeft   = 64.0
+	marginRight  = 64.0
+	marginTop    = 72.0
+	marginBottom = 72.0
+	bodySize     = 10.5
+	monoSize     = 9.0
+	leading      = 1.4
+	indentStep   = 18.0
+)
+
+var headingSizes = [...]float64{20, 16, 14, 12, 11, 10.5}
+
+// annotation is a clickable area on a page, either an external URI or an internal anchor.
+type annotation struct {
+	x1, y1, x2, y2 float64
+	uri            string
+	anchor         string
+}
+
+// page is a single output page under construction.
+type page struct {
+	content bytes.Buffer
+	links   []annotation
+}
+
+// position records where an anchor was placed.
+type position struct {
+	page int
+	y    float64
+}
+
+// tocEntry is an entry in the table of contents.
+type tocEntry struct {
+	title  string
+	level  int
+	anchor string
+}
+
+// layout flows blocks onto pages.
+type layout struct {
+	pages   []*page
+	y       float64 // baseline position on the current page
+	images  []*picture
+	anchors map[string]position
+	toc     []tocEntry
+}
+
+func newLayout() *layout {
+	return &layout{anchors: make(map[string]position)}
+}
+
+func (l *layout) current() *page {
+	return l.pages[len(l.pages)-1]
+}
+
+func (l *layout) newPage() {
+	l.pages = append(l.pages, &page{})
+	l.y = pageHeight - marginTop
+}
+
+// ensure starts a new page unless there is room for the given height.
+func (l *layout) ensure(height float64) {
+	if len(l.pages) == 0 || l.y-height < marginBottom {
+		l.newPage()
+	}
+}
+
+// atTop tells us if nothing has been placed on the current page.
+func (l *layout) atTop() bool {
+	return len(l.pages) == 0 || l.y == pageHeight-marginTop
+}
+
+// word is a unit of text that is never broken across lines.
+type word struct {
+	text  []byte
+	style style
+	link  string
+	space bool // preceded by a space
+	br    bool // forced line break
+	size  float64
+}
+
+func (w word) width() float64 {
+	return fontFor(w.style).width(w.text, w.size)
+}
+
+// words splits spans into words at the given font size.
+func words(spans []span, size float64) (out []word) {
+	pending := false // whitespace seen since the last word
+	for _, s := range spans {
+		for i, line := range strings.Split(s.text, "\n") {
+			if i > 0 {
+				out = append(out, word{br: true})
+				pending = false
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				pending = pending || len(line) > 0
+				continue
+			}
+			if line[0] == ' ' {
+				pending = true
+			}
+			for j, f := range fields {
+				out = append(out, word{text: encode(f), style: s.style, link: s.link, space: pending || j > 0, size: size})
+			}
+			pending = strings.HasSuffix(line, " ")
+		}
+	}
+	return
+}
+
+// wrap breaks words into lines no wider than width.
+func wrap(in []word, width float64) (lines [][]word) {
+	var line []word
+	x := 0.0
+	for _, w := range in {
+		if w.br {
+			lines = append(lines, line)
+			line, x = nil, 0
+			continue
+		}
+		gap := 0.0
+		if w.space && len(line) > 0 {
+			gap = fontFor(w.style).width([]byte(" "), w.size)
+		}
+		ww := w.width()
+		if len(line) > 0 && x+gap+ww > width {
+			lines = append(lines, line)
+			line, x, gap = nil, 0, 0
+		}
+		// break words too long for a line of their own, such as URLs
+		for len(line) == 0 && ww > width && len(w.text) > 1 {
+			n := len(w.text) - 1
+			for n > 1 && fontFor(w.style).width(w.text[:n], w.size) > width {
+				n--
+			}
+			head := w
+			head.text = w.text[:n]
+			lines = append(lines, []word{head})
+			w.text = w.text[n:]
+			w.space = false
+			ww = w.width()
+		}
+		w.space = gap > 0
+		line = append(line, w)
+		x += gap + ww
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return
+}
+
+// text writes a single run of text at the given position.
+func (p *page) text(x, y float64, s style, size float64, t []byte) {
+	p.content.WriteString(fmt.Sprintf("BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", fontFor(s).name, size, x, y, escape(t)))
+}
+
+// color sets the fill and stroke color.
+func (p *page) color(r, g, b float64) {
+	p.content.WriteString(fmt.Sprintf("%.3f %.3f %.3f rg %.3f %.3f %.3f RG\n", r, g, b, r, g, b))
+}
+
+// rect draws a rectangle, filled or stroked.
+func (p *page) rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	p.content.WriteString(fmt.Sprintf("%.2f %.2f %.2f %.2f re %s\n", x, y, w, h, op))
+}
+
+// line draws a line.
+func (p *page) line(x1, y1, x2, y2, width float64) {
+	p.content.WriteString(fmt.Sprintf("%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2))
+}
+
+// drawLine renders a wrapped line with its baseline at y, adding link annotations.
+func (p *page) drawLine(line []word, x, y float64) {
+	var active *annotation
+	for _, w := range line {
+		if w.space {
+			x += fontFor(w.style).width([]byte(" "), w.size)
+		}
+		ww := w.width()
+		if len(w.link) > 0 {
+			p.color(0, 0.27, 0.6)
+		}
+		p.text(x, y, w.style, w.size, w.text)
+		if len(w.link) > 0 {
+			p.color(0, 0, 0)
+			if active != nil && active.uri == w.link {
+				active.x2 = x + ww
+			} else {
+				p.links = append(p.links, annotation{x1: x, y1: y - w.size*0.25, x2: x + ww, y2: y + w.size, uri: w.link})
+				active = &p.links[len(p.links)-1]
+			}
+		} else {
+			active = nil
+		}
+		x += ww
+	}
+}
+
+// paragraph flows styled text between left and right, returning without trailing space.
+func (l *layout) paragraph(spans []span, left, size float64, marker string) {
+	width := pageWidth - marginRight - left
+	lines := wrap(words(spans, size), width)
+	lh := size * leading
+
+	for i, line := range lines {
+		l.ensure(lh)
+		l.y -= lh
+		if i == 0 && len(marker) > 0 {
+			m := encode(marker)
+			l.current().text(left-fontFor(0).width(m, size)-4, l.y, 0, size, m)
+		}
+		l.current().drawLine(line, left, l.y)
+	}
+}
+
+// flow lays out the blocks, starting on a new page.
+func (l *layout) flow(blocks []block) {
+	l.newPage()
+
+	for i, b := range blocks {
+		left := marginLeft + float64(b.indent)*indentStep
+
+		switch b.kind {
+		case heading:
+			size := headingSizes[b.level-1]
+			lh := size * leading
+			// keep headings with the content that follows
+			need := lh * 1.5
+			if i+1 < len(blocks) {
+				need += bodySize * leading * 3
+			}
+			if !l.atTop() {
+				l.y -= size * 0.6
+			}
+			l.ensure(need)
+			if len(b.anchor) > 0 {
+				l.anchors[b.anchor] = position{page: len(l.pages) - 1, y: l.y}
+				l.toc = append(l.toc, tocEntry{title: plain(b.spans), level: b.level, anchor: b.anchor})
+			}
+			l.paragraph(b.spans, left, size, "")
+			l.y -= size * 0.3
+
+		case paragraph:
+			l.paragraph(b.spans, left, bodySize, b.marker)
+			if len(b.marker) > 0 {
+				l.y -= 2
+			} else {
+				l.y -= bodySize * 0.6
+			}
+
+		case preformatted:
+			l.pre(b.code, b.fill, left)
+			l.y -= bodySize * 0.6
+
+		case graphic:
+			l.image(b.img, left)
+			l.y -= bodySize * 0.6
+
+		case rule:
+			l.ensure(12)
+			l.y -= 6
+			l.current().color(0.75, 0.75, 0.75)
+			l.current().line(left, l.y, pageWidth-marginRight, l.y, 0.75)
+			l.current().color(0, 0, 0)
+			l.y -= 6
+
+		case table:
+			l.table(b.rows, left)
+			l.y -= bodySize * 0.6
+		}
+	}
+}
+
+// pre renders preformatted text on a shaded background, breaking long lines.
+// Highlighted code keeps its colours and background.
+func (l *layout) pre(code []codeRun, fill *rgb, left float64) {
+	width := pageWidth - marginRight - left
+	perLine := int((width - 8) / (monoSize * 0.6))
+	lh := monoSize * 1.3
+
+	if fill == nil {
+		fill = &rgb{0.95, 0.95, 0.95}
+	}
+
+	// segment is part of a line in a single colour
+	type segment struct {
+		text  []byte
+		color *rgb
+	}
+
+	lines := [][]segment{nil}
+	used := 0
+	for _, r := range code {
+		for i, part := range strings.Split(r.text, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+				used = 0
+			}
+			b := encode(part)
+			for len(b) > 0 {
+				if used == perLine {
+					lines = append(lines, nil)
+					used = 0
+				}
+				n := perLine - used
+				if n > len(b) {
+					n = len(b)
+				}
+				lines[len(lines)-1] = append(lines[len(lines)-1], segment{text: b[:n], color: r.color})
+				used += n
+				b = b[n:]
+			}
+		}
+	}
+
+	for i, line := range lines {
+		l.ensure(lh)
+		p := l.current()
+		top := l.y
+		if i == 0 || l.atTop() {
+			top += 4
+		}
+		l.y -= lh
+		bottom := l.y - monoSize*0.3
+		if i == len(lines)-1 {
+			bottom -= 4
+		}
+		p.color(fill.r, fill.g, fill.b)
+		p.rect(left, bottom, width, top-bottom, true)
+
+		x := left + 4
+		for _, s := range line {
+			if s.color != nil {
+				p.color(s.color.r, s.color.g, s.color.b)
+			} else {
+				p.color(0, 0, 0)
+			}
+			p.text(x, l.y, mono, monoSize, s.text)
+			x += fontFor(mono).width(s.text, monoSize)
+		}
+		p.color(0, 0, 0)
+		if i == len(lines)-1 {
+			l.y -= 4
+		}
+	}
+}
+
+// image places a picture, scaled to fit the page.
+func (l *layout) image(pic *picture, left float64) {
+	maxWidth := pageWidth - marginRight - left
+	maxHeight := pageHeight - marginTop - marginBottom
+
+	// images are sized for the screen at 96 DPI
+	w := float64(pic.width) * 0.75
+	h := float64(pic.height) * 0.75
+	if w > maxWidth {
+		h, w = h*maxWidth/w, maxWidth
+	}
+	if h > maxHeight {
+		w, h = w*maxHeight/h, maxHeight
+	}
+
+	l.ensure(h + 4)
+	l.y -= h + 4
+
+	index := len(l.images)
+	l.images = append(l.images, pic)
+	l.current().content.WriteString(fmt.Sprintf("q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, left, l.y, index+1))
+}
+
+// table renders rows with equal column widths and cell borders.
+func (l *layout) table(rows [][]cell, left float64) {
+	columns := 0
+	for _, r := range rows {
+		if len(r) > columns {
+			columns = len(r)
+		}
+	}
+	width := pageWidth - marginRight - left
+	colWidth := width / float64(columns)
+	lh := bodySize * leading
+	pad := 4.0
+
+	for _, r := range rows {
+		wrapped := make([][][]word, columns)
+		height := lh
+		for c := range r {
+			wrapped[c] = wrap(words(r[c].spans, bodySize), colWidth-pad*2)
+			if h := float64(len(wrapped[c])) * lh; h > height {
+				height = h
+			}
+		}
+		height += pad * 2
+
+		l.ensure(height)
+		p := l.current()
+		top := l.y
+		l.y -= height
+
+		for c := 0; c < columns; c++ {
+			x := left + float64(c)*colWidth
+			if c < len(r) && r[c].header {
+				p.color(0.92, 0.92, 0.92)
+				p.rect(x, l.y, colWidth, height, true)
+				p.color(0, 0, 0)
+			}
+			p.color(0.6, 0.6, 0.6)
+			p.content.WriteString("0.5 w\n")
+			p.rect(x, l.y, colWidth, height, false)
+			p.color(0, 0, 0)
+
+			y := top - pad
+			for _, line := range wrapped[c] {
+				y -= lh
+				p.drawLine(line, x+pad, y+lh-bodySize)
+			}
+		}
+	}
+}
+
+// plain returns the text content of the spans.
+func plain(spans []span) string {
+	var b bytes.Buffer
+	for _, s := range spans {
+		b.WriteString(s.text)
+	}
+	return strings.TrimSpace(whitespace.ReplaceAllString(b.String(), " "))
+}
+
+// escape makes text safe for use within a PDF string literal.
+func escape(t []byte) []byte {
+	var b bytes.Buffer
+	for _, c := range t {
+		switch c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.Bytes()
+}
+
+// truncate shortens text to fit the width, adding an ellipsis when required.
+func truncate(t []byte, s style, size, width float64) []byte {
+	f := fontFor(s)
+	if f.width(t, size) <= width {
+		return t
+	}
+	for len(t) > 0 && f.width(append(t[:len(t):len(t)], 133), size) > width {
+		t = t[:len(t)-1]
+	}
+	return append(t[:len(t):len(t)], 133)
+}
@@ -0,0 +1,414 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package pdf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	_ "image/gif"  // register GIF decoding
+	_ "image/jpeg" // register JPEG decoding
+	_ "image/png"  // register PNG decoding
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+type blockKind int
+
+const (
+	paragraph blockKind = iota
+	heading
+	preformatted
+	graphic
+	rule
+	table
+)
+
+// span is a run of text sharing the same style.
+type span struct {
+	text  string
+	style style
+	link  string
+}
+
+// block is a unit of vertical layout.
+type block struct {
+	kind   blockKind
+	level  int    // heading level 1-6
+	anchor string // heading identifier, page titles have one
+	indent int    // list and quotation nesting
+	marker string // list item bullet or number
+	spans  []span
+	code   []codeRun // preformatted text
+	fill   *rgb      // preformatted background, when styled
+	img    *picture
+	rows   [][]cell
+}
+
+// rgb is a colour with components between 0 and 1.
+type rgb struct {
+	r, g, b float64
+}
+
+// codeRun is preformatted text in a single colour, nil for the default.
+type codeRun struct {
+	text  string
+	color *rgb
+}
+
+type cell struct {
+	header bool
+	spans  []span
+}
+
+// picture is a decoded image ready for embedding.
+type picture struct {
+	width, height int
+	colorSpace    string
+	filter        string // DCTDecode for JPEG, empty for samples still to be compressed
+	data          []byte // raw JPEG or 8-bit RGB samples
+}
+
+// meta holds the document information found in the HTML head.
+type meta struct {
+	title string
+	names map[string]string
+}
+
+type parser struct {
+	blocks []block
+	inline []span
+	indent int
+	marker string
+}
+
+var (
+	whitespace = regexp.MustCompile(`\s+`)
+	colorStyle = regexp.MustCompile(`(?:^|;)\s*(background-color|color)\s*:\s*#([0-9a-fA-F]{6})`)
+)
+
+// parse converts HTML into document metadata and a list of blocks.
+func parse(h []byte) (m meta, blocks []block, err error) {
+	doc, err := html.Parse(bytes.NewReader(h))
+	if err != nil {
+		return
+	}
+
+	m.names = make(map[string]string)
+	if head := stringutil.FindElement(doc, atom.Head); head != nil {
+		if t := stringutil.FindElement(head, atom.Title); t != nil {
+			m.title = strings.TrimSpace(stringutil.NodeText(t))
+		}
+		for c := head.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && c.DataAtom == atom.Meta {
+				m.names[stringutil.NodeAttr(c, "name")] = stringutil.NodeAttr(c, "content")
+			}
+		}
+	}
+
+	body := stringutil.FindElement(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+
+	p := &parser{}
+	p.children(body, 0, "")
+	p.flush()
+
+	return m, p.blocks, nil
+}
+
+func (p *parser) children(n *html.Node, s style, link string) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.node(c, s, link)
+	}
+}
+
+func (p *parser) node(n *html.Node, s style, link string) {
+	if n.Type == html.TextNode {
+		p.inline = append(p.inline, span{text: whitespace.ReplaceAllString(n.Data, " "), style: s, link: link})
+		return
+	}
+	if n.Type != html.ElementNode {
+		p.children(n, s, link)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head:
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		p.flush()
+		p.children(n, s|bold, link)
+		p.add(block{kind: heading, level: int(n.Data[1] - '0'), anchor: stringutil.NodeAttr(n, "id")})
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Dl, atom.Dt, atom.Dd, atom.Figure, atom.Figcaption:
+		p.flush()
+		p.children(n, s, link)
+		p.flush()
+	case atom.Br:
+		p.inline = append(p.inline, span{text: "\n", style: s})
+	case atom.B, atom.Strong, atom.Th:
+		p.children(n, s|bold, link)
+	case atom.I, atom.Em, atom.Cite, atom.Var:
+		p.children(n, s|italic, link)
+	case atom.Code, atom.Tt, atom.Kbd, atom.Samp:
+		p.children(n, s|mono, link)
+	case atom.A:
+		href := stringutil.NodeAttr(n, "href")
+		if strings.HasPrefix(href, "javascript:") {
+			href = ""
+		}
+		p.children(n, s, href)
+	case atom.Ul, atom.Ol:
+		p.flush()
+		p.list(n, s, link)
+	case atom.Blockquote:
+		p.flush()
+		p.indent++
+		p.children(n, s|italic, link)
+		p.flush()
+		p.indent--
+	case atom.Pre:
+		p.flush()
+		p.blocks = append(p.blocks, block{kind: preformatted, indent: p.indent, code: codeRuns(n), fill: styleColor(n, "background-color")})
+	case atom.Hr:
+		p.flush()
+		p.blocks = append(p.blocks, block{kind: rule, indent: p.indent})
+	case atom.Img:
+		pic := decodeImage(stringutil.NodeAttr(n, "src"))
+		if pic == nil {
+			if alt := stringutil.NodeAttr(n, "alt"); len(alt) > 0 {
+				p.inline = append(p.inline, span{text: "[" + alt + "]", style: s | italic, link: link})
+			}
+			return
+		}
+		p.flush()
+		p.blocks = append(p.blocks, block{kind: graphic, indent: p.indent, img: pic})
+	case atom.Table:
+		p.flush()
+		p.table(n)
+	default:
+		p.children(n, s, link)
+	}
+}
+
+func (p *parser) list(n *html.Node, s style, link string) {
+	number := 1
+	if v, err := strconv.Atoi(stringutil.NodeAttr(n, "start")); err == nil {
+		number = v
+	}
+
+	p.indent++
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		if n.DataAtom == atom.Ol {
+			p.marker = strconv.Itoa(number) + "."
+			number++
+		} else {
+			p.marker = "•"
+		}
+		p.children(li, s, link)
+		p.flush()
+		p.marker = ""
+	}
+	p.indent--
+}
+
+func (p *parser) table(n *html.Node) {
+	var rows [][]cell
+	var walk func(*html.Node)
+	walk = func(t *html.Node) {
+		for c := t.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				var row []cell
+				for td := c.FirstChild; td != nil; td = td.NextSibling {
+					if td.Type != html.ElementNode || (td.DataAtom != atom.Td && td.DataAtom != atom.Th) {
+						continue
+					}
+					sub := &parser{}
+					sub.children(td, 0, "")
+					if td.DataAtom == atom.Th {
+						for i := range sub.inline {
+							sub.inline[i].style |= bold
+						}
+					}
+					row = append(row, cell{header: td.DataAtom == atom.Th, spans: sub.inline})
+				}
+				if len(row) > 0 {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	walk(n)
+
+	if len(rows) > 0 {
+		p.blocks = append(p.blocks, block{kind: table, indent: p.indent, rows: rows})
+	}
+}
+
+// add completes a block using the pending inline text.
+func (p *parser) add(b block) {
+	b.spans = trimSpans(p.inline)
+	b.indent = p.indent
+	p.inline = nil
+	if len(b.spans) > 0 {
+		p.blocks = append(p.blocks, b)
+	}
+}
+
+// flush turns pending inline text into a paragraph.
+func (p *parser) flush() {
+	spans := trimSpans(p.inline)
+	p.inline = nil
+	if len(spans) == 0 {
+		return
+	}
+	p.blocks = append(p.blocks, block{kind: paragraph, indent: p.indent, marker: p.marker, spans: spans})
+	p.marker = ""
+}
+
+// trimSpans removes leading and trailing whitespace, returning nil if nothing is left.
+func trimSpans(in []span) []span {
+	start, end := 0, len(in)
+	for start < end && strings.TrimSpace(in[start].text) == "" {
+		start++
+	}
+	for end > start && strings.TrimSpace(in[end-1].text) == "" {
+		end--
+	}
+	if start == end {
+		return nil
+	}
+	out := append([]span{}, in[start:end]...)
+	out[0].text = strings.TrimLeft(out[0].text, " ")
+	out[len(out)-1].text = strings.TrimRight(out[len(out)-1].text, " ")
+	return out
+}
+
+// maxPixels caps the size of an embedded image, as decoding one takes memory for every pixel it declares.
+const maxPixels = 24 << 20
+
+// decodeImage reads an image held in a data URI, other sources cannot be embedded.
+// Images larger than maxPixels are left out.
+func decodeImage(src string) *picture {
+	if !strings.HasPrefix(src, "data:") {
+		return nil
+	}
+	comma := strings.Index(src, ",")
+	if comma < 0 || !strings.HasSuffix(src[:comma], ";base64") {
+		return nil
+	}
+	data, err := base64.StdEncoding.DecodeString(src[comma+1:])
+	if err != nil {
+		return nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil
+	}
+
+	if format == "jpeg" {
+		cs := "DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			cs = "DeviceGray"
+		case color.CMYKModel:
+			cs = "DeviceCMYK"
+		}
+		return &picture{width: cfg.Width, height: cfg.Height, colorSpace: cs, filter: "DCTDecode", data: data}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	// flatten onto a white background as PDF has no simple alpha channel
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			white := 0xffff - a
+			rgb = append(rgb, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	return &picture{width: bounds.Dx(), height: bounds.Dy(), colorSpace: "DeviceRGB", data: rgb}
+}
+
+// codeRuns returns the text of preformatted content, coloured as highlighted code is.
+func codeRuns(n *html.Node) (runs []codeRun) {
+	var walk func(*html.Node, *rgb)
+	walk = func(n *html.Node, c *rgb) {
+		switch {
+		case n.Type == html.TextNode:
+			runs = append(runs, codeRun{text: n.Data, color: c})
+			return
+		case n.Type == html.ElementNode && n.DataAtom == atom.Br:
+			runs = append(runs, codeRun{text: "\n", color: c})
+			return
+		case n.Type == html.ElementNode:
+			if color := styleColor(n, "color"); color != nil {
+				c = color
+			}
+		}
+		for ch := n.FirstChild; ch != nil; ch = ch.NextSibling {
+			walk(ch, c)
+		}
+	}
+	walk(n, nil)
+
+	// drop the blank lines that surround the text
+	for len(runs) > 0 && strings.Trim(runs[0].text, "\n") == "" {
+		runs = runs[1:]
+	}
+	for len(runs) > 0 && strings.Trim(runs[len(runs)-1].text, "\n") == "" {
+		runs = runs[:len(runs)-1]
+	}
+	if len(runs) > 0 {
+		runs[0].text = strings.TrimLeft(runs[0].text, "\n")
+		runs[len(runs)-1].text = strings.TrimRight(runs[len(runs)-1].text, "\n")
+	}
+
+	return
+}
+
+// styleColor returns a colour property set in the style attribute of n.
+func styleColor(n *html.Node, property string) *rgb {
+	for _, m := range colorStyle.FindAllStringSubmatch(stringutil.NodeAttr(n, "style"), -1) {
+		if m[1] != property {
+			continue
+		}
+		v, err := strconv.ParseUint(m[2], 16, 32)
+		if err != nil {
+			return nil
+		}
+		return &rgb{float64(v>>16) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255}
+	}
+	return nil
+}
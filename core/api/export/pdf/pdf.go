@@ -0,0 +1,284 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package pdf renders exported document HTML as a PDF file, using only the standard PDF fonts.
+// The output has a cover page showing the document metadata, a table of contents built from
+// the page headings, running headers and footers with page numbers, and embedded images.
+//
+// The standard fonts are WinAnsi encoded, so body text is limited to Latin-1 and a few typographic
+// characters; others, such as Greek, Cyrillic or CJK text, are written as "?". Document metadata
+// is not limited in this way.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	api "github.com/documize/community/core/convapi"
+	"golang.org/x/net/context"
+)
+
+// Export provides the "Export" plugin for the pdf format.
+func Export(ctx context.Context, in interface{}) (interface{}, error) {
+	h, ok := in.([]byte)
+	if !ok {
+		return nil, errors.New("expected []byte to export as pdf")
+	}
+
+	file, err := FromHTML(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.DocumentExport{Format: "pdf", File: file}, nil
+}
+
+// FromHTML converts exported document HTML into a PDF file.
+func FromHTML(h []byte) ([]byte, error) {
+	m, blocks, err := parse(h)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.title) == 0 {
+		m.title = "Untitled"
+	}
+
+	body := newLayout()
+	body.flow(blocks)
+
+	// the number of contents pages decides the page numbers listed within them
+	available := pageHeight - marginTop - marginBottom - 40
+	tocLines := int(available / (bodySize * 1.8))
+	tocPages := 0
+	if len(body.toc) > 0 {
+		tocPages = (len(body.toc) + tocLines - 1) / tocLines
+	}
+	offset := 1 + tocPages
+
+	pages := []*page{cover(m)}
+	pages = append(pages, contents(body, tocLines, offset)...)
+	pages = append(pages, body.pages...)
+
+	for i, p := range pages[1:] {
+		decorate(p, m.title, i+2, len(pages))
+	}
+
+	return write(m, pages, body.images, body.anchors, offset)
+}
+
+// cover renders the title page with the document metadata.
+func cover(m meta) *page {
+	l := newLayout()
+	l.newPage()
+	l.y = pageHeight * 0.62
+
+	l.paragraph([]span{{text: m.title, style: bold}}, marginLeft, 28, "")
+	l.y -= 10
+	p := l.current()
+	p.color(0.2, 0.4, 0.7)
+	p.line(marginLeft, l.y, pageWidth-marginRight, l.y, 2)
+	p.color(0, 0, 0)
+	l.y -= 16
+
+	details := []struct{ label, value string }{
+		{"Space", m.names["space"]},
+		{"Author", m.names["author"]},
+		{"Created", date(m.names["created"])},
+		{"Last revised", date(m.names["revised"])},
+		{"Tags", m.names["keywords"]},
+	}
+	for _, d := range details {
+		if len(d.value) == 0 {
+			continue
+		}
+		l.y -= bodySize * 1.8
+		p.color(0.4, 0.4, 0.4)
+		p.text(marginLeft, l.y, 0, bodySize, encode(d.label))
+		p.color(0, 0, 0)
+		p.text(marginLeft+90, l.y, 0, bodySize, truncate(encode(d.value), 0, bodySize, pageWidth-marginRight-marginLeft-90))
+	}
+
+	if excerpt := m.names["description"]; len(excerpt) > 0 {
+		l.y -= bodySize * 2
+		l.paragraph([]span{{text: excerpt, style: italic}}, marginLeft, bodySize, "")
+	}
+
+	return p
+}
+
+// date formats an RFC3339 timestamp for display.
+func date(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("2 January 2006")
+}
+
+// contents renders the table of contents, linking each entry to its heading.
+func contents(body *layout, perPage, offset int) (pages []*page) {
+	lh := bodySize * 1.8
+	var p *page
+	y := 0.0
+
+	for i, e := range body.toc {
+		if i%perPage == 0 {
+			p = &page{}
+			pages = append(pages, p)
+			y = pageHeight - marginTop
+			if i == 0 {
+				p.text(marginLeft, y-16, bold, 16, encode("Contents"))
+			}
+			y -= 40
+		}
+		y -= lh
+
+		pos := body.anchors[e.anchor]
+		number := encode(fmt.Sprintf("%d", pos.page+offset+1))
+		numberWidth := fontFor(0).width(number, bodySize)
+		right := pageWidth - marginRight
+
+		s := style(0)
+		if e.level == 1 {
+			s = bold
+		}
+		x := marginLeft + float64(e.level-1)*12
+		title := truncate(encode(e.title), s, bodySize, right-x-numberWidth-24)
+		p.text(x, y, s, bodySize, title)
+		p.text(right-numberWidth, y, 0, bodySize, number)
+
+		// dotted leader between title and page number
+		p.color(0.7, 0.7, 0.7)
+		p.content.WriteString("[1 2] 0 d\n")
+		p.line(x+fontFor(s).width(title, bodySize)+6, y+1, right-numberWidth-6, y+1, 0.5)
+		p.content.WriteString("[] 0 d\n")
+		p.color(0, 0, 0)
+
+		p.links = append(p.links, annotation{x1: x, y1: y - 3, x2: right, y2: y + bodySize, anchor: e.anchor})
+	}
+
+	return
+}
+
+// decorate adds the running header and footer to a page.
+func decorate(p *page, title string, number, total int) {
+	p.color(0.45, 0.45, 0.45)
+	header := truncate(encode(title), 0, 8, pageWidth-marginLeft-marginRight)
+	p.text(marginLeft, pageHeight-44, 0, 8, header)
+	p.line(marginLeft, pageHeight-50, pageWidth-marginRight, pageHeight-50, 0.5)
+
+	footer := encode(fmt.Sprintf("Page %d of %d", number, total))
+	p.text((pageWidth-fontFor(0).width(footer, 8))/2, 40, 0, 8, footer)
+	p.color(0, 0, 0)
+}
+
+// write serializes the pages into a PDF file.
+func write(m meta, pages []*page, images []*picture, anchors map[string]position, offset int) ([]byte, error) {
+	w := &writer{}
+
+	catalog := w.reserve()
+	tree := w.reserve()
+
+	var resources bytes.Buffer
+	resources.WriteString("<< /Font <<")
+	for _, f := range fonts {
+		n := w.add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+		resources.WriteString(fmt.Sprintf(" /%s %d 0 R", f.name, n))
+	}
+	resources.WriteString(" >>")
+
+	if len(images) > 0 {
+		resources.WriteString(" /XObject <<")
+		for i, img := range images {
+			n, err := w.stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8",
+				img.width, img.height, img.colorSpace), img.data, img.filter)
+			if err != nil {
+				return nil, err
+			}
+			resources.WriteString(fmt.Sprintf(" /Im%d %d 0 R", i+1, n))
+		}
+		resources.WriteString(" >>")
+	}
+	resources.WriteString(" >>")
+	res := w.add(resources.String())
+
+	// page objects are numbered up-front so that links can refer to them
+	numbers := make([]int, len(pages))
+	for i := range pages {
+		numbers[i] = w.reserve()
+	}
+
+	kids := make([]string, len(pages))
+	for i, p := range pages {
+		content, err := w.stream("", p.content.Bytes(), "")
+		if err != nil {
+			return nil, err
+		}
+
+		var annots []string
+		for _, a := range p.links {
+			rect := fmt.Sprintf("[%.2f %.2f %.2f %.2f]", a.x1, a.y1, a.x2, a.y2)
+			if len(a.anchor) > 0 {
+				pos, ok := anchors[a.anchor]
+				if !ok {
+					continue
+				}
+				annots = append(annots, fmt.Sprintf("%d 0 R", w.add(fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect %s /Border [0 0 0] /Dest [%d 0 R /XYZ 0 %.2f null] >>",
+					rect, numbers[pos.page+offset], pos.y+20))))
+				continue
+			}
+			if !external(a.uri) {
+				continue
+			}
+			annots = append(annots, fmt.Sprintf("%d 0 R", w.add(fmt.Sprintf("<< /Type /Annot /Subtype /Link /Rect %s /Border [0 0 0] /A << /S /URI /URI %s >> >>",
+				rect, literal(a.uri)))))
+		}
+
+		dict := fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %d 0 R /Contents %d 0 R",
+			tree, pageWidth, pageHeight, res, content)
+		if len(annots) > 0 {
+			dict += " /Annots [" + strings.Join(annots, " ") + "]"
+		}
+		w.set(numbers[i], dict+" >>")
+		kids[i] = fmt.Sprintf("%d 0 R", numbers[i])
+	}
+
+	w.set(tree, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	w.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", tree))
+
+	info := fmt.Sprintf("<< /Title %s /Producer (Documize)", textString(m.title))
+	if author := m.names["author"]; len(author) > 0 {
+		info += " /Author " + textString(author)
+	}
+	if subject := m.names["description"]; len(subject) > 0 {
+		info += " /Subject " + textString(subject)
+	}
+	if keywords := m.names["keywords"]; len(keywords) > 0 {
+		info += " /Keywords " + textString(keywords)
+	}
+	if t, err := time.Parse(time.RFC3339, m.names["created"]); err == nil {
+		info += " /CreationDate " + literal(t.UTC().Format("D:20060102150405Z"))
+	}
+	if t, err := time.Parse(time.RFC3339, m.names["revised"]); err == nil {
+		info += " /ModDate " + literal(t.UTC().Format("D:20060102150405Z"))
+	}
+
+	return w.bytes(catalog, w.add(info+" >>")), nil
+}
+
+// external tells us if the link can be followed from outside of Documize.
+func external(uri string) bool {
+	u := strings.ToLower(uri)
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") || strings.HasPrefix(u, "mailto:")
+}
@@ -0,0 +1,194 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	lines := wrap(words([]span{{text: "one two "}, {text: "three", style: bold}, {text: " four\nfive"}}, 10), 60)
+
+	var got []string
+	for _, line := range lines {
+		var b bytes.Buffer
+		for _, w := range line {
+			if w.space {
+				b.WriteString(" ")
+			}
+			b.Write(w.text)
+		}
+		got = append(got, b.String())
+	}
+
+	if strings.Join(got, "|") != "one two|three four|five" {
+		t.Errorf("unexpected wrapping %q", got)
+	}
+}
+
+func TestFromHTML(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Fatal(err)
+	}
+	src := "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes())
+
+	var body bytes.Buffer
+	body.WriteString(`<html><head><title>Audit (2016)</title><meta name="author" content="Jane Doe"><meta name="revised" content="2016-05-01T10:00:00Z"></head><body>`)
+	body.WriteString(`<h1 id="page-a">Introduction</h1><p>See <a href="https://documize.com">our site</a>.</p><img src="` + src + `">`)
+	for i := 0; i < 80; i++ {
+		body.WriteString(`<h2 id="page-` + strconv.Itoa(i) + `">Section</h2><p>Lorem ipsum dolor sit amet.</p>`)
+	}
+	body.WriteString(`<table><tr><th>Name</th></tr><tr><td>Value</td></tr></table><pre>x := 1</pre></body></html>`)
+
+	file, err := FromHTML(body.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(file, []byte("%PDF-1.4")) || !bytes.HasSuffix(file, []byte("%%EOF\n")) {
+		t.Fatal("not a PDF file")
+	}
+
+	for _, want := range []string{
+		`/Title (Audit \(2016\))`,
+		`/Author (Jane Doe)`,
+		`/ModDate (D:20160501100000Z)`,
+		`/URI (https://documize.com)`,
+		`/Subtype /Image /Width 4 /Height 3`,
+		`/Dest [`,
+	} {
+		if !bytes.Contains(file, []byte(want)) {
+			t.Errorf("expected %q in PDF", want)
+		}
+	}
+
+	// the cross-reference table must point at each object
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(file, -1)
+	for i, o := range offsets {
+		at, _ := strconv.Atoi(string(o[1]))
+		if !bytes.HasPrefix(file[at:], []byte(strconv.Itoa(i+1)+" 0 obj")) {
+			t.Fatalf("bad offset for object %d", i+1)
+		}
+	}
+
+	text := contentText(t, file)
+	count := regexp.MustCompile(`/Type /Page /`).FindAll(file, -1)
+	for _, want := range []string{
+		"Contents",
+		"Last revised", "1 May 2016", "Jane Doe",
+		"Introduction", "Page 2 of " + strconv.Itoa(len(count)),
+		"Value", "x := 1",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in page content", want)
+		}
+	}
+	if len(count) < 4 {
+		t.Errorf("expected cover, contents and body pages, got %d pages", len(count))
+	}
+}
+
+// contentText returns the uncompressed content streams of the file.
+func contentText(t *testing.T, file []byte) string {
+	var out bytes.Buffer
+	streams := regexp.MustCompile(`(?s)<<  /Filter /FlateDecode /Length (\d+) >>\nstream\n`)
+	for _, loc := range streams.FindAllSubmatchIndex(file, -1) {
+		n, _ := strconv.Atoi(string(file[loc[2]:loc[3]]))
+		z, err := zlib.NewReader(bytes.NewReader(file[loc[1] : loc[1]+n]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(z)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.Write(data)
+	}
+	return out.String()
+}
+
+func TestTextString(t *testing.T) {
+	if got := textString("Audit (2016)"); got != `(Audit \(2016\))` {
+		t.Errorf("unexpected ASCII string %s", got)
+	}
+	if got := textString("Café €"); got != "<FEFF00430061006600E9002020AC>" {
+		t.Errorf("unexpected UTF-16 string %s", got)
+	}
+	if got := textString("𝄞"); got != "<FEFFD834DD1E>" {
+		t.Errorf("unexpected surrogate pair %s", got)
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+
+	uri := func(data []byte) string {
+		return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data)
+	}
+
+	if p := decodeImage(uri(b.Bytes())); p == nil || p.width != 2 || p.height != 2 {
+		t.Fatalf("image not decoded: %+v", p)
+	}
+
+	// a small file may declare a huge image, which is left out rather than decoded
+	huge := append([]byte{}, b.Bytes()...)
+	binary.BigEndian.PutUint32(huge[16:], 50000)
+	binary.BigEndian.PutUint32(huge[20:], 50000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+	if p := decodeImage(uri(huge)); p != nil {
+		t.Errorf("expected an image of %dx%d to be left out", p.width, p.height)
+	}
+}
+
+func TestHighlightedCode(t *testing.T) {
+	_, blocks, err := parse([]byte(`<pre style="background-color: #002b36; color: #93a1a1;">
+<span style="color: #859900;">func</span> main()
+</pre>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || len(blocks[0].code) != 2 || blocks[0].fill == nil {
+		t.Fatalf("unexpected blocks %+v", blocks)
+	}
+
+	l := newLayout()
+	l.flow(blocks)
+	content := l.pages[0].content.String()
+
+	for _, want := range []string{
+		"0.000 0.169 0.212 rg",
+		"0.522 0.600 0.000 rg",
+		"(func) Tj",
+		"( main\\(\\)) Tj",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in page content", want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"unicode/utf16"
+)
+
+// writer accumulates numbered PDF objects and serializes them with a cross-reference table.
+type writer struct {
+	objects [][]byte // object number n is held at index n-1
+}
+
+// reserve allocates an object number to be filled in later.
+func (w *writer) reserve() int {
+	w.objects = append(w.objects, nil)
+	return len(w.objects)
+}
+
+// set provides the body of a reserved object.
+func (w *writer) set(n int, body string) {
+	w.objects[n-1] = []byte(body)
+}
+
+// add appends a new object, returning its number.
+func (w *writer) add(body string) int {
+	n := w.reserve()
+	w.set(n, body)
+	return n
+}
+
+// stream appends a stream object, compressing the data unless a filter is already given.
+func (w *writer) stream(dict string, data []byte, filter string) (int, error) {
+	if len(filter) == 0 {
+		var b bytes.Buffer
+		z := zlib.NewWriter(&b)
+		if _, err := z.Write(data); err != nil {
+			return 0, err
+		}
+		if err := z.Close(); err != nil {
+			return 0, err
+		}
+		data = b.Bytes()
+		filter = "FlateDecode"
+	}
+
+	n := w.reserve()
+	var b bytes.Buffer
+	b.WriteString(fmt.Sprintf("<< %s /Filter /%s /Length %d >>\nstream\n", dict, filter, len(data)))
+	b.Write(data)
+	b.WriteString("\nendstream")
+	w.objects[n-1] = b.Bytes()
+
+	return n, nil
+}
+
+// bytes serializes the complete file.
+func (w *writer) bytes(root, info int) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(w.objects))
+	for i, o := range w.objects {
+		offsets[i] = b.Len()
+		b.WriteString(fmt.Sprintf("%d 0 obj\n", i+1))
+		b.Write(o)
+		b.WriteString("\nendobj\n")
+	}
+
+	xref := b.Len()
+	b.WriteString(fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(w.objects)+1))
+	for _, o := range offsets {
+		b.WriteString(fmt.Sprintf("%010d 00000 n \n", o))
+	}
+	b.WriteString(fmt.Sprintf("trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.objects)+1, root, info, xref))
+
+	return b.Bytes()
+}
+
+// literal returns text as a PDF string literal.
+func literal(s string) string {
+	return "(" + string(escape(encode(s))) + ")"
+}
+
+// textString returns text for the document information dictionary, which viewers read
+// as PDFDocEncoding unless the string is UTF-16BE starting with a byte order mark.
+func textString(s string) string {
+	ascii := true
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			ascii = false
+			break
+		}
+	}
+
+	if ascii {
+		return literal(s)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		b.WriteString(fmt.Sprintf("%04X", u))
+	}
+	b.WriteString(">")
+
+	return b.String()
+}
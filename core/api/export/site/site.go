@@ -0,0 +1,197 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package site builds a static web site from the documents within a space,
+// suitable for publishing to any static web host.
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/documize/community/core/api/export"
+)
+
+// Stylesheet is the name of the stylesheet shared by every page of the site.
+const Stylesheet = "style.css"
+
+// Document is a document published as a page of the site.
+type Document struct {
+	export.Document
+	Name    string          // file name without the .html extension
+	Resolve export.Resolver // rewrites links within page content, may be nil
+}
+
+type index struct {
+	Title     string
+	Space     string
+	Documents []summary
+}
+
+type summary struct {
+	Name    string
+	Title   string
+	Excerpt string
+	Revised string
+}
+
+type page struct {
+	Space    string
+	Title    string
+	Author   string
+	Revised  string
+	Contents []entry
+	Pages    []section
+}
+
+type entry struct {
+	Anchor string
+	Title  string
+	Level  int
+}
+
+type section struct {
+	Anchor string
+	Title  string
+	Level  int
+	Body   template.HTML
+}
+
+// Build returns the files of the site: an index page, the stylesheet and one HTML file per document.
+func Build(space string, docs []Document) (files []export.File, err error) {
+	in := index{Title: space, Space: space}
+
+	for _, d := range docs {
+		in.Documents = append(in.Documents, summary{
+			Name:    d.Name,
+			Title:   d.Document.Document.Title,
+			Excerpt: d.Document.Document.Excerpt,
+			Revised: revised(d),
+		})
+
+		var p page
+		p.Space = space
+		p.Title = d.Document.Document.Title
+		p.Author = d.Author
+		p.Revised = revised(d)
+
+		for _, pg := range d.Pages {
+			anchor := export.PageAnchor(pg.RefID)
+			level := export.HeadingLevel(pg.Level)
+			body := pg.Body
+			if d.Resolve != nil {
+				body = export.ResolveLinks(body, d.Resolve)
+			}
+			p.Contents = append(p.Contents, entry{Anchor: anchor, Title: pg.Title, Level: level})
+			p.Pages = append(p.Pages, section{Anchor: anchor, Title: pg.Title, Level: level, Body: template.HTML(body)})
+		}
+
+		var b bytes.Buffer
+		if err = documentTemplate.Execute(&b, p); err != nil {
+			return
+		}
+		files = append(files, export.File{Path: d.Name + ".html", Data: b.Bytes()})
+	}
+
+	var b bytes.Buffer
+	if err = indexTemplate.Execute(&b, in); err != nil {
+		return
+	}
+
+	files = append([]export.File{
+		{Path: "index.html", Data: b.Bytes()},
+		{Path: Stylesheet, Data: []byte(strings.TrimSpace(stylesheet) + "\n")},
+	}, files...)
+
+	return files, nil
+}
+
+func revised(d Document) string {
+	if d.Document.Document.Revised.IsZero() {
+		return ""
+	}
+	return d.Document.Document.Revised.Format("2 January 2006")
+}
+
+var funcs = template.FuncMap{
+	"heading": func(level int, anchor, title string) template.HTML {
+		return template.HTML(fmt.Sprintf(`<h%d id="%s">%s</h%d>`, level, template.HTMLEscapeString(anchor), template.HTMLEscapeString(title), level))
+	},
+}
+
+const head = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="` + Stylesheet + `">
+</head>
+`
+
+var indexTemplate = template.Must(template.New("index").Parse(head + `<body>
+<header><a href="index.html">{{.Space}}</a></header>
+<main>
+<h1>{{.Space}}</h1>
+<ul class="documents">
+{{range .Documents}}<li><a href="{{.Name}}.html">{{.Title}}</a>{{if .Revised}} <span class="revised">{{.Revised}}</span>{{end}}{{if .Excerpt}}<p>{{.Excerpt}}</p>{{end}}</li>
+{{end}}</ul>
+</main>
+</body>
+</html>
+`))
+
+var documentTemplate = template.Must(template.New("document").Funcs(funcs).Parse(head + `<body>
+<header><a href="index.html">{{.Space}}</a></header>
+<main>
+<h1 class="title">{{.Title}}</h1>
+{{if or .Author .Revised}}<p class="meta">{{if .Author}}{{.Author}}{{end}}{{if and .Author .Revised}} &middot; {{end}}{{if .Revised}}{{.Revised}}{{end}}</p>{{end}}
+{{if .Contents}}<nav class="contents">
+<ul>
+{{range .Contents}}<li class="level-{{.Level}}"><a href="#{{.Anchor}}">{{.Title}}</a></li>
+{{end}}</ul>
+</nav>{{end}}
+{{range .Pages}}<section>
+{{heading .Level .Anchor .Title}}
+{{.Body}}
+</section>
+{{end}}</main>
+</body>
+</html>
+`))
+
+const stylesheet = `
+body { margin: 0; font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; font-size: 16px; line-height: 1.6; color: #222; }
+header { padding: 12px 24px; background: #f4f5f7; border-bottom: 1px solid #e1e4e8; }
+header a { color: #444; font-weight: bold; text-decoration: none; }
+main { max-width: 860px; margin: 0 auto; padding: 24px; }
+a { color: #0366d6; }
+h1, h2, h3, h4, h5, h6 { line-height: 1.25; margin: 1.5em 0 0.5em; }
+h1.title { margin-top: 0.5em; }
+.meta, .revised { color: #6a737d; font-size: 0.9em; }
+.documents { list-style: none; padding: 0; }
+.documents li { padding: 12px 0; border-bottom: 1px solid #eaecef; }
+.documents p { margin: 4px 0 0; color: #555; }
+.contents { margin: 16px 0 32px; padding: 12px 16px; background: #f6f8fa; border-radius: 4px; }
+.contents ul { list-style: none; margin: 0; padding: 0; }
+.contents .level-2 { padding-left: 16px; }
+.contents .level-3 { padding-left: 32px; }
+.contents .level-4, .contents .level-5, .contents .level-6 { padding-left: 48px; }
+img { max-width: 100%; }
+pre { padding: 12px; overflow: auto; background: #f6f8fa; border-radius: 4px; font-size: 0.875em; }
+code { font-family: Consolas, Menlo, monospace; }
+table { border-collapse: collapse; margin: 16px 0; }
+th, td { padding: 6px 12px; border: 1px solid #dfe2e5; }
+th { background: #f6f8fa; }
+blockquote { margin: 0; padding: 0 16px; color: #6a737d; border-left: 4px solid #dfe2e5; }
+`
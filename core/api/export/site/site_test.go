@@ -0,0 +1,81 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package site_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/export"
+	"github.com/documize/community/core/api/export/site"
+)
+
+func TestBuild(t *testing.T) {
+	resolve := func(l export.Link) string {
+		if l.LinkType == "section" {
+			return "guide.html#" + export.PageAnchor(l.TargetID)
+		}
+		return l.URL
+	}
+
+	docs := []site.Document{
+		{
+			Name: "api",
+			Document: export.Document{
+				Document: entity.Document{Title: "API <Reference>", Excerpt: "All endpoints"},
+				Pages: []entity.Page{
+					{BaseEntity: entity.BaseEntity{RefID: "p1"}, Level: 1, Title: "Auth", Body: `<p>See <a data-link-type="section" data-link-target-id="p9" href="/link/section/x">setup</a></p>`},
+					{BaseEntity: entity.BaseEntity{RefID: "p2"}, Level: 2, Title: "Tokens", Body: `<p>Bearer</p>`},
+				},
+				Author: "Jane Doe",
+			},
+			Resolve: resolve,
+		},
+		{Name: "guide", Document: export.Document{Document: entity.Document{Title: "Guide"}}},
+	}
+
+	files, err := site.Build("Public Docs", docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := make(map[string]string)
+	for _, f := range files {
+		content[f.Path] = string(f.Data)
+	}
+
+	for _, name := range []string{"index.html", site.Stylesheet, "api.html", "guide.html"} {
+		if _, ok := content[name]; !ok {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	for _, want := range []string{`<a href="api.html">API &lt;Reference&gt;</a>`, `<p>All endpoints</p>`, `<a href="guide.html">Guide</a>`} {
+		if !strings.Contains(content["index.html"], want) {
+			t.Errorf("expected %s in index", want)
+		}
+	}
+
+	for _, want := range []string{
+		`<link rel="stylesheet" href="style.css">`,
+		`<a href="index.html">Public Docs</a>`,
+		`<li class="level-2"><a href="#page-p2">Tokens</a></li>`,
+		`<h1 id="page-p1">Auth</h1>`,
+		`href="guide.html#page-p9"`,
+		`Jane Doe`,
+	} {
+		if !strings.Contains(content["api.html"], want) {
+			t.Errorf("expected %s in document", want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package transfer provides a lossless JSON representation of a document,
+// used to move documents between Documize instances.
+// The package holds everything needed to recreate the document: its pages with their
+// raw section data, the revision history, attachments and content links.
+package transfer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/api/entity"
+)
+
+// Version is the format version written by this instance.
+// Packages written by a later version cannot be imported.
+const Version = 1
+
+// Package is a document together with everything it owns.
+type Package struct {
+	Version     int               `json:"version"`
+	Exported    time.Time         `json:"exported"`
+	Document    entity.Document   `json:"document"`
+	Pages       []Page            `json:"pages"`
+	Revisions   []entity.Revision `json:"revisions"`
+	Attachments []Attachment      `json:"attachments"`
+	Links       []entity.Link     `json:"links"`
+}
+
+// Page is a document page with the meta data used to render it, in presentation sequence.
+type Page struct {
+	Page entity.Page     `json:"page"`
+	Meta entity.PageMeta `json:"meta"`
+}
+
+// Attachment is a document attachment including its data.
+type Attachment struct {
+	entity.Attachment
+	Data []byte `json:"data"`
+}
+
+// Marshal returns the package as JSON.
+func Marshal(p Package) ([]byte, error) {
+	p.Version = Version
+	p.Exported = time.Now().UTC()
+
+	return json.Marshal(p)
+}
+
+// Unmarshal reads a package written by this or an earlier version.
+func Unmarshal(data []byte) (p Package, err error) {
+	err = json.Unmarshal(data, &p)
+	if err != nil {
+		return
+	}
+
+	if p.Version < 1 || p.Version > Version {
+		err = fmt.Errorf("unsupported document package version %d", p.Version)
+		return
+	}
+
+	if len(p.Document.RefID) == 0 {
+		err = errors.New("document package has no document")
+		return
+	}
+
+	return
+}
+
+// Rekey gives the document and everything it owns new identifiers, placing the document in the given folder.
+// References held within page content, section data and revisions are rewritten to match,
+// and attachment links are pointed at the attachment URL returned for each new attachment ID.
+// Links to other documents are left as they are.
+func (p *Package) Rekey(folderID string, newID func() string, attachmentURL func(attachmentID string) string) {
+	ids := make(map[string]string)
+	rekey := func(id string) string {
+		if len(id) == 0 {
+			return id
+		}
+		if n, ok := ids[id]; ok {
+			return n
+		}
+		ids[id] = newID()
+		return ids[id]
+	}
+
+	documentID := rekey(p.Document.RefID)
+	for _, pg := range p.Pages {
+		rekey(pg.Page.RefID)
+	}
+	attachments := make(map[string]bool)
+	for _, a := range p.Attachments {
+		attachments[rekey(a.RefID)] = true
+	}
+	for _, r := range p.Revisions {
+		rekey(r.RefID)
+		rekey(r.PageID) // revisions may outlive their page
+	}
+	for _, l := range p.Links {
+		rekey(l.RefID)
+	}
+
+	swap := func(id string) string {
+		if n, ok := ids[id]; ok {
+			return n
+		}
+		return id
+	}
+
+	var pairs []string
+	for o, n := range ids {
+		pairs = append(pairs, o, n)
+	}
+	r := strings.NewReplacer(pairs...)
+
+	content := func(s string) string {
+		s = r.Replace(s)
+		s = attachmentLink.ReplaceAllStringFunc(s, func(m string) string {
+			id := attachmentLink.FindStringSubmatch(m)[1]
+			if !attachments[id] {
+				return m
+			}
+			return attachmentURL(id)
+		})
+		s = spaceLink.ReplaceAllStringFunc(s, func(m string) string {
+			if !strings.Contains(m, documentID) {
+				return m
+			}
+			return spaceID.ReplaceAllString(m, "${1}"+folderID+"${2}")
+		})
+		return s
+	}
+
+	p.Document.RefID = documentID
+	p.Document.LabelID = folderID
+
+	for i := range p.Pages {
+		pg := &p.Pages[i]
+		pg.Page.RefID = ids[pg.Page.RefID]
+		pg.Page.DocumentID = documentID
+		pg.Page.Body = content(pg.Page.Body)
+		pg.Meta.PageID = pg.Page.RefID
+		pg.Meta.DocumentID = documentID
+		pg.Meta.RawBody = content(pg.Meta.RawBody)
+		pg.Meta.Config = content(pg.Meta.Config)
+	}
+
+	for i := range p.Revisions {
+		rv := &p.Revisions[i]
+		rv.RefID = ids[rv.RefID]
+		rv.DocumentID = documentID
+		rv.PageID = ids[rv.PageID]
+		rv.Body = content(rv.Body)
+		rv.RawBody = content(rv.RawBody)
+		rv.Config = content(rv.Config)
+	}
+
+	for i := range p.Attachments {
+		a := &p.Attachments[i]
+		a.RefID = ids[a.RefID]
+		a.DocumentID = documentID
+	}
+
+	for i := range p.Links {
+		l := &p.Links[i]
+		l.RefID = ids[l.RefID]
+		l.FolderID = folderID
+		l.SourceDocumentID = documentID
+		l.SourcePageID = swap(l.SourcePageID)
+		l.TargetDocumentID = swap(l.TargetDocumentID)
+		l.TargetID = swap(l.TargetID)
+	}
+}
+
+// Internal reports whether the link targets the document itself.
+func (p *Package) Internal(l entity.Link) bool {
+	return l.TargetDocumentID == p.Document.RefID
+}
+
+var (
+	// attachmentLink matches the URL of an attachment, whichever host and organization it was served from
+	attachmentLink = regexp.MustCompile(`(?:https?://[^\s'"<>]*)?/api/public/attachments/[^/\s'"<>]+/([^/\s'"<>?#]+)`)
+
+	// spaceLink matches the opening tag of a content link
+	spaceLink = regexp.MustCompile(`<a\s[^>]*data-link-space-id=[^>]*>`)
+	spaceID   = regexp.MustCompile(`(data-link-space-id=['"]?)[^'"\s>]*(['"]?)`)
+)
@@ -0,0 +1,120 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package transfer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/export/transfer"
+)
+
+func TestRoundTrip(t *testing.T) {
+	in := transfer.Package{
+		Document: entity.Document{BaseEntity: entity.BaseEntity{RefID: "doc1"}, LabelID: "space1", Title: "Runbook", Tags: "#ops#"},
+		Pages: []transfer.Page{
+			{
+				Page: entity.Page{BaseEntity: entity.BaseEntity{RefID: "page1"}, DocumentID: "doc1", Sequence: 1024, Level: 1, Title: "Start",
+					Body: `<p><a data-documize='true' data-link-space-id='space1' data-link-id='link1' data-link-target-document-id='doc1' data-link-target-id='page2' data-link-type='section' href='/link/section/link1'>Stop</a> ` +
+						`<a data-documize='true' data-link-space-id='space9' data-link-id='link2' data-link-target-document-id='doc9' data-link-target-id='' data-link-type='document' href='/link/document/link2'>Other</a> ` +
+						`<a href='https://old.example.com/api/public/attachments/org1/att1'>diagram</a></p>`},
+				Meta: entity.PageMeta{PageID: "page1", DocumentID: "doc1", RawBody: `<p>raw</p>`, Config: `{}`},
+			},
+			{
+				Page: entity.Page{BaseEntity: entity.BaseEntity{RefID: "page2"}, DocumentID: "doc1", Sequence: 2048, Level: 2, Title: "Stop", ContentType: "code"},
+				Meta: entity.PageMeta{PageID: "page2", DocumentID: "doc1", RawBody: `{"code":"x"}`, Config: `{"mode":"go"}`},
+			},
+		},
+		Revisions: []entity.Revision{
+			{BaseEntity: entity.BaseEntity{RefID: "rev1"}, DocumentID: "doc1", PageID: "page1", Body: `<p>old</p>`},
+			{BaseEntity: entity.BaseEntity{RefID: "rev2"}, DocumentID: "doc1", PageID: "gone1", Body: `<p>deleted</p>`},
+		},
+		Attachments: []transfer.Attachment{
+			{Attachment: entity.Attachment{BaseEntity: entity.BaseEntity{RefID: "att1"}, DocumentID: "doc1", Filename: "diagram.png"}, Data: []byte{1, 2, 3}},
+		},
+		Links: []entity.Link{
+			{BaseEntity: entity.BaseEntity{RefID: "link1"}, FolderID: "space1", LinkType: "section", SourceDocumentID: "doc1", SourcePageID: "page1", TargetDocumentID: "doc1", TargetID: "page2"},
+			{BaseEntity: entity.BaseEntity{RefID: "link2"}, FolderID: "space1", LinkType: "document", SourceDocumentID: "doc1", SourcePageID: "page1", TargetDocumentID: "doc9"},
+		},
+	}
+
+	data, err := transfer.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := transfer.Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Version != transfer.Version || len(out.Pages) != 2 || len(out.Revisions) != 2 || len(out.Links) != 2 {
+		t.Fatal("package not read back intact")
+	}
+	if string(out.Attachments[0].Data) != "\x01\x02\x03" {
+		t.Error("attachment data lost")
+	}
+	if out.Pages[1].Meta.Config != `{"mode":"go"}` || out.Pages[1].Meta.RawBody != `{"code":"x"}` {
+		t.Error("section data lost")
+	}
+
+	n := 0
+	out.Rekey("space2", func() string {
+		n++
+		return fmt.Sprintf("new%d", n)
+	}, func(id string) string {
+		return "https://new.example.com/api/public/attachments/org2/" + id
+	})
+
+	doc := out.Document.RefID
+	if doc == "doc1" || out.Document.LabelID != "space2" {
+		t.Fatal("document not rekeyed")
+	}
+
+	page1, page2 := out.Pages[0].Page.RefID, out.Pages[1].Page.RefID
+	if out.Pages[0].Meta.PageID != page1 || out.Pages[1].Page.DocumentID != doc || out.Pages[1].Meta.DocumentID != doc {
+		t.Error("pages not rekeyed")
+	}
+
+	body := out.Pages[0].Page.Body
+	for _, want := range []string{
+		"data-link-space-id='space2' data-link-id='" + out.Links[0].RefID + "' data-link-target-document-id='" + doc + "' data-link-target-id='" + page2 + "'",
+		"data-link-space-id='space9' data-link-id='" + out.Links[1].RefID + "' data-link-target-document-id='doc9'",
+		"href='https://new.example.com/api/public/attachments/org2/" + out.Attachments[0].RefID + "'",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %s", want, body)
+		}
+	}
+
+	if out.Revisions[0].PageID != page1 || out.Revisions[1].PageID == "gone1" || out.Revisions[0].DocumentID != doc {
+		t.Error("revisions not rekeyed")
+	}
+
+	if l := out.Links[0]; l.SourcePageID != page1 || l.TargetDocumentID != doc || l.TargetID != page2 || l.FolderID != "space2" || !out.Internal(l) {
+		t.Error("internal link not rekeyed")
+	}
+	if l := out.Links[1]; l.TargetDocumentID != "doc9" || out.Internal(l) {
+		t.Error("external link changed")
+	}
+}
+
+func TestUnmarshalVersion(t *testing.T) {
+	if _, err := transfer.Unmarshal([]byte(`{"version":99,"document":{"id":"x"}}`)); err == nil {
+		t.Error("expected error for later version")
+	}
+	if _, err := transfer.Unmarshal([]byte(`{"version":1}`)); err == nil {
+		t.Error("expected error for missing document")
+	}
+}
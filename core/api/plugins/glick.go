@@ -21,6 +21,10 @@ import (
 	"github.com/documize/community/core/api/convert/documizeapi"
 	"github.com/documize/community/core/api/convert/html"
 	"github.com/documize/community/core/api/convert/md"
+	exportdocx "github.com/documize/community/core/api/export/docx"
+	exportepub "github.com/documize/community/core/api/export/epub"
+	exportmd "github.com/documize/community/core/api/export/md"
+	exportpdf "github.com/documize/community/core/api/export/pdf"
 	"github.com/documize/community/core/api/request"
 	api "github.com/documize/community/core/convapi"
 	"github.com/documize/community/core/log"
@@ -105,6 +109,28 @@ func LibSetup() error {
 		return err
 	}
 
+	for _, xtn := range []string{"md", "markdown"} {
+		err = Lib.RegPlugin("Export", xtn, exportmd.Export, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = Lib.RegPlugin("Export", "pdf", exportpdf.Export, nil)
+	if err != nil {
+		return err
+	}
+
+	err = Lib.RegPlugin("Export", "epub", exportepub.Export, nil)
+	if err != nil {
+		return err
+	}
+
+	err = Lib.RegPlugin("Export", "docx", exportdocx.Export, nil) // may be replaced by a configured plugin
+	if err != nil {
+		return err
+	}
+
 	var json = make([]byte, 0)
 	if PluginFile == "DB" {
 		json = []byte(request.ConfigString("FILEPLUGINS", ""))
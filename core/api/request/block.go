@@ -26,10 +26,11 @@ import (
 func (p *Persister) AddBlock(b entity.Block) (err error) {
 	b.OrgID = p.Context.OrgID
 	b.UserID = p.Context.UserID
+	b.Version = 1
 	b.Created = time.Now().UTC()
 	b.Revised = time.Now().UTC()
 
-	stmt, err := p.Context.Transaction.Preparex("INSERT INTO block (refid, orgid, labelid, userid, contenttype, pagetype, title, body, excerpt, rawbody, config, externalsource, used, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO block (refid, orgid, labelid, shared, userid, contenttype, pagetype, title, body, excerpt, rawbody, config, externalsource, used, version, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -37,7 +38,7 @@ func (p *Persister) AddBlock(b entity.Block) (err error) {
 		return
 	}
 
-	_, err = stmt.Exec(b.RefID, b.OrgID, b.LabelID, b.UserID, b.ContentType, b.PageType, b.Title, b.Body, b.Excerpt, b.RawBody, b.Config, b.ExternalSource, b.Used, b.Created, b.Revised)
+	_, err = stmt.Exec(b.RefID, b.OrgID, b.LabelID, b.Shared, b.UserID, b.ContentType, b.PageType, b.Title, b.Body, b.Excerpt, b.RawBody, b.Config, b.ExternalSource, b.Used, b.Version, b.Created, b.Revised)
 
 	if err != nil {
 		log.Error("Unable to execute insert AddBlock", err)
@@ -49,7 +50,7 @@ func (p *Persister) AddBlock(b entity.Block) (err error) {
 
 // GetBlock returns requested reusable content block.
 func (p *Persister) GetBlock(id string) (b entity.Block, err error) {
-	stmt, err := Db.Preparex("SELECT a.id, a.refid, a.orgid, a.labelid, a.userid, a.contenttype, a.pagetype, a.title, a.body, a.excerpt, a.rawbody, a.config, a.externalsource, a.used, a.created, a.revised, b.firstname, b.lastname FROM block a LEFT JOIN user b ON a.userid = b.refid WHERE a.orgid=? AND a.refid=?")
+	stmt, err := Db.Preparex("SELECT a.id, a.refid, a.orgid, a.labelid, a.shared, a.userid, a.contenttype, a.pagetype, a.title, a.body, a.excerpt, a.rawbody, a.config, a.externalsource, a.used, a.version, a.created, a.revised, b.firstname, b.lastname FROM block a LEFT JOIN user b ON a.userid = b.refid WHERE a.orgid=? AND a.refid=?")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -66,9 +67,9 @@ func (p *Persister) GetBlock(id string) (b entity.Block, err error) {
 	return
 }
 
-// GetBlocksForSpace returns all reusable content scoped to given space.
+// GetBlocksForSpace returns all reusable content scoped to given space, and that shared with every space.
 func (p *Persister) GetBlocksForSpace(labelID string) (b []entity.Block, err error) {
-	err = Db.Select(&b, "SELECT a.id, a.refid, a.orgid, a.labelid, a.userid, a.contenttype, a.pagetype, a.title, a.body, a.excerpt, a.rawbody, a.config, a.externalsource, a.used, a.created, a.revised, b.firstname, b.lastname FROM block a LEFT JOIN user b ON a.userid = b.refid WHERE a.orgid=? AND a.labelid=? ORDER BY a.title", p.Context.OrgID, labelID)
+	err = Db.Select(&b, "SELECT a.id, a.refid, a.orgid, a.labelid, a.shared, a.userid, a.contenttype, a.pagetype, a.title, a.body, a.excerpt, a.rawbody, a.config, a.externalsource, a.used, a.version, a.created, a.revised, b.firstname, b.lastname FROM block a LEFT JOIN user b ON a.userid = b.refid WHERE a.orgid=? AND (a.labelid=? OR a.shared=1) ORDER BY a.title", p.Context.OrgID, labelID)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute select GetBlocksForSpace org %s and label %s", p.Context.OrgID, labelID), err)
@@ -114,9 +115,9 @@ func (p *Persister) DecrementBlockUsage(id string) (err error) {
 	return
 }
 
-// RemoveBlockReference clears page.blockid for given blockID.
+// RemoveBlockReference clears page.blockid for given blockID, linked pages keep their copy of the content.
 func (p *Persister) RemoveBlockReference(id string) (err error) {
-	stmt, err := p.Context.Transaction.Preparex("UPDATE page SET blockid='', revised=? WHERE orgid=? AND blockid=?")
+	stmt, err := p.Context.Transaction.Preparex("UPDATE page SET blockid='', blocklinked=0, revised=? WHERE orgid=? AND blockid=?")
 	defer streamutil.Close(stmt)
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to prepare update RemoveBlockReference id %s", id), err)
@@ -138,20 +139,65 @@ func (p *Persister) RemoveBlockReference(id string) (err error) {
 	return
 }
 
-// UpdateBlock updates existing reusable content block item.
-func (p *Persister) UpdateBlock(b entity.Block) (err error) {
+// GetLinkedPages returns the pages that follow changes to the content block.
+func (p *Persister) GetLinkedPages(id string) (pages []entity.Page, err error) {
+	err = Db.Select(&pages, "SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.revisions, a.blockid, a.blocklinked, a.blockversion, a.created, a.revised FROM page a WHERE a.orgid=? AND a.blockid=? AND a.blocklinked=1", p.Context.OrgID, id)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select GetLinkedPages block %s", id), err)
+		return
+	}
+
+	return
+}
+
+// DetachPage turns a page linked to a content block into a copy, so later changes to the block no longer reach it.
+func (p *Persister) DetachPage(pageID string) (err error) {
+	stmt, err := p.Context.Transaction.Preparex("UPDATE page SET blocklinked=0 WHERE orgid=? AND refid=?")
+	defer streamutil.Close(stmt)
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to prepare update DetachPage id %s", pageID), err)
+		return
+	}
+
+	_, err = stmt.Exec(p.Context.OrgID, pageID)
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute DetachPage id %s", pageID), err)
+		return
+	}
+
+	return
+}
+
+// UpdateBlock updates existing reusable content block item, keeping its previous content as a revision.
+func (p *Persister) UpdateBlock(b entity.Block, refID string) (err error) {
 	b.Revised = time.Now().UTC()
 
-	var stmt *sqlx.NamedStmt
-	stmt, err = p.Context.Transaction.PrepareNamed("UPDATE block SET title=:title, body=:body, excerpt=:excerpt, rawbody=:rawbody, config=:config, revised=:revised WHERE orgid=:orgid AND refid=:refid")
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO blockrevision (refid, orgid, blockid, userid, version, title, body, excerpt, rawbody, config, created, revised) SELECT ? as refid, orgid, refid as blockid, ? as userid, version, title, body, excerpt, rawbody, config, ? as created, ? as revised FROM block WHERE orgid=? AND refid=?")
 	defer streamutil.Close(stmt)
 
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to prepare insert for block revision %s", b.RefID), err)
+		return
+	}
+
+	_, err = stmt.Exec(refID, p.Context.UserID, b.Revised, b.Revised, p.Context.OrgID, b.RefID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute insert for block revision %s", b.RefID), err)
+		return
+	}
+
+	var stmt2 *sqlx.NamedStmt
+	stmt2, err = p.Context.Transaction.PrepareNamed("UPDATE block SET title=:title, body=:body, excerpt=:excerpt, rawbody=:rawbody, config=:config, shared=:shared, version=version+1, revised=:revised WHERE orgid=:orgid AND refid=:refid")
+	defer streamutil.Close(stmt2)
+
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to prepare update UpdateBlock %s", b.RefID), err)
 		return
 	}
 
-	_, err = stmt.Exec(&b)
+	_, err = stmt2.Exec(&b)
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute update UpdateBlock %s", b.RefID), err)
 		return
@@ -160,7 +206,89 @@ func (p *Persister) UpdateBlock(b entity.Block) (err error) {
 	return
 }
 
-// DeleteBlock removes reusable content block from database.
+// SetPageBlockVersion records the version of its block a page now has.
+func (p *Persister) SetPageBlockVersion(pageID string, version uint64) (err error) {
+	stmt, err := p.Context.Transaction.Preparex("UPDATE page SET blockversion=? WHERE orgid=? AND refid=?")
+	defer streamutil.Close(stmt)
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to prepare update SetPageBlockVersion id %s", pageID), err)
+		return
+	}
+
+	_, err = stmt.Exec(version, p.Context.OrgID, pageID)
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute SetPageBlockVersion id %s", pageID), err)
+		return
+	}
+
+	return
+}
+
+// GetBlockRevisions returns the previous versions of a content block, latest first, without their content.
+func (p *Persister) GetBlockRevisions(id string) (revisions []entity.BlockRevision, err error) {
+	err = Db.Select(&revisions, "SELECT a.id, a.refid, a.orgid, a.blockid, a.userid, a.version, a.title, a.excerpt, a.created, a.revised, coalesce(b.firstname,'') as firstname, coalesce(b.lastname,'') as lastname FROM blockrevision a LEFT JOIN user b ON a.userid=b.refid WHERE a.orgid=? AND a.blockid=? ORDER BY a.version DESC", p.Context.OrgID, id)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select GetBlockRevisions block %s", id), err)
+		return
+	}
+
+	return
+}
+
+// GetBlockRevision returns a previous version of a content block.
+func (p *Persister) GetBlockRevision(revisionID string) (revision entity.BlockRevision, err error) {
+	stmt, err := Db.Preparex("SELECT id, refid, orgid, blockid, userid, version, title, body, excerpt, coalesce(rawbody, '') as rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, created, revised FROM blockrevision WHERE orgid=? AND refid=?")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to prepare select GetBlockRevision %s", revisionID), err)
+		return
+	}
+
+	err = stmt.Get(&revision, p.Context.OrgID, revisionID)
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select GetBlockRevision %s", revisionID), err)
+		return
+	}
+
+	return
+}
+
+// GetBlockUsage returns the pages inserted from a content block, in documents the user can see, with the version each has.
+func (p *Persister) GetBlockUsage(id string) (usage []entity.BlockUsage, err error) {
+	err = Db.Select(&usage,
+		`SELECT a.documentid, d.title as documenttitle, d.labelid, a.refid as pageid, a.title as pagetitle, a.blocklinked as linked, a.blockversion as version
+		FROM page a JOIN document d ON a.documentid=d.refid WHERE a.orgid=? AND a.blockid=? AND d.labelid IN
+		(SELECT refid from label WHERE orgid=? AND type=2 AND userid=?
+		UNION ALL SELECT refid FROM label a where orgid=? AND type=1 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid='' AND (canedit=1 OR canview=1))
+		UNION ALL SELECT refid FROM label a where orgid=? AND type=3 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid=? AND (canedit=1 OR canview=1)))
+		ORDER BY a.blockversion DESC, d.title, a.sequence`,
+		p.Context.OrgID,
+		id,
+		p.Context.OrgID,
+		p.Context.UserID,
+		p.Context.OrgID,
+		p.Context.OrgID,
+		p.Context.OrgID,
+		p.Context.OrgID,
+		p.Context.UserID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select GetBlockUsage block %s", id), err)
+		return
+	}
+
+	return
+}
+
+// DeleteBlock removes reusable content block from database, with its previous versions.
 func (p *Persister) DeleteBlock(id string) (rows int64, err error) {
+	_, err = p.Context.Transaction.Exec("DELETE FROM blockrevision WHERE orgid=? AND blockid=?", p.Context.OrgID, id)
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute delete block revisions %s", id), err)
+		return
+	}
+
 	return p.Base.DeleteConstrained(p.Context.Transaction, "block", p.Context.OrgID, id)
 }
@@ -13,8 +13,13 @@ package request
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/secrets"
 	"github.com/documize/community/core/streamutil"
 )
 
@@ -75,9 +80,8 @@ func ConfigSet(area, json string) error {
 	if area == "" {
 		return errors.New("no area")
 	}
-	sql := "INSERT INTO `config` (`key`,`config`) " +
-		"VALUES ('" + area + "','" + json +
-		"') ON DUPLICATE KEY UPDATE `config`='" + json + "';"
+	// the JSON is bound rather than quoted, it may hold anything, such as passwords
+	sql := "INSERT INTO `config` (`key`,`config`) VALUES (?,?) ON DUPLICATE KEY UPDATE `config`=?;"
 
 	stmt, err := Db.Preparex(sql)
 	if err != nil {
@@ -86,49 +90,76 @@ func ConfigSet(area, json string) error {
 	}
 	defer streamutil.Close(stmt)
 
-	_, err = stmt.Exec()
+	_, err = stmt.Exec(area, json, json)
 	return err
 }
 
+// Keyring encrypts values in the userconfig table, which hold secrets such as section credentials.
+var Keyring *secrets.Keyring
+
 // UserConfigGetJSON fetches a configuration JSON element from the userconfig table for a given orgid/userid combination.
 // Errors return the empty string. A blank path returns the whole JSON object, as JSON.
 func UserConfigGetJSON(orgid, userid, area, path string) (ret string) {
 	if Db == nil {
 		return ""
 	}
-	if path != "" {
-		path = "." + path
-	}
-	sql := "SELECT JSON_EXTRACT(`config`,'$" + path + "') FROM `userconfig` WHERE `key` = '" + area +
-		"' AND `orgid` = '" + orgid + "' AND `userid` = '" + userid + "';"
+
+	sql := "SELECT `config` FROM `userconfig` WHERE `key`=? AND `orgid`=? AND `userid`=?;"
 
 	stmt, err := Db.Preparex(sql)
 	if err != nil {
-		//fmt.Printf("DEBUG: Unable to prepare select SQL for ConfigString: %s -- error: %v\n", sql, err)
 		return ""
 	}
 	defer streamutil.Close(stmt)
 
 	var item = make([]uint8, 0)
 
-	err = stmt.Get(&item)
+	err = stmt.Get(&item, area, orgid, userid)
+	if err != nil {
+		return ""
+	}
 
+	item, _, err = Keyring.Open(item)
 	if err != nil {
-		//fmt.Printf("DEBUG: Unable to prepare execute SQL for ConfigString: %s -- error: %v\n", sql, err)
+		log.Error("unable to read userconfig "+area, err)
 		return ""
 	}
 
-	if len(item) > 1 {
-		q := []byte(`"`)
-		ret = string(bytes.TrimPrefix(bytes.TrimSuffix(item, q), q))
+	return jsonPath(item, path)
+}
+
+// jsonPath returns the element at a path of dot separated names, strings without their quotes.
+func jsonPath(item []byte, path string) string {
+	if path == "" {
+		return string(bytes.TrimSpace(item))
 	}
 
-	//fmt.Println("DEBUG UserConfigString " + sql + " => " + ret)
-	return ret
+	var v interface{}
+	if json.Unmarshal(item, &v) != nil {
+		return ""
+	}
+
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[name]
+	}
 
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	}
+
+	b, _ := json.Marshal(v)
+	return string(b)
 }
 
-// UserConfigSetJSON writes a configuration JSON element to the userconfig table for the current user.
+// UserConfigSetJSON writes a configuration JSON element to the userconfig table for the current user,
+// encrypted when there is a master key.
 func UserConfigSetJSON(orgid, userid, area, json string) error {
 	if Db == nil {
 		return errors.New("no database")
@@ -136,17 +167,129 @@ func UserConfigSetJSON(orgid, userid, area, json string) error {
 	if area == "" {
 		return errors.New("no area")
 	}
-	sql := "INSERT INTO `userconfig` (`orgid`,`userid`,`key`,`config`) " +
-		"VALUES ('" + orgid + "','" + userid + "','" + area + "','" + json +
-		"') ON DUPLICATE KEY UPDATE `config`='" + json + "';"
+
+	sealed, err := Keyring.Seal([]byte(json))
+	if err != nil {
+		return err
+	}
+
+	sql := "INSERT INTO `userconfig` (`orgid`,`userid`,`key`,`config`) VALUES (?,?,?,?) ON DUPLICATE KEY UPDATE `config`=?;"
 
 	stmt, err := Db.Preparex(sql)
 	if err != nil {
-		//fmt.Printf("DEBUG: Unable to prepare select SQL for UserConfigSetJSON: %s -- error: %v\n", sql, err)
 		return err
 	}
 	defer streamutil.Close(stmt)
 
-	_, err = stmt.Exec()
+	_, err = stmt.Exec(orgid, userid, area, sealed, sealed)
 	return err
 }
+
+// SealUserConfig encrypts userconfig values and the secrets of shared connections and webhooks stored as plain text,
+// and when rotate is true, those encrypted with a previous master key. It returns how many values
+// were sealed and how many could not be read, which are left as they are.
+func SealUserConfig(rotate bool) (sealed, failed int, err error) {
+	if Db == nil {
+		return 0, 0, errors.New("no database")
+	}
+	if !Keyring.Encrypting() {
+		return 0, 0, errors.New("no master key to encrypt with")
+	}
+
+	rows := []struct {
+		OrgID  string `db:"orgid"`
+		UserID string `db:"userid"`
+		Key    string `db:"key"`
+		Config []byte `db:"config"`
+	}{}
+
+	err = Db.Select(&rows, "SELECT `orgid`, `userid`, `key`, `config` FROM `userconfig`")
+	if err != nil {
+		return
+	}
+
+	for _, row := range rows {
+		ok, err2 := sealValue(row.Config, rotate, func(v []byte) error {
+			_, err := Db.Exec("UPDATE `userconfig` SET `config`=? WHERE `orgid`=? AND `userid`=? AND `key`=?", v, row.OrgID, row.UserID, row.Key)
+			return err
+		})
+
+		if err2 != nil {
+			log.Error(fmt.Sprintf("unable to seal userconfig %s for user %s", row.Key, row.UserID), err2)
+			failed++
+		} else if ok {
+			sealed++
+		}
+	}
+
+	connections := []struct {
+		OrgID  string `db:"orgid"`
+		RefID  string `db:"refid"`
+		Config []byte `db:"config"`
+	}{}
+
+	err = Db.Select(&connections, "SELECT `orgid`, `refid`, `config` FROM `connection`")
+	if err != nil {
+		return
+	}
+
+	for _, row := range connections {
+		ok, err2 := sealValue(row.Config, rotate, func(v []byte) error {
+			_, err := Db.Exec("UPDATE `connection` SET `config`=? WHERE `orgid`=? AND `refid`=?", v, row.OrgID, row.RefID)
+			return err
+		})
+
+		if err2 != nil {
+			log.Error(fmt.Sprintf("unable to seal connection %s", row.RefID), err2)
+			failed++
+		} else if ok {
+			sealed++
+		}
+	}
+
+	hooks := []struct {
+		RefID  string `db:"refid"`
+		Secret []byte `db:"secret"`
+	}{}
+
+	err = Db.Select(&hooks, "SELECT `refid`, `secret` FROM `webhook`")
+	if err != nil {
+		return
+	}
+
+	for _, row := range hooks {
+		ok, err2 := sealValue(row.Secret, rotate, func(v []byte) error {
+			_, err := Db.Exec("UPDATE `webhook` SET `secret`=? WHERE `refid`=?", v, row.RefID)
+			return err
+		})
+
+		if err2 != nil {
+			log.Error(fmt.Sprintf("unable to seal webhook %s", row.RefID), err2)
+			failed++
+		} else if ok {
+			sealed++
+		}
+	}
+
+	return
+}
+
+// sealValue stores the value sealed with the current key when it is plain text,
+// or when rotating and it was sealed with a previous key. It reports whether it did.
+func sealValue(stored []byte, rotate bool, store func([]byte) error) (bool, error) {
+	plain, current, err := Keyring.Open(stored)
+	if err != nil {
+		return false, err
+	}
+
+	if current || (!rotate && !bytes.Equal(plain, stored)) {
+		return false, nil // sealed with the current key, or with a previous one when not rotating
+	}
+
+	v, err := Keyring.Seal(plain)
+	if err != nil {
+		return false, err
+	}
+
+	return true, store(v)
+}
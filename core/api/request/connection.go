@@ -0,0 +1,215 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package request
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/streamutil"
+)
+
+// connectionLogLimit caps how many uses of a connection are returned.
+const connectionLogLimit = 500
+
+// AddConnection saves a new shared connection, with its secrets encrypted when there is a master key.
+func (p *Persister) AddConnection(c entity.Connection) (err error) {
+	c.Created = time.Now().UTC()
+	c.Revised = time.Now().UTC()
+
+	config, err := Keyring.Seal([]byte(c.Secrets))
+	if err != nil {
+		log.Error("Unable to seal secrets for connection", err)
+		return
+	}
+
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO connection (refid, orgid, userid, contenttype, name, config, lastused, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error("Unable to prepare insert for connection", err)
+		return
+	}
+
+	_, err = stmt.Exec(c.RefID, c.OrgID, c.UserID, c.ContentType, c.Name, config, c.Created, c.Created, c.Revised)
+
+	if err != nil {
+		log.Error("Unable to execute insert for connection", err)
+		return
+	}
+
+	return
+}
+
+// GetConnection returns the connection, without its secrets.
+func (p *Persister) GetConnection(id string) (c entity.Connection, err error) {
+	stmt, err := Db.Preparex("SELECT id, refid, orgid, userid, contenttype, name, uses, lastused, created, revised FROM connection WHERE orgid=? AND refid=?")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to prepare select for connection %s", id), err)
+		return
+	}
+
+	err = stmt.Get(&c, p.Context.OrgID, id)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select for connection %s", id), err)
+		return
+	}
+
+	return
+}
+
+// GetConnections returns the connections of the organization, without their secrets,
+// for one section type or for all of them when contentType is empty.
+func (p *Persister) GetConnections(contentType string) (connections []entity.Connection, err error) {
+	err = Db.Select(&connections, "SELECT id, refid, orgid, userid, contenttype, name, uses, lastused, created, revised FROM connection WHERE orgid=? AND (?='' OR contenttype=?) ORDER BY contenttype, name", p.Context.OrgID, contentType, contentType)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select connections for org %s", p.Context.OrgID), err)
+		return
+	}
+
+	return
+}
+
+// UpdateConnection renames the connection, and replaces its secrets when new ones are given.
+func (p *Persister) UpdateConnection(c entity.Connection) (err error) {
+	c.Revised = time.Now().UTC()
+
+	if len(c.Secrets) == 0 {
+		stmt, err2 := p.Context.Transaction.Preparex("UPDATE connection SET name=?, revised=? WHERE orgid=? AND refid=?")
+		defer streamutil.Close(stmt)
+
+		if err2 != nil {
+			log.Error(fmt.Sprintf("Unable to prepare update for connection %s", c.RefID), err2)
+			return err2
+		}
+
+		_, err = stmt.Exec(c.Name, c.Revised, p.Context.OrgID, c.RefID)
+	} else {
+		config, err2 := Keyring.Seal([]byte(c.Secrets))
+		if err2 != nil {
+			log.Error(fmt.Sprintf("Unable to seal secrets for connection %s", c.RefID), err2)
+			return err2
+		}
+
+		stmt, err2 := p.Context.Transaction.Preparex("UPDATE connection SET name=?, config=?, userid=?, revised=? WHERE orgid=? AND refid=?")
+		defer streamutil.Close(stmt)
+
+		if err2 != nil {
+			log.Error(fmt.Sprintf("Unable to prepare update for connection %s", c.RefID), err2)
+			return err2
+		}
+
+		_, err = stmt.Exec(c.Name, config, c.UserID, c.Revised, p.Context.OrgID, c.RefID)
+	}
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute update for connection %s", c.RefID), err)
+		return
+	}
+
+	return
+}
+
+// DeleteConnection removes the connection, sections that used it go back to the secrets of their owner.
+func (p *Persister) DeleteConnection(id string) (rows int64, err error) {
+	_, err = p.Context.Transaction.Exec("UPDATE pagemeta SET connectionid='' WHERE orgid=? AND connectionid=?", p.Context.OrgID, id)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute update of pagemeta for connection %s", id), err)
+		return
+	}
+
+	return p.Base.DeleteConstrained(p.Context.Transaction, "connection", p.Context.OrgID, id)
+}
+
+// LogConnection records that the context user used the connection, for the page when known.
+func (p *Persister) LogConnection(id, pageID, action string) {
+	now := time.Now().UTC()
+
+	_, err := Db.Exec("INSERT INTO connectionlog (orgid, connectionid, userid, pageid, action, created) VALUES (?, ?, ?, ?, ?, ?)", p.Context.OrgID, id, p.Context.UserID, pageID, action, now)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute insert for connection log %s", id), err)
+		return
+	}
+
+	_, err = Db.Exec("UPDATE connection SET uses=uses+1, lastused=? WHERE orgid=? AND refid=?", now, p.Context.OrgID, id)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute update for connection use %s", id), err)
+	}
+}
+
+// GetConnectionLog returns the most recent uses of the connection.
+func (p *Persister) GetConnectionLog(id string) (uses []entity.ConnectionLog, err error) {
+	err = Db.Select(&uses, `SELECT l.id, l.orgid, l.connectionid, l.userid, coalesce(u.firstname,'') as firstname, coalesce(u.lastname,'') as lastname, l.pageid, l.action, l.created
+		FROM connectionlog l LEFT JOIN user u ON u.refid=l.userid
+		WHERE l.orgid=? AND l.connectionid=? ORDER BY l.created DESC, l.id DESC LIMIT ?`, p.Context.OrgID, id, connectionLogLimit)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select for connection log %s", id), err)
+		return
+	}
+
+	return
+}
+
+// ConnectionGetJSON fetches the secrets of a shared connection, as UserConfigGetJSON does for those of a user.
+// The connection must belong to the organization and be for the section type given as area.
+// Errors return the empty string. A blank path returns the whole JSON object, as JSON.
+func ConnectionGetJSON(orgid, connectionid, area, path string) string {
+	if Db == nil {
+		return ""
+	}
+
+	var item = make([]uint8, 0)
+
+	err := Db.Get(&item, "SELECT `config` FROM `connection` WHERE `orgid`=? AND `refid`=? AND `contenttype`=?", orgid, connectionid, area)
+	if err != nil {
+		return ""
+	}
+
+	item, _, err = Keyring.Open(item)
+	if err != nil {
+		log.Error("unable to read connection "+connectionid, err)
+		return ""
+	}
+
+	return jsonPath(item, path)
+}
+
+// ErrConnectionNotFound is returned when a connection does not belong to the organization or section type.
+var ErrConnectionNotFound = errors.New("connection not found")
+
+// CheckConnection returns ErrConnectionNotFound unless the connection belongs to the organization
+// and is for the section type.
+func CheckConnection(orgid, connectionid, contentType string) error {
+	var n int
+
+	err := Db.Get(&n, "SELECT COUNT(*) FROM connection WHERE orgid=? AND refid=? AND contenttype=?", orgid, connectionid, contentType)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrConnectionNotFound
+	}
+
+	return nil
+}
@@ -29,7 +29,7 @@ func (p *Persister) AddDocument(document entity.Document) (err error) {
 	document.Created = time.Now().UTC()
 	document.Revised = document.Created // put same time in both fields
 
-	stmt, err := p.Context.Transaction.Preparex("INSERT INTO document (refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO document (refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -37,7 +37,7 @@ func (p *Persister) AddDocument(document entity.Document) (err error) {
 		return
 	}
 
-	_, err = stmt.Exec(document.RefID, document.OrgID, document.LabelID, document.UserID, document.Job, document.Location, document.Title, document.Excerpt, document.Slug, document.Tags, document.Template, document.Created, document.Revised)
+	_, err = stmt.Exec(document.RefID, document.OrgID, document.LabelID, document.UserID, document.Job, document.Location, document.Title, document.Excerpt, document.Slug, document.Tags, document.Template, document.TemplateID, document.TemplateVersion, document.Created, document.Revised)
 
 	if err != nil {
 		log.Error("Unable to execute insert for document", err)
@@ -49,7 +49,7 @@ func (p *Persister) AddDocument(document entity.Document) (err error) {
 
 // GetDocument fetches the document record with the given id fromt the document table and audits that it has been got.
 func (p *Persister) GetDocument(id string) (document entity.Document, err error) {
-	stmt, err := Db.Preparex("SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, layout, created, revised FROM document WHERE orgid=? and refid=?")
+	stmt, err := Db.Preparex("SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, layout, created, revised FROM document WHERE orgid=? and refid=?")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -108,7 +108,7 @@ func (p *Persister) GetDocumentMeta(id string) (meta entity.DocumentMeta, err er
 
 // GetDocuments returns a slice containg all of the the documents for the client's organisation, with the most recient first.
 func (p *Persister) GetDocuments() (documents []entity.Document, err error) {
-	err = Db.Select(&documents, "SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, layout, created, revised FROM document WHERE orgid=? AND template=0 ORDER BY revised DESC", p.Context.OrgID)
+	err = Db.Select(&documents, "SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, layout, created, revised FROM document WHERE orgid=? AND template=0 ORDER BY revised DESC", p.Context.OrgID)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute select documents for org %s", p.Context.OrgID), err)
@@ -120,7 +120,7 @@ func (p *Persister) GetDocuments() (documents []entity.Document, err error) {
 
 // GetDocumentsByFolder returns a slice containing the documents for a given folder, most recient first.
 func (p *Persister) GetDocumentsByFolder(folderID string) (documents []entity.Document, err error) {
-	err = Db.Select(&documents, "SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, layout, created, revised FROM document WHERE orgid=? AND template=0 AND labelid=? ORDER BY revised DESC", p.Context.OrgID, folderID)
+	err = Db.Select(&documents, "SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, layout, created, revised FROM document WHERE orgid=? AND template=0 AND labelid=? ORDER BY revised DESC", p.Context.OrgID, folderID)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute select documents for org %s", p.Context.OrgID), err)
@@ -136,7 +136,7 @@ func (p *Persister) GetDocumentsByTag(tag string) (documents []entity.Document,
 	tagQuery := "tags LIKE '%#" + tag + "#%'"
 
 	err = Db.Select(&documents,
-		`SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, layout, created, revised FROM document WHERE orgid=? AND template=0 AND `+tagQuery+` AND labelid IN
+		`SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, layout, created, revised FROM document WHERE orgid=? AND template=0 AND `+tagQuery+` AND labelid IN
 		(SELECT refid from label WHERE orgid=? AND type=2 AND userid=?
     	UNION ALL SELECT refid FROM label a where orgid=? AND type=1 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid='' AND (canedit=1 OR canview=1))
 		UNION ALL SELECT refid FROM label a where orgid=? AND type=3 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid=? AND (canedit=1 OR canview=1)))
@@ -161,7 +161,7 @@ func (p *Persister) GetDocumentsByTag(tag string) (documents []entity.Document,
 // GetDocumentTemplates returns a slice containing the documents available as templates to the client's organisation, in title order.
 func (p *Persister) GetDocumentTemplates() (documents []entity.Document, err error) {
 	err = Db.Select(&documents,
-		`SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, layout, created, revised FROM document WHERE orgid=? AND template=1 AND labelid IN
+		`SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, layout, created, revised FROM document WHERE orgid=? AND template=1 AND labelid IN
 		(SELECT refid from label WHERE orgid=? AND type=2 AND userid=?
     	UNION ALL SELECT refid FROM label a where orgid=? AND type=1 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid='' AND (canedit=1 OR canview=1))
 		UNION ALL SELECT refid FROM label a where orgid=? AND type=3 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid=? AND (canedit=1 OR canview=1)))
@@ -203,7 +203,7 @@ AND d.template=0`, orgID)
 // GetDocumentList returns a slice containing the documents available as templates to the client's organisation, in title order.
 func (p *Persister) GetDocumentList() (documents []entity.Document, err error) {
 	err = Db.Select(&documents,
-		`SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, layout, created, revised FROM document WHERE orgid=? AND template=0 AND labelid IN
+		`SELECT id, refid, orgid, labelid, userid, job, location, title, excerpt, slug, tags, template, templateid, templateversion, layout, created, revised FROM document WHERE orgid=? AND template=0 AND labelid IN
 		(SELECT refid from label WHERE orgid=? AND type=2 AND userid=?
     	UNION ALL SELECT refid FROM label a where orgid=? AND type=1 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid='' AND (canedit=1 OR canview=1))
 		UNION ALL SELECT refid FROM label a where orgid=? AND type=3 AND refid IN (SELECT labelid from labelrole WHERE orgid=? AND userid=? AND (canedit=1 OR canview=1)))
@@ -334,6 +334,18 @@ func (p *Persister) UpdateDocument(document entity.Document) (err error) {
 	return
 }
 
+// SetDocumentTemplate records the template and version a document was started from.
+func (p *Persister) SetDocumentTemplate(documentID, templateID, version string) (err error) {
+	_, err = p.Context.Transaction.Exec("UPDATE document SET templateid=?, templateversion=? WHERE orgid=? AND refid=?", templateID, version, p.Context.OrgID, documentID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute update template for document %s", documentID), err)
+		return
+	}
+
+	return
+}
+
 // ChangeDocumentLabel assigns the specified folder to the document.
 func (p *Persister) ChangeDocumentLabel(document, label string) (err error) {
 	revised := time.Now().UTC()
@@ -412,6 +424,12 @@ func (p *Persister) DeleteDocument(documentID string) (rows int64, err error) {
 		return
 	}
 
+	_, err = p.Context.Transaction.Exec("DELETE FROM templatevariable WHERE orgid=? AND documentid=?", p.Context.OrgID, documentID)
+
+	if err != nil {
+		return
+	}
+
 	// Mark references to this document as orphaned
 	err = p.MarkOrphanDocumentLink(documentID)
 	if err != nil {
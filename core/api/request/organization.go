@@ -60,7 +60,7 @@ func (p *Persister) AddOrganization(org entity.Organization) error {
 
 // GetOrganization returns the Organization reocrod from the organization database table with the given id.
 func (p *Persister) GetOrganization(id string) (org entity.Organization, err error) {
-	stmt, err := Db.Preparex("SELECT id, refid, company, title, message, url, domain, service as conversionendpoint, email, serial, active, allowanonymousaccess, authprovider, coalesce(authconfig,JSON_UNQUOTE('{}')) as authconfig, created, revised FROM organization WHERE refid=?")
+	stmt, err := Db.Preparex("SELECT id, refid, company, title, message, url, domain, service as conversionendpoint, templatefolderid, email, serial, active, allowanonymousaccess, authprovider, coalesce(authconfig,JSON_UNQUOTE('{}')) as authconfig, created, revised FROM organization WHERE refid=?")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -87,7 +87,7 @@ func (p *Persister) GetOrganizationByDomain(subdomain string) (org entity.Organi
 
 		var stmt *sqlx.Stmt
 
-		stmt, err = Db.Preparex("SELECT id, refid, company, title, message, url, domain, service as conversionendpoint, email, serial, active, allowanonymousaccess, authprovider, coalesce(authconfig,JSON_UNQUOTE('{}')) as authconfig, created, revised FROM organization WHERE domain=? AND active=1")
+		stmt, err = Db.Preparex("SELECT id, refid, company, title, message, url, domain, service as conversionendpoint, templatefolderid, email, serial, active, allowanonymousaccess, authprovider, coalesce(authconfig,JSON_UNQUOTE('{}')) as authconfig, created, revised FROM organization WHERE domain=? AND active=1")
 		defer streamutil.Close(stmt)
 
 		if err != nil {
@@ -191,6 +191,18 @@ func (p *Persister) UpdateAuthConfig(org entity.Organization) (err error) {
 	return
 }
 
+// UpdateTemplateFolder designates the space whose documents the organization offers as templates, none when empty.
+func (p *Persister) UpdateTemplateFolder(folderID string) (err error) {
+	_, err = p.Context.Transaction.Exec("UPDATE organization SET templatefolderid=?, revised=? WHERE refid=?", folderID, time.Now().UTC(), p.Context.OrgID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute update template folder for org %s", p.Context.OrgID), err)
+		return
+	}
+
+	return
+}
+
 // CheckDomain makes sure there is an organisation with the correct domain
 func CheckDomain(domain string) string {
 	row := Db.QueryRow("SELECT COUNT(*) FROM organization WHERE domain=? AND active=1", domain)
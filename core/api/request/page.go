@@ -50,7 +50,7 @@ func (p *Persister) AddPage(model models.PageModel) (err error) {
 		model.Page.Sequence = maxSeq * 2
 	}
 
-	stmt, err := p.Context.Transaction.Preparex("INSERT INTO page (refid, orgid, documentid, userid, contenttype, pagetype, level, title, body, revisions, sequence, blockid, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO page (refid, orgid, documentid, userid, contenttype, pagetype, level, title, body, revisions, sequence, blockid, blocklinked, blockversion, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -58,7 +58,7 @@ func (p *Persister) AddPage(model models.PageModel) (err error) {
 		return
 	}
 
-	_, err = stmt.Exec(model.Page.RefID, model.Page.OrgID, model.Page.DocumentID, model.Page.UserID, model.Page.ContentType, model.Page.PageType, model.Page.Level, model.Page.Title, model.Page.Body, model.Page.Revisions, model.Page.Sequence, model.Page.BlockID, model.Page.Created, model.Page.Revised)
+	_, err = stmt.Exec(model.Page.RefID, model.Page.OrgID, model.Page.DocumentID, model.Page.UserID, model.Page.ContentType, model.Page.PageType, model.Page.Level, model.Page.Title, model.Page.Body, model.Page.Revisions, model.Page.Sequence, model.Page.BlockID, model.Page.BlockLinked, model.Page.BlockVersion, model.Page.Created, model.Page.Revised)
 
 	if err != nil {
 		log.Error("Unable to execute insert for page", err)
@@ -67,7 +67,7 @@ func (p *Persister) AddPage(model models.PageModel) (err error) {
 
 	_ = searches.Add(&databaseRequest{OrgID: p.Context.OrgID}, model.Page, model.Page.RefID)
 
-	stmt2, err := p.Context.Transaction.Preparex("INSERT INTO pagemeta (pageid, orgid, userid, documentid, rawbody, config, externalsource, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	stmt2, err := p.Context.Transaction.Preparex("INSERT INTO pagemeta (pageid, orgid, userid, documentid, rawbody, config, externalsource, connectionid, refreshinterval, refreshed, refreshattempted, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	defer streamutil.Close(stmt2)
 
 	if err != nil {
@@ -75,7 +75,7 @@ func (p *Persister) AddPage(model models.PageModel) (err error) {
 		return
 	}
 
-	_, err = stmt2.Exec(model.Meta.PageID, model.Meta.OrgID, model.Meta.UserID, model.Meta.DocumentID, model.Meta.RawBody, model.Meta.Config, model.Meta.ExternalSource, model.Meta.Created, model.Meta.Revised)
+	_, err = stmt2.Exec(model.Meta.PageID, model.Meta.OrgID, model.Meta.UserID, model.Meta.DocumentID, model.Meta.RawBody, model.Meta.Config, model.Meta.ExternalSource, model.Meta.ConnectionID, model.Meta.RefreshInterval, model.Meta.Created, model.Meta.Created, model.Meta.Created, model.Meta.Revised)
 
 	if err != nil {
 		log.Error("Unable to execute insert for page meta", err)
@@ -87,7 +87,7 @@ func (p *Persister) AddPage(model models.PageModel) (err error) {
 
 // GetPage returns the pageID page record from the page table.
 func (p *Persister) GetPage(pageID string) (page entity.Page, err error) {
-	stmt, err := Db.Preparex("SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.revisions, a.blockid, a.created, a.revised FROM page a WHERE a.orgid=? AND a.refid=?")
+	stmt, err := Db.Preparex("SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.revisions, a.blockid, a.blocklinked, a.blockversion, a.created, a.revised FROM page a WHERE a.orgid=? AND a.refid=?")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -107,7 +107,7 @@ func (p *Persister) GetPage(pageID string) (page entity.Page, err error) {
 
 // GetPages returns a slice containing all the page records for a given documentID, in presentation sequence.
 func (p *Persister) GetPages(documentID string) (pages []entity.Page, err error) {
-	err = Db.Select(&pages, "SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.revisions, a.blockid, a.created, a.revised FROM page a WHERE a.orgid=? AND a.documentid=? ORDER BY a.sequence", p.Context.OrgID, documentID)
+	err = Db.Select(&pages, "SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.revisions, a.blockid, a.blocklinked, a.blockversion, a.created, a.revised FROM page a WHERE a.orgid=? AND a.documentid=? ORDER BY a.sequence", p.Context.OrgID, documentID)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute select pages for org %s and document %s", p.Context.OrgID, documentID), err)
@@ -122,7 +122,7 @@ func (p *Persister) GetPages(documentID string) (pages []entity.Page, err error)
 func (p *Persister) GetPagesWhereIn(documentID, inPages string) (pages []entity.Page, err error) {
 	args := []interface{}{p.Context.OrgID, documentID}
 	tempValues := strings.Split(inPages, ",")
-	sql := "SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.blockid, a.revisions, a.created, a.revised FROM page a WHERE a.orgid=? AND a.documentid=? AND a.refid IN (?" + strings.Repeat(",?", len(tempValues)-1) + ") ORDER BY sequence"
+	sql := "SELECT a.id, a.refid, a.orgid, a.documentid, a.userid, a.contenttype, a.pagetype, a.level, a.sequence, a.title, a.body, a.blockid, a.blocklinked, a.blockversion, a.revisions, a.created, a.revised FROM page a WHERE a.orgid=? AND a.documentid=? AND a.refid IN (?" + strings.Repeat(",?", len(tempValues)-1) + ") ORDER BY sequence"
 
 	inValues := make([]interface{}, len(tempValues))
 
@@ -172,7 +172,7 @@ func (p *Persister) GetPagesWhereIn(documentID, inPages string) (pages []entity.
 // GetPagesWithoutContent returns a slice containing all the page records for a given documentID, in presentation sequence,
 // but without the body field (which holds the HTML content).
 func (p *Persister) GetPagesWithoutContent(documentID string) (pages []entity.Page, err error) {
-	err = Db.Select(&pages, "SELECT id, refid, orgid, documentid, userid, contenttype, pagetype, sequence, level, title, revisions, blockid, created, revised FROM page WHERE orgid=? AND documentid=? ORDER BY sequence", p.Context.OrgID, documentID)
+	err = Db.Select(&pages, "SELECT id, refid, orgid, documentid, userid, contenttype, pagetype, sequence, level, title, revisions, blockid, blocklinked, blockversion, created, revised FROM page WHERE orgid=? AND documentid=? ORDER BY sequence", p.Context.OrgID, documentID)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute select pages for org %s and document %s", p.Context.OrgID, documentID), err)
@@ -299,7 +299,7 @@ func (p *Persister) UpdatePageMeta(meta entity.PageMeta, updateUserID bool) (err
 	}
 
 	var stmt *sqlx.NamedStmt
-	stmt, err = p.Context.Transaction.PrepareNamed("UPDATE pagemeta SET userid=:userid, documentid=:documentid, rawbody=:rawbody, config=:config, externalsource=:externalsource, revised=:revised WHERE orgid=:orgid AND pageid=:pageid")
+	stmt, err = p.Context.Transaction.PrepareNamed("UPDATE pagemeta SET userid=:userid, documentid=:documentid, rawbody=:rawbody, config=:config, externalsource=:externalsource, connectionid=:connectionid, refreshinterval=:refreshinterval, revised=:revised WHERE orgid=:orgid AND pageid=:pageid")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -317,6 +317,28 @@ func (p *Persister) UpdatePageMeta(meta entity.PageMeta, updateUserID bool) (err
 	return
 }
 
+// UpdatePageMetaRefresh records the outcome of refreshing an external data section,
+// storing the latest data when the refresh worked.
+func (p *Persister) UpdatePageMetaRefresh(meta entity.PageMeta) (err error) {
+	var stmt *sqlx.NamedStmt
+	stmt, err = p.Context.Transaction.PrepareNamed("UPDATE pagemeta SET rawbody=:rawbody, refreshed=:refreshed, refreshattempted=:refreshattempted, refresherror=:refresherror WHERE orgid=:orgid AND pageid=:pageid")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to prepare refresh update for page meta %s", meta.PageID), err)
+		return
+	}
+
+	_, err = stmt.Exec(&meta)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute refresh update for page meta %s", meta.PageID), err)
+		return
+	}
+
+	return
+}
+
 // UpdatePageSequence changes the presentation sequence of the pageID page in the document.
 // It then propagates that change into the search table and audits that it has occurred.
 func (p *Persister) UpdatePageSequence(documentID, pageID string, sequence float64) (err error) {
@@ -387,7 +409,7 @@ func (p *Persister) DeletePage(documentID, pageID string) (rows int64, err error
 
 // GetPageMeta returns the meta information associated with the page.
 func (p *Persister) GetPageMeta(pageID string) (meta entity.PageMeta, err error) {
-	stmt, err := Db.Preparex("SELECT id, pageid, orgid, userid, documentid, rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, externalsource, created, revised FROM pagemeta WHERE orgid=? AND pageid=?")
+	stmt, err := Db.Preparex("SELECT id, pageid, orgid, userid, documentid, rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, externalsource, connectionid, refreshinterval, refreshed, refreshattempted, refresherror, created, revised FROM pagemeta WHERE orgid=? AND pageid=?")
 	defer streamutil.Close(stmt)
 
 	if err != nil {
@@ -412,7 +434,7 @@ func (p *Persister) GetDocumentPageMeta(documentID string, externalSourceOnly bo
 		filter = " AND externalsource=1"
 	}
 
-	err = Db.Select(&meta, "SELECT id, pageid, orgid, userid, documentid, rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, externalsource, created, revised FROM pagemeta WHERE orgid=? AND documentid=?"+filter, p.Context.OrgID, documentID)
+	err = Db.Select(&meta, "SELECT id, pageid, orgid, userid, documentid, rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, externalsource, connectionid, refreshinterval, refreshed, refreshattempted, refresherror, created, revised FROM pagemeta WHERE orgid=? AND documentid=?"+filter, p.Context.OrgID, documentID)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("Unable to execute select document page meta for org %s and document %s", p.Context.OrgID, documentID), err)
@@ -422,6 +444,21 @@ func (p *Persister) GetDocumentPageMeta(documentID string, externalSourceOnly bo
 	return
 }
 
+// GetPageMetaToRefresh returns external data sections, across all organizations, that have not been
+// refreshed within their interval, least recently tried first.
+// Intervals are in minutes, sections without one use defaultInterval and none are shorter than minInterval.
+func (p *Persister) GetPageMetaToRefresh(now time.Time, defaultInterval, minInterval, limit int) (meta []entity.PageMeta, err error) {
+	err = Db.Select(&meta, "SELECT id, pageid, orgid, userid, documentid, rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, externalsource, connectionid, refreshinterval, refreshed, refreshattempted, refresherror, created, revised FROM pagemeta WHERE externalsource=1 AND TIMESTAMPADD(MINUTE, GREATEST(IF(refreshinterval>0, refreshinterval, ?), ?), refreshattempted) <= ? ORDER BY refreshattempted LIMIT ?",
+		defaultInterval, minInterval, now, limit)
+
+	if err != nil {
+		log.Error("Unable to execute select page meta to refresh", err)
+		return
+	}
+
+	return
+}
+
 /********************
 * Page Revisions
 ********************/
@@ -463,6 +500,45 @@ func (p *Persister) GetDocumentRevisions(documentID string) (revisions []entity.
 	return
 }
 
+// GetDocumentRevisionsWithContent returns a slice of complete page revision records for a given document, in the order they were created.
+func (p *Persister) GetDocumentRevisionsWithContent(documentID string) (revisions []entity.Revision, err error) {
+	err = Db.Select(&revisions, "SELECT id, refid, orgid, documentid, ownerid, pageid, userid, contenttype, pagetype, title, body, coalesce(rawbody, '') as rawbody, coalesce(config,JSON_UNQUOTE('{}')) as config, created, revised FROM revision WHERE orgid=? AND documentid=? ORDER BY id", p.Context.OrgID, documentID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select revisions with content for org %s and document %s", p.Context.OrgID, documentID), err)
+		return
+	}
+
+	if len(revisions) == 0 {
+		revisions = []entity.Revision{}
+	}
+
+	return
+}
+
+// AddRevision inserts the given page revision record, keeping its original timestamps.
+// Used when recreating the revision history of an imported document.
+func (p *Persister) AddRevision(r entity.Revision) (err error) {
+	r.OrgID = p.Context.OrgID
+
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO revision (refid, orgid, documentid, ownerid, pageid, userid, contenttype, pagetype, title, body, rawbody, config, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error("Unable to prepare insert for revision", err)
+		return
+	}
+
+	_, err = stmt.Exec(r.RefID, r.OrgID, r.DocumentID, r.OwnerID, r.PageID, r.UserID, r.ContentType, r.PageType, r.Title, r.Body, r.RawBody, r.Config, r.Created, r.Revised)
+
+	if err != nil {
+		log.Error("Unable to execute insert for revision", err)
+		return
+	}
+
+	return
+}
+
 // GetPageRevisions returns a slice of page revision records for a given pageID, in the order they were created.
 // Then audits that the get-page-revisions action has occurred.
 func (p *Persister) GetPageRevisions(pageID string) (revisions []entity.Revision, err error) {
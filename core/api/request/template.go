@@ -0,0 +1,103 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package request
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/streamutil"
+)
+
+// SetTemplateVariables replaces the variables of a template, which keep the order given.
+func (p *Persister) SetTemplateVariables(documentID string, vars []entity.TemplateVariable) (err error) {
+	_, err = p.Context.Transaction.Exec("DELETE FROM templatevariable WHERE orgid=? AND documentid=?", p.Context.OrgID, documentID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute delete template variables for %s", documentID), err)
+		return
+	}
+
+	if len(vars) == 0 {
+		return
+	}
+
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO templatevariable (refid, orgid, documentid, name, label, type, defaultvalue, sequence, created, revised) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error("Unable to prepare insert for template variable", err)
+		return
+	}
+
+	now := time.Now().UTC()
+
+	for i, v := range vars {
+		_, err = stmt.Exec(v.RefID, p.Context.OrgID, documentID, v.Name, v.Label, v.Type, v.DefaultValue, i, now, now)
+
+		if err != nil {
+			log.Error(fmt.Sprintf("Unable to execute insert for template variable %s", v.Name), err)
+			return
+		}
+	}
+
+	return
+}
+
+// GetTemplateVariables returns the variables of a template in order.
+func (p *Persister) GetTemplateVariables(documentID string) (vars []entity.TemplateVariable, err error) {
+	err = Db.Select(&vars, "SELECT id, refid, orgid, documentid, name, label, type, defaultvalue, sequence, created, revised FROM templatevariable WHERE orgid=? AND documentid=? ORDER BY sequence", p.Context.OrgID, documentID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select template variables for %s", documentID), err)
+		return
+	}
+
+	return
+}
+
+// GetOrgTemplateVariables returns the variables of every template of the organization, in order for each template.
+func (p *Persister) GetOrgTemplateVariables() (vars []entity.TemplateVariable, err error) {
+	err = Db.Select(&vars, "SELECT id, refid, orgid, documentid, name, label, type, defaultvalue, sequence, created, revised FROM templatevariable WHERE orgid=? ORDER BY documentid, sequence", p.Context.OrgID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select template variables for org %s", p.Context.OrgID), err)
+		return
+	}
+
+	return
+}
+
+// GetTemplateChanges returns when each saved template, and each document in the template space, last changed,
+// which is when its latest page changed when that is later than the document itself.
+func (p *Persister) GetTemplateChanges(folderID string) (changes map[string]time.Time, err error) {
+	rows := []struct {
+		DocumentID string    `db:"documentid"`
+		Revised    time.Time `db:"revised"`
+	}{}
+
+	err = Db.Select(&rows, "SELECT d.refid AS documentid, GREATEST(d.revised, COALESCE(MAX(p.revised), d.revised)) AS revised FROM document d LEFT JOIN page p ON p.orgid=d.orgid AND p.documentid=d.refid WHERE d.orgid=? AND (d.template=1 OR d.labelid=?) GROUP BY d.refid, d.revised", p.Context.OrgID, folderID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select template changes for org %s", p.Context.OrgID), err)
+		return
+	}
+
+	changes = make(map[string]time.Time)
+	for _, r := range rows {
+		changes[r.DocumentID] = r.Revised
+	}
+
+	return
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/streamutil"
+)
+
+// SetWebhook saves the webhook of a section type, replacing any the organization had for it,
+// with its secret encrypted when there is a master key.
+func (p *Persister) SetWebhook(hook entity.Webhook) (err error) {
+	hook.Created = time.Now().UTC()
+	hook.Revised = time.Now().UTC()
+
+	secret, err := sealString(hook.Secret)
+	if err != nil {
+		log.Error("Unable to seal secret for webhook", err)
+		return
+	}
+
+	_, err = p.Context.Transaction.Exec("DELETE FROM webhook WHERE orgid=? AND contenttype=?", p.Context.OrgID, hook.ContentType)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute delete for webhook %s", hook.ContentType), err)
+		return
+	}
+
+	stmt, err := p.Context.Transaction.Preparex("INSERT INTO webhook (refid, orgid, contenttype, secret, created, revised) VALUES (?, ?, ?, ?, ?, ?)")
+	defer streamutil.Close(stmt)
+
+	if err != nil {
+		log.Error("Unable to prepare insert for webhook", err)
+		return
+	}
+
+	_, err = stmt.Exec(hook.RefID, p.Context.OrgID, hook.ContentType, secret, hook.Created, hook.Revised)
+
+	if err != nil {
+		log.Error("Unable to execute insert for webhook", err)
+		return
+	}
+
+	return
+}
+
+// GetWebhooks returns the webhooks of the organization, without their secrets.
+func (p *Persister) GetWebhooks() (hooks []entity.Webhook, err error) {
+	err = Db.Select(&hooks, "SELECT id, refid, orgid, contenttype, created, revised FROM webhook WHERE orgid=? ORDER BY contenttype", p.Context.OrgID)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select webhooks for org %s", p.Context.OrgID), err)
+		return
+	}
+
+	return
+}
+
+// DeleteWebhook removes the webhook of a section type.
+func (p *Persister) DeleteWebhook(contentType string) (rows int64, err error) {
+	result, err := p.Context.Transaction.Exec("DELETE FROM webhook WHERE orgid=? AND contenttype=?", p.Context.OrgID, contentType)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute delete for webhook %s", contentType), err)
+		return
+	}
+
+	return result.RowsAffected()
+}
+
+// GetWebhook returns the webhook an event was posted to, with its secret, whatever the organization.
+func GetWebhook(id string) (hook entity.Webhook, err error) {
+	row := struct {
+		entity.Webhook
+		Sealed []byte `db:"sealed"`
+	}{}
+
+	err = Db.Get(&row, "SELECT id, refid, orgid, contenttype, secret as sealed, created, revised FROM webhook WHERE refid=?", id)
+
+	if err != nil {
+		return
+	}
+
+	hook = row.Webhook
+	hook.Secret, err = openString(row.Sealed)
+
+	if err != nil {
+		log.Error("unable to read secret for webhook "+id, err)
+	}
+
+	return
+}
+
+// GetExternalPageMeta returns the sections of a type that source their data externally.
+func (p *Persister) GetExternalPageMeta(contentType string) (meta []entity.PageMeta, err error) {
+	err = Db.Select(&meta, "SELECT b.id, b.pageid, b.orgid, b.userid, b.documentid, b.rawbody, coalesce(b.config,JSON_UNQUOTE('{}')) as config, b.externalsource, b.connectionid, b.refreshinterval, b.refreshed, b.refreshattempted, b.refresherror, b.created, b.revised FROM page a, pagemeta b WHERE a.orgid=? AND a.contenttype=? AND a.refid=b.pageid AND b.externalsource=1", p.Context.OrgID, contentType)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("Unable to execute select external page meta for org %s and section %s", p.Context.OrgID, contentType), err)
+		return
+	}
+
+	return
+}
+
+// sealString stores a string in a JSON column, encrypted when there is a master key.
+func sealString(s string) ([]byte, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return Keyring.Seal(b)
+}
+
+// openString returns a string stored by sealString.
+func openString(stored []byte) (s string, err error) {
+	b, _, err := Keyring.Open(stored)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(b, &s)
+	return
+}
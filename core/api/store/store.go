@@ -16,7 +16,9 @@ import (
 	"strings"
 
 	"github.com/documize/community/core/api/entity"
+	"github.com/documize/community/core/api/plugins"
 	api "github.com/documize/community/core/convapi"
+	"github.com/documize/community/core/log"
 	"github.com/documize/community/core/stringutil"
 )
 
@@ -56,18 +58,26 @@ func ConvertFileResult(filename string, fileResult *api.DocumentConversionRespon
 // If the target extension is "html" it simply returns the given html suitably wrapped,
 // otherwise it runs the "Export" plugin for the given target extension name.
 func ExportAs(xtn, html string) (*api.DocumentExport, error) {
+	xtn = strings.ToLower(xtn)
 
-	if strings.ToLower(xtn) == "html" {
+	if xtn == "html" {
 		return &api.DocumentExport{File: []byte(html), Format: "html"}, nil
 	}
-	return nil, errors.New("Only 'HTML' export is supported")
 
-	/* This functionality removed for now
+	if plugins.Lib == nil {
+		return nil, errors.New("no export plugins available")
+	}
+
 	fileI, err := plugins.Lib.Run(nil, "Export", xtn, []byte(html))
 	if err != nil {
 		log.Error("ExportAs failed", err)
 		return nil, err
 	}
-	return fileI.(*api.DocumentExport), nil
-	*/
+
+	export, ok := fileI.(*api.DocumentExport)
+	if !ok || export == nil {
+		return nil, errors.New("Export plugin for '" + xtn + "' returned no file")
+	}
+
+	return export, nil
 }
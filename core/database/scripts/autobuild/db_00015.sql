@@ -0,0 +1,8 @@
+/* community edition */
+ALTER TABLE pagemeta ADD COLUMN `refreshinterval` INT NOT NULL DEFAULT 0 AFTER `externalsource`;
+ALTER TABLE pagemeta ADD COLUMN `refreshed` TIMESTAMP DEFAULT CURRENT_TIMESTAMP AFTER `refreshinterval`;
+ALTER TABLE pagemeta ADD COLUMN `refreshattempted` TIMESTAMP DEFAULT CURRENT_TIMESTAMP AFTER `refreshed`;
+ALTER TABLE pagemeta ADD COLUMN `refresherror` VARCHAR(1000) NOT NULL DEFAULT '' AFTER `refreshattempted`;
+ALTER TABLE pagemeta ADD INDEX `idx_pagemeta_refresh` (`externalsource`, `refreshattempted`);
+
+UPDATE pagemeta SET refreshed=revised, refreshattempted=revised;
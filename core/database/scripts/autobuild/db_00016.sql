@@ -0,0 +1,37 @@
+/* community edition */
+DROP TABLE IF EXISTS `connection`;
+
+CREATE TABLE IF NOT EXISTS `connection` (
+	`id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	`refid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`orgid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`userid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`contenttype` CHAR(20) NOT NULL DEFAULT '',
+	`name` VARCHAR(100) NOT NULL DEFAULT '',
+	`config` JSON,
+	`uses` INT UNSIGNED NOT NULL DEFAULT 0,
+	`lastused` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	`created` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	`revised` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT pk_id PRIMARY KEY (id),
+	INDEX `idx_connection_refid` (`refid` ASC),
+	INDEX `idx_connection_orgid` (`orgid` ASC, `contenttype` ASC))
+DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci
+ENGINE =  InnoDB;
+
+DROP TABLE IF EXISTS `connectionlog`;
+
+CREATE TABLE IF NOT EXISTS `connectionlog` (
+	`id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	`orgid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`connectionid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`userid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`pageid` CHAR(16) NOT NULL COLLATE utf8_bin DEFAULT '',
+	`action` VARCHAR(20) NOT NULL DEFAULT '',
+	`created` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT pk_id PRIMARY KEY (id),
+	INDEX `idx_connectionlog_connectionid` (`connectionid` ASC, `created` ASC))
+DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci
+ENGINE =  InnoDB;
+
+ALTER TABLE pagemeta ADD COLUMN `connectionid` CHAR(16) NOT NULL COLLATE utf8_bin DEFAULT '' AFTER `externalsource`;
@@ -0,0 +1,16 @@
+/* community edition */
+DROP TABLE IF EXISTS `webhook`;
+
+CREATE TABLE IF NOT EXISTS `webhook` (
+	`id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	`refid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`orgid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`contenttype` CHAR(20) NOT NULL DEFAULT '',
+	`secret` JSON,
+	`created` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	`revised` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT pk_id PRIMARY KEY (id),
+	UNIQUE INDEX `idx_webhook_refid` (`refid` ASC),
+	UNIQUE INDEX `idx_webhook_orgid` (`orgid` ASC, `contenttype` ASC))
+DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci
+ENGINE =  InnoDB;
@@ -0,0 +1,3 @@
+/* community edition */
+ALTER TABLE page ADD COLUMN `blocklinked` BOOL NOT NULL DEFAULT 0 AFTER `blockid`;
+ALTER TABLE page ADD INDEX `idx_page_blockid` (`blockid` ASC);
@@ -0,0 +1,27 @@
+/* community edition */
+ALTER TABLE block ADD COLUMN `shared` BOOL NOT NULL DEFAULT 0 AFTER `labelid`;
+ALTER TABLE block ADD COLUMN `version` INT UNSIGNED NOT NULL DEFAULT 1 AFTER `used`;
+ALTER TABLE page ADD COLUMN `blockversion` INT UNSIGNED NOT NULL DEFAULT 0 AFTER `blocklinked`;
+UPDATE page SET blockversion=1 WHERE blockid<>'';
+
+DROP TABLE IF EXISTS `blockrevision`;
+
+CREATE TABLE IF NOT EXISTS `blockrevision` (
+	`id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	`refid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`orgid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`blockid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`userid` CHAR(16) NOT NULL DEFAULT '' COLLATE utf8_bin,
+	`version` INT UNSIGNED NOT NULL,
+	`title` NVARCHAR(2000) NOT NULL,
+	`body` LONGTEXT,
+	`excerpt` NVARCHAR(2000) NOT NULL,
+	`rawbody` LONGBLOB,
+	`config` JSON,
+	`created` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	`revised` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT pk_id PRIMARY KEY (id),
+	INDEX `idx_blockrevision_refid` (`refid` ASC),
+	INDEX `idx_blockrevision_blockid` (`blockid` ASC, `version` ASC))
+DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci
+ENGINE =  InnoDB;
@@ -0,0 +1,19 @@
+/* community edition */
+DROP TABLE IF EXISTS `templatevariable`;
+
+CREATE TABLE IF NOT EXISTS `templatevariable` (
+	`id` INT UNSIGNED NOT NULL AUTO_INCREMENT,
+	`refid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`orgid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`documentid` CHAR(16) NOT NULL COLLATE utf8_bin,
+	`name` VARCHAR(50) NOT NULL,
+	`label` NVARCHAR(200) NOT NULL DEFAULT '',
+	`type` VARCHAR(10) NOT NULL DEFAULT 'text',
+	`defaultvalue` NVARCHAR(1000) NOT NULL DEFAULT '',
+	`sequence` INT UNSIGNED NOT NULL DEFAULT 0,
+	`created` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	`revised` TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT pk_id PRIMARY KEY (id),
+	INDEX `idx_templatevariable_documentid` (`orgid` ASC, `documentid` ASC, `sequence` ASC))
+DEFAULT CHARACTER SET utf8 COLLATE utf8_general_ci
+ENGINE =  InnoDB;
@@ -0,0 +1,4 @@
+/* community edition */
+ALTER TABLE document ADD COLUMN `templateid` VARCHAR(100) NOT NULL DEFAULT '' AFTER `template`;
+ALTER TABLE document ADD COLUMN `templateversion` VARCHAR(50) NOT NULL DEFAULT '' AFTER `templateid`;
+ALTER TABLE organization ADD COLUMN `templatefolderid` CHAR(16) NOT NULL DEFAULT '' COLLATE utf8_bin AFTER `service`;
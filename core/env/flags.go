@@ -30,6 +30,8 @@ type Flags struct {
 	HTTPPort          string // (optional) HTTP or HTTPS port
 	ForceHTTPPort2SSL string // (optional) HTTP that should be redirected to HTTPS
 	SiteMode          string // (optional) if 1 then serve offline web page
+	SecretKey         string // (optional) master key used to encrypt stored secrets
+	OldSecretKeys     string // (optional) comma separated master keys replaced by SecretKey
 }
 
 // SSLEnabled returns true if both cert and key were provided at runtime.
@@ -71,7 +73,7 @@ var loadMutex sync.Mutex
 
 // ParseFlags loads command line and OS environment variables required by the program to function.
 func ParseFlags() (f Flags) {
-	var dbConn, jwtKey, siteMode, port, certFile, keyFile, forcePort2SSL string
+	var dbConn, jwtKey, siteMode, port, certFile, keyFile, forcePort2SSL, secretKey, oldSecretKeys string
 
 	register(&jwtKey, "salt", false, "the salt string used to encode JWT tokens, if not set a random value will be generated")
 	register(&certFile, "cert", false, "the cert.pem file used for https")
@@ -79,6 +81,8 @@ func ParseFlags() (f Flags) {
 	register(&port, "port", false, "http/https port number")
 	register(&forcePort2SSL, "forcesslport", false, "redirect given http port number to TLS")
 	register(&siteMode, "offline", false, "set to '1' for OFFLINE mode")
+	register(&secretKey, "secretkey", false, "the master key used to encrypt stored secrets, such as section credentials")
+	register(&oldSecretKeys, "oldsecretkeys", false, "comma separated master keys replaced by secretkey, used until secrets are rotated")
 	register(&dbConn, "db", true, `'username:password@protocol(hostname:port)/databasename" for example "fred:bloggs@tcp(localhost:3306)/documize"`)
 
 	parse("db")
@@ -90,6 +94,8 @@ func ParseFlags() (f Flags) {
 	f.SiteMode = siteMode
 	f.SSLCertFile = certFile
 	f.SSLKeyFile = keyFile
+	f.SecretKey = secretKey
+	f.OldSecretKeys = oldSecretKeys
 
 	return f
 }
@@ -0,0 +1,152 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sealed is how an encrypted value is stored, which is itself JSON so it fits JSON columns.
+type sealed struct {
+	Sealed string `json:"documizeSealed"` // base64 of the nonce followed by the AES-GCM encryption
+	KeyID  string `json:"keyId"`          // identifies the master key used
+}
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+func newMasterKey(master string) masterKey {
+	k := sha256.Sum256([]byte(master))
+	id := sha256.Sum256(append([]byte("documize-key-id:"), k[:]...))
+
+	// the encryption key is derived apart from the key id, so the id tells nothing about it
+	mac := hmac.New(sha256.New, k[:])
+	mac.Write([]byte("documize-keyring-aes-gcm"))
+
+	block, _ := aes.NewCipher(mac.Sum(nil)) // cannot fail with a 32 byte key
+	aead, _ := cipher.NewGCM(block)
+
+	return masterKey{id: hex.EncodeToString(id[:6]), aead: aead}
+}
+
+// seal encrypts and authenticates the value, binding it to the key id.
+func (m *masterKey) seal(plain []byte) ([]byte, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return m.aead.Seal(nonce, nonce, plain, []byte(m.id)), nil
+}
+
+// open decrypts a value sealed by seal, failing when it has been changed.
+func (m *masterKey) open(b []byte) ([]byte, error) {
+	if len(b) < m.aead.NonceSize() {
+		return nil, errors.New("sealed value too short")
+	}
+
+	n := m.aead.NonceSize()
+	return m.aead.Open(nil, b[:n], b[n:], []byte(m.id))
+}
+
+// Keyring holds the master key that encrypts stored secrets, and the keys it replaced,
+// which are only used to read values stored before the last rotation.
+type Keyring struct {
+	current  *masterKey
+	previous []masterKey
+}
+
+// NewKeyring returns a keyring for the current master key, which may be empty to store values as given,
+// and a comma separated list of previous master keys.
+func NewKeyring(current, previous string) *Keyring {
+	k := &Keyring{}
+
+	if len(current) > 0 {
+		m := newMasterKey(current)
+		k.current = &m
+	}
+
+	for _, p := range strings.Split(previous, ",") {
+		if p = strings.TrimSpace(p); len(p) > 0 {
+			k.previous = append(k.previous, newMasterKey(p))
+		}
+	}
+
+	return k
+}
+
+// Encrypting reports whether there is a master key to encrypt with.
+func (k *Keyring) Encrypting() bool {
+	return k != nil && k.current != nil
+}
+
+// Seal encrypts the value with the current master key, without one the value is returned as given.
+func (k *Keyring) Seal(plain []byte) ([]byte, error) {
+	if !k.Encrypting() {
+		return plain, nil
+	}
+
+	b, err := k.current.seal(plain)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(sealed{Sealed: string(EncodeBase64(b)), KeyID: k.current.id})
+}
+
+// Open returns the plain value of one that was stored, which may not have been encrypted.
+// It also reports whether the value is stored as Seal would store it now, if not it should be sealed again.
+func (k *Keyring) Open(stored []byte) (plain []byte, current bool, err error) {
+	var s sealed
+	if json.Unmarshal(stored, &s) != nil || len(s.Sealed) == 0 {
+		return stored, !k.Encrypting(), nil
+	}
+
+	var key *masterKey
+	if k.Encrypting() && k.current.id == s.KeyID {
+		key = k.current
+	} else if k != nil {
+		for i := range k.previous {
+			if k.previous[i].id == s.KeyID {
+				key = &k.previous[i]
+			}
+		}
+	}
+
+	if key == nil {
+		return nil, false, fmt.Errorf("value sealed with unknown master key %s", s.KeyID)
+	}
+
+	b, err := DecodeBase64([]byte(s.Sealed))
+	if err != nil {
+		return nil, false, err
+	}
+
+	plain, err = key.open(b)
+	if err != nil {
+		return nil, false, errors.New("unable to open sealed value: " + err.Error())
+	}
+
+	return plain, key == k.current, nil
+}
@@ -11,7 +11,11 @@
 
 package secrets
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
 
 func TestSecrets(t *testing.T) {
 	mimi := "007"
@@ -33,3 +37,58 @@ func TestSecrets(t *testing.T) {
 	}
 
 }
+
+func TestKeyring(t *testing.T) {
+	plain := []byte(`{"token":"s3cret"}`)
+
+	old := NewKeyring("first master key", "")
+	stored, err := old.Seal(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(stored), "s3cret") {
+		t.Fatalf("value not encrypted: %s", stored)
+	}
+
+	got, current, err := old.Open(stored)
+	if err != nil || !current || string(got) != string(plain) {
+		t.Errorf("unable to open with the same key: %s %v %v", got, current, err)
+	}
+
+	// a changed value fails to open rather than giving garbage
+	var env map[string]string
+	if err = json.Unmarshal(stored, &env); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := DecodeBase64([]byte(env["documizeSealed"]))
+	b[len(b)-1] ^= 1
+	env["documizeSealed"] = string(EncodeBase64(b))
+	tampered, _ := json.Marshal(env)
+	if _, _, err = old.Open(tampered); err == nil {
+		t.Error("expected an error opening a tampered value")
+	}
+
+	// after rotation the old key still opens values, which need sealing again
+	k := NewKeyring("second master key", " first master key , another")
+	got, current, err = k.Open(stored)
+	if err != nil || current || string(got) != string(plain) {
+		t.Errorf("unable to open with a previous key: %s %v %v", got, current, err)
+	}
+
+	if _, _, err = NewKeyring("second master key", "").Open(stored); err == nil {
+		t.Error("expected an error without the key that sealed the value")
+	}
+
+	// plain values are read as they are, and need sealing when there is a key
+	if got, current, err = k.Open(plain); err != nil || current || string(got) != string(plain) {
+		t.Errorf("plain value not read: %s %v %v", got, current, err)
+	}
+
+	none := NewKeyring("", "first master key")
+	if got, _ = none.Seal(plain); string(got) != string(plain) {
+		t.Errorf("expected the value stored as given, got %s", got)
+	}
+	if got, current, err = none.Open(plain); err != nil || !current {
+		t.Errorf("plain value should be current without a key: %v %v", current, err)
+	}
+}
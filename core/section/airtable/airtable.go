@@ -12,11 +12,39 @@
 package airtable
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/documize/community/core/log"
 	"github.com/documize/community/core/section/provider"
 )
 
+const me = "airtable"
+
+// maxRecords caps the number of records held by a section.
+const maxRecords = 500
+
+// apiURL is the Airtable REST API, replaced when testing.
+var apiURL = "https://api.airtable.com"
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// errForbidden is an API key Airtable does not accept, which is then forgotten.
+var errForbidden = errors.New("forbidden")
+
+// errNoAccess is a base or table the API key may not read, the key itself is kept.
+var errNoAccess = errors.New("no access to this base/table")
+
 // Provider represents Airtable
 type Provider struct {
 }
@@ -34,30 +62,361 @@ func (*Provider) Meta() provider.TypeMeta {
 	return section
 }
 
-// Command stub.
+// Command handles authentication and the listing of bases, tables, views and records.
 func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
-	provider.WriteEmpty(w)
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var config = airtableConfig{}
+	err = json.Unmarshal(body, &config)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad config")
+		return
+	}
+
+	config.Clean()
+
+	typed := config.APIKey != provider.SecretReplacement && len(config.APIKey) > 0
+	if !typed {
+		config.APIKey = ctx.GetSecrets("apiKey")
+	}
+
+	if len(config.APIKey) == 0 {
+		provider.WriteMessage(w, me, "Missing API key")
+		return
+	}
+
+	var result interface{}
+
+	switch method {
+	case "auth", "bases":
+		result, err = getBases(config)
+	case "tables":
+		result, err = getTables(config)
+	case "records":
+		result, err = getRecords(config)
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+		return
+	}
+
+	if err == errForbidden {
+		log.IfErr(ctx.MarshalSecrets(secrets{})) // invalid key, so reset it
+		provider.WriteForbidden(w)
+		return
+	}
+
+	if err == errNoAccess {
+		provider.WriteMessage(w, me, err.Error())
+		return
+	}
+
+	if err != nil {
+		provider.WriteError(w, me, err)
+		return
+	}
+
+	// the key has just worked, so save it as our secret
+	if typed {
+		log.IfErr(ctx.MarshalSecrets(secrets{APIKey: config.APIKey}))
+	}
+
+	provider.WriteJSON(w, result)
 }
 
-// Render converts markdown data into HTML suitable for browser rendering.
+// Render converts Airtable records into an HTML table.
 func (*Provider) Render(ctx *provider.Context, config, data string) string {
-	return embed(config, data)
+	var c = airtableConfig{}
+	var d = airtableData{}
+
+	json.Unmarshal([]byte(config), &c)
+
+	// sections created before records were supported hold an embed snippet
+	if err := json.Unmarshal([]byte(data), &d); err != nil || len(c.Table.ID) == 0 {
+		return data
+	}
+
+	c.Clean()
+
+	return render(c, d)
 }
 
-// Refresh just sends back data as-is.
+// Refresh fetches the latest records.
 func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
-	return embed(config, data)
-}
-
-func embed(config, data string) string {
-	return data
-	// return `
-	// <iframe class="airtable-embed"
-	// src="https://airtable.com/embed/shrFOcQ6BYrlUe62L?backgroundColor=yellow&viewControls=on"
-	// frameborder="0"
-	// onmousewheel=""
-	// width="100%"
-	// height="533"
-	// style="background: transparent; border: 1px solid #ccc;"></iframe>
-	// `
+	var c = airtableConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil || len(c.Table.ID) == 0 {
+		return data
+	}
+
+	c.Clean()
+	c.APIKey = ctx.GetSecrets("apiKey")
+
+	if len(c.APIKey) == 0 {
+		log.ErrorString("airtable refresh: missing API key")
+		ctx.RefreshFailed(errors.New("missing API key"))
+		return data
+	}
+
+	result, err := getRecords(c)
+
+	if err != nil {
+		log.Error("airtable refresh: unable to fetch records", err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	j, err := json.Marshal(result)
+
+	if err != nil {
+		log.Error("unable to marshal airtable records", err)
+		return data
+	}
+
+	return string(j)
+}
+
+func render(c airtableConfig, d airtableData) string {
+	payload := airtableRender{Config: c, Fields: c.Fields}
+	if len(payload.Fields) == 0 {
+		payload.Fields = d.Fields
+	}
+	if len(payload.Fields) == 0 {
+		payload.Fields = sortedKeys(d.Records)
+	}
+
+	records := d.Records
+	if len(records) > c.Max {
+		records = records[:c.Max]
+	}
+
+	for _, rec := range records {
+		row := make([]string, len(payload.Fields))
+		for i, f := range payload.Fields {
+			row[i] = cell(rec.Fields[f])
+		}
+		payload.Rows = append(payload.Rows, row)
+	}
+
+	payload.Count = len(payload.Rows)
+	payload.HasData = payload.Count > 0 && len(payload.Fields) > 0
+
+	t := template.New("airtable")
+	t, _ = t.Parse(renderTemplate)
+
+	buffer := new(bytes.Buffer)
+	t.Execute(buffer, payload)
+
+	return buffer.String()
+}
+
+// cell returns the text of a field value, which may be a list or an object such as an attachment or collaborator.
+func cell(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		if t {
+			return "Yes"
+		}
+		return "No"
+	case []interface{}:
+		var parts []string
+		for _, e := range t {
+			if s := cell(e); len(s) > 0 {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		for _, k := range []string{"name", "filename", "email", "text", "url"} {
+			if s, ok := t[k].(string); ok && len(s) > 0 {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func getBases(config airtableConfig) (bases []airtableBase, err error) {
+	params := url.Values{}
+	for {
+		var page airtableBases
+		err = get(config, "/v0/meta/bases", params, &page)
+		if err != nil {
+			return
+		}
+		bases = append(bases, page.Bases...)
+
+		if len(page.Offset) == 0 {
+			break
+		}
+		params.Set("offset", page.Offset)
+	}
+
+	if bases == nil {
+		bases = []airtableBase{}
+	}
+
+	return
+}
+
+func getTables(config airtableConfig) (tables []airtableTable, err error) {
+	if len(config.Base.ID) == 0 {
+		return nil, errors.New("missing base")
+	}
+
+	var result airtableTables
+	err = get(config, "/v0/meta/bases/"+url.PathEscape(config.Base.ID)+"/tables", nil, &result)
+	if err != nil {
+		return
+	}
+
+	tables = result.Tables
+	if tables == nil {
+		tables = []airtableTable{}
+	}
+
+	return
+}
+
+func getRecords(config airtableConfig) (data airtableData, err error) {
+	if len(config.Base.ID) == 0 || len(config.Table.ID) == 0 {
+		err = errors.New("missing base or table")
+		return
+	}
+
+	data.Fields = config.Fields
+	if len(data.Fields) == 0 {
+		data.Fields, err = getFieldNames(config)
+		if err != nil {
+			return
+		}
+	}
+
+	params := url.Values{}
+	params.Set("pageSize", "100")
+	params.Set("maxRecords", strconv.Itoa(config.Max))
+	if len(config.View.ID) > 0 {
+		params.Set("view", config.View.ID)
+	}
+	for _, f := range config.Fields {
+		params.Add("fields[]", f)
+	}
+	for i, s := range config.Sort {
+		params.Set(fmt.Sprintf("sort[%d][field]", i), s.Field)
+		if s.Direction == "desc" {
+			params.Set(fmt.Sprintf("sort[%d][direction]", i), s.Direction)
+		}
+	}
+
+	path := "/v0/" + url.PathEscape(config.Base.ID) + "/" + url.PathEscape(config.Table.ID)
+
+	for {
+		var page airtableRecords
+		err = get(config, path, params, &page)
+		if err != nil {
+			return
+		}
+		data.Records = append(data.Records, page.Records...)
+
+		if len(page.Offset) == 0 || len(data.Records) >= config.Max {
+			break
+		}
+		params.Set("offset", page.Offset)
+	}
+
+	if len(data.Records) > config.Max {
+		data.Records = data.Records[:config.Max]
+	}
+	if data.Records == nil {
+		data.Records = []airtableRecord{}
+	}
+
+	return
+}
+
+// getFieldNames returns the fields of the configured table in schema order.
+func getFieldNames(config airtableConfig) (names []string, err error) {
+	tables, err := getTables(config)
+	if err != nil {
+		return
+	}
+
+	for _, t := range tables {
+		if t.ID == config.Table.ID || t.Name == config.Table.ID {
+			for _, f := range t.Fields {
+				names = append(names, f.Name)
+			}
+			return
+		}
+	}
+
+	return nil, fmt.Errorf("table %s not found", config.Table.ID)
+}
+
+func get(config airtableConfig, path string, params url.Values, v interface{}) error {
+	u := apiURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.APIKey)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized {
+		return errForbidden
+	}
+	if res.StatusCode == http.StatusForbidden {
+		return errNoAccess
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error: HTTP status code %d", res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// sortedKeys returns the field names used by the records, for when no schema is available.
+func sortedKeys(records []airtableRecord) (keys []string) {
+	seen := make(map[string]bool)
+	for _, r := range records {
+		for k := range r.Fields {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return
 }
@@ -0,0 +1,141 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package airtable
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// standIn serves the parts of the Airtable API used by the section.
+func standIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v0/meta/bases":
+			if r.URL.Query().Get("offset") == "" {
+				w.Write([]byte(`{"bases":[{"id":"app1","name":"Ops"}],"offset":"next"}`))
+			} else {
+				w.Write([]byte(`{"bases":[{"id":"app2","name":"Sales"}]}`))
+			}
+		case "/v0/meta/bases/app3/tables":
+			w.WriteHeader(http.StatusForbidden)
+		case "/v0/meta/bases/app1/tables":
+			w.Write([]byte(`{"tables":[{"id":"tbl1","name":"Incidents","fields":[{"id":"f1","name":"Name","type":"singleLineText"},{"id":"f2","name":"Owner","type":"singleCollaborator"},{"id":"f3","name":"Hours","type":"number"}],"views":[{"id":"viw1","name":"Open","type":"grid"}]}]}`))
+		case "/v0/app1/tbl1":
+			if r.URL.Query().Get("view") != "viw1" {
+				t.Errorf("view not requested: %s", r.URL.RawQuery)
+			}
+			if r.URL.Query().Get("offset") == "" {
+				w.Write([]byte(`{"records":[{"id":"rec1","fields":{"Name":"<script>alert(1)</script>","Owner":{"name":"Jane"},"Hours":1.5}}],"offset":"p2"}`))
+			} else {
+				w.Write([]byte(`{"records":[{"id":"rec2","fields":{"Name":"Disk full","Hours":3}}]}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestBasesAndTables(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+	apiURL = s.URL
+
+	c := airtableConfig{APIKey: "key123", Base: airtableOption{ID: "app1"}}
+
+	bases, err := getBases(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bases) != 2 || bases[1].Name != "Sales" {
+		t.Errorf("bases not paged: %+v", bases)
+	}
+
+	tables, err := getTables(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 || len(tables[0].Views) != 1 || tables[0].Views[0].Name != "Open" {
+		t.Errorf("tables not read: %+v", tables)
+	}
+
+	// a base the key cannot read is not a bad key
+	c.Base.ID = "app3"
+	if _, err = getTables(c); err != errNoAccess {
+		t.Errorf("expected no access, got %v", err)
+	}
+
+	c.APIKey = "wrong"
+	if _, err = getBases(c); err != errForbidden {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+}
+
+func TestRefreshAndRender(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+	apiURL = s.URL
+
+	c := airtableConfig{
+		APIKey: "key123",
+		Base:   airtableOption{ID: "app1", Name: "Ops"},
+		Table:  airtableOption{ID: "tbl1", Name: "Incidents"},
+		View:   airtableOption{ID: "viw1", Name: "Open"},
+	}
+	c.Clean()
+
+	data, err := getRecords(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(data.Fields, ",") != "Name,Owner,Hours" || len(data.Records) != 2 {
+		t.Fatalf("records not read: %+v", data)
+	}
+
+	j, _ := json.Marshal(data)
+	cfg, _ := json.Marshal(c)
+
+	html := (&Provider{}).Render(nil, string(cfg), string(j))
+	for _, want := range []string{
+		`<th class="bordered">Owner</th>`,
+		`<td class="bordered">&lt;script&gt;alert(1)&lt;/script&gt;</td><td class="bordered">Jane</td><td class="bordered">1.5</td>`,
+		`<td class="bordered">Disk full</td><td class="bordered"></td><td class="bordered">3</td>`,
+		`contains 2 records`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in %s", want, html)
+		}
+	}
+
+	// chosen columns, in the chosen order
+	c.Fields = []string{"Hours", "Name"}
+	cfg, _ = json.Marshal(c)
+	html = (&Provider{}).Render(nil, string(cfg), string(j))
+	if !strings.Contains(html, `<td class="bordered">3</td><td class="bordered">Disk full</td>`) || strings.Contains(html, "Jane") {
+		t.Errorf("columns not chosen: %s", html)
+	}
+}
+
+func TestRenderEmbed(t *testing.T) {
+	embed := `<iframe class="airtable-embed" src="https://airtable.com/embed/shr1"></iframe>`
+	if (&Provider{}).Render(nil, "{}", embed) != embed {
+		t.Error("embed snippet not kept")
+	}
+}
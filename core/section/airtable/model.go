@@ -0,0 +1,137 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package airtable
+
+import "strings"
+
+// the HTML that is rendered by this section.
+const renderTemplate = `
+{{if .HasData}}
+<p>The <em>{{.Config.Table.Name}}</em> table{{if .Config.View.Name}}, view <em>{{.Config.View.Name}}</em>,{{end}} in the {{.Config.Base.Name}} base contains {{.Count}} records.</p>
+<table class="basic-table section-airtable-table">
+	<thead>
+		<tr>
+			{{range $field := .Fields}}<th class="bordered">{{$field}}</th>{{end}}
+		</tr>
+	</thead>
+	<tbody>
+		{{range $row := .Rows}}
+		<tr>
+			{{range $cell := $row}}<td class="bordered">{{$cell}}</td>{{end}}
+		</tr>
+		{{end}}
+	</tbody>
+</table>
+{{else}}
+<p>There are no Airtable records to see.</p>
+{{end}}
+`
+
+type secrets struct {
+	APIKey string `json:"apiKey"`
+}
+
+type airtableConfig struct {
+	APIKey string          `json:"apiKey"` // only contains the correct key just after it is typed in
+	Base   airtableOption  `json:"base"`
+	Table  airtableOption  `json:"table"`
+	View   airtableOption  `json:"view"`
+	Fields []string        `json:"fields"` // columns to show, in order, all columns when empty
+	Max    int             `json:"max"`
+	Sort   []airtableOrder `json:"sort"`
+}
+
+func (c *airtableConfig) Clean() {
+	c.APIKey = strings.TrimSpace(c.APIKey)
+
+	if c.Max <= 0 || c.Max > maxRecords {
+		c.Max = maxRecords
+	}
+
+	var fields []string
+	for _, f := range c.Fields {
+		if f = strings.TrimSpace(f); len(f) > 0 {
+			fields = append(fields, f)
+		}
+	}
+	c.Fields = fields
+}
+
+type airtableOption struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type airtableOrder struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction"`
+}
+
+type airtableBase struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	PermissionLevel string `json:"permissionLevel"`
+}
+
+type airtableBases struct {
+	Bases  []airtableBase `json:"bases"`
+	Offset string         `json:"offset"`
+}
+
+type airtableField struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type airtableView struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type airtableTable struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	PrimaryFieldID string          `json:"primaryFieldId"`
+	Fields         []airtableField `json:"fields"`
+	Views          []airtableView  `json:"views"`
+}
+
+type airtableTables struct {
+	Tables []airtableTable `json:"tables"`
+}
+
+type airtableRecord struct {
+	ID          string                 `json:"id"`
+	CreatedTime string                 `json:"createdTime"`
+	Fields      map[string]interface{} `json:"fields"`
+}
+
+type airtableRecords struct {
+	Records []airtableRecord `json:"records"`
+	Offset  string           `json:"offset"`
+}
+
+// airtableData is what we store as the section data.
+type airtableData struct {
+	Fields  []string         `json:"fields"`
+	Records []airtableRecord `json:"records"`
+}
+
+type airtableRender struct {
+	Config  airtableConfig
+	Fields  []string
+	Rows    [][]string
+	Count   int
+	HasData bool
+}
@@ -0,0 +1,203 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package chart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+	"github.com/documize/community/core/section/table"
+)
+
+const me = "chart"
+
+// tableSection is a table in the document that can be charted.
+type tableSection struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Provider represents Chart
+type Provider struct {
+}
+
+// Meta describes us
+func (*Provider) Meta() provider.TypeMeta {
+	section := provider.TypeMeta{}
+
+	section.ID = "4c3a4d1e-9b0f-4a8e-bb55-6f2d8e0c7a13"
+	section.Title = "Chart"
+	section.Description = "Bar, line, pie and area charts"
+	section.ContentType = "chart"
+	section.PageType = "section"
+	section.Order = 9995
+
+	return section
+}
+
+// Command lists the tables in the document ("tables"), fetches the data of one ("table"),
+// and draws a preview of the chart ("preview").
+func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var payload struct {
+		Config chartConfig `json:"config"`
+		Data   string      `json:"data"`
+	}
+
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &payload); err != nil {
+			provider.WriteMessage(w, me, "Bad payload")
+			return
+		}
+	}
+
+	payload.Config.Clean()
+
+	switch method {
+	case "tables":
+		tables, err := tables(ctx, query.Get("documentID"))
+		if err != nil {
+			provider.WriteError(w, me, err)
+			return
+		}
+		provider.WriteJSON(w, tables)
+
+	case "table":
+		data, err := tableData(ctx, payload.Config.PageID)
+		if err != nil {
+			provider.WriteMessage(w, me, err.Error())
+			return
+		}
+		provider.WriteJSON(w, data)
+
+	case "preview":
+		d, err := readData(payload.Data)
+		if err != nil {
+			provider.WriteMessage(w, me, err.Error())
+			return
+		}
+		provider.WriteJSON(w, svg(payload.Config, d))
+
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+	}
+}
+
+// Render draws the chart as inline SVG.
+func (*Provider) Render(ctx *provider.Context, config, data string) string {
+	var c chartConfig
+	json.Unmarshal([]byte(config), &c) // an empty config charts the data as bars
+	c.Clean()
+
+	d, err := readData(data)
+	if err != nil {
+		return fmt.Sprintf("<p>Unable to chart the data: %s</p>", template.HTMLEscapeString(err.Error()))
+	}
+
+	return svg(c, d)
+}
+
+// Refresh fetches the data again when the chart is of a table section, keeping the data it has when that fails.
+func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
+	var c chartConfig
+	if err := json.Unmarshal([]byte(config), &c); err != nil {
+		return data
+	}
+	c.Clean()
+
+	if c.Source != "table" {
+		return data
+	}
+
+	latest, err := tableData(ctx, c.PageID)
+	if err != nil {
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	return latest
+}
+
+// tables returns the table sections in the document.
+func tables(ctx *provider.Context, documentID string) (tables []tableSection, err error) {
+	p := persister(ctx)
+	tables = []tableSection{}
+
+	if !p.CanViewDocument(documentID) {
+		return tables, errors.New("no permission to view the document")
+	}
+
+	pages, err := p.GetPagesWithoutContent(documentID)
+	if err != nil {
+		return
+	}
+
+	for _, page := range pages {
+		if page.ContentType == "table" {
+			tables = append(tables, tableSection{ID: page.RefID, Title: page.Title})
+		}
+	}
+
+	return
+}
+
+// tableData returns the data of a table section as CSV, when the context user can view its document.
+func tableData(ctx *provider.Context, pageID string) (string, error) {
+	if len(pageID) == 0 {
+		return "", errors.New("no table chosen")
+	}
+
+	p := persister(ctx)
+
+	page, err := p.GetPage(pageID)
+	if err != nil || page.ContentType != "table" {
+		return "", errors.New("the table no longer exists")
+	}
+
+	if !p.CanViewDocument(page.DocumentID) {
+		return "", errors.New("no permission to view the table")
+	}
+
+	b, err := table.CSV(page.Body)
+	if err != nil {
+		log.Info(fmt.Sprintf("unable to read table %s for chart: %s", pageID, err))
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func persister(ctx *provider.Context) request.Persister {
+	return request.Persister{Context: request.Context{OrgID: ctx.OrgID, UserID: ctx.UserID, Authenticated: true}}
+}
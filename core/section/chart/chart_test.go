@@ -0,0 +1,100 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package chart
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadData(t *testing.T) {
+	d, err := readData("\xef\xbb\xbfMonth\tSales\tCosts\nJan\t1,200\t$800\nFeb\t45%\tn/a\n\nMar\t7\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(d.Categories, ",") != "Jan,Feb,Mar" || len(d.Series) != 2 || d.Series[1].Name != "Costs" {
+		t.Fatalf("unexpected data %+v", d)
+	}
+	if d.Series[0].Values[0] != 1200 || d.Series[1].Values[0] != 800 || d.Series[0].Values[1] != 45 {
+		t.Errorf("unexpected values %+v", d.Series)
+	}
+	if !math.IsNaN(d.Series[1].Values[1]) || !math.IsNaN(d.Series[1].Values[2]) {
+		t.Errorf("expected missing values to be NaN %+v", d.Series[1])
+	}
+
+	for _, bad := range []string{"", "a,b", "only\none"} {
+		if _, err := readData(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestTicks(t *testing.T) {
+	step, out := ticks(0, 93, 5)
+	if step != 20 || len(out) != 6 || out[5] != 100 {
+		t.Errorf("got step %v ticks %v", step, out)
+	}
+
+	step, out = ticks(-0.3, 0.3, 5)
+	if step != 0.2 || out[0] != -0.4 {
+		t.Errorf("got step %v ticks %v", step, out)
+	}
+
+	for _, span := range [][2]float64{{0, 5e-324}, {-1e308, 1e308}, {0, math.Inf(1)}, {math.NaN(), 1}} {
+		if _, out := ticks(span[0], span[1], 5); len(out) < 2 {
+			t.Errorf("%v: got ticks %v", span, out)
+		}
+	}
+
+	if tickLabel(25000, 5000) != "25k" || tickLabel(0.25, 0.05) != "0.25" {
+		t.Errorf("unexpected labels %s %s", tickLabel(25000, 5000), tickLabel(0.25, 0.05))
+	}
+}
+
+func TestRender(t *testing.T) {
+	p := &Provider{}
+	data := "Quarter,North,South\nQ1,10,4\nQ2,-3,8\n"
+
+	for _, kind := range []string{"bar", "line", "area", "pie"} {
+		out := p.Render(nil, `{"type":"`+kind+`","title":"<Sales>","legend":"bottom","stacked":true,"colors":["#123456","red"]}`, data)
+
+		if !strings.HasPrefix(out, "<svg") || !strings.HasSuffix(out, "</svg>") {
+			t.Errorf("%s: not an svg: %s", kind, out)
+		}
+		if strings.Contains(out, "<Sales>") || !strings.Contains(out, "&lt;Sales&gt;") {
+			t.Errorf("%s: title not escaped", kind)
+		}
+		if !strings.Contains(out, "#123456") || strings.Contains(out, `"red"`) {
+			t.Errorf("%s: colours not applied", kind)
+		}
+		if strings.Contains(out, "NaN") || strings.Contains(out, "Inf") {
+			t.Errorf("%s: bad coordinates: %s", kind, out)
+		}
+	}
+
+	// extreme values must neither panic nor leave the axes without ticks
+	for _, data := range []string{"a,b\nx,5e-324\n", "a,b\nx,-1e308\ny,1e308\n", "a,b,c\nx,1e308,1e308\n"} {
+		for _, kind := range []string{"bar", "line", "area"} {
+			out := p.Render(nil, `{"type":"`+kind+`","stacked":true}`, data)
+			if !strings.HasSuffix(out, "</svg>") || strings.Contains(out, "NaN") || strings.Contains(out, "Inf") {
+				t.Errorf("%s %q: bad chart: %s", kind, data, out)
+			}
+		}
+	}
+
+	out := p.Render(nil, "", "<script>")
+	if !strings.HasPrefix(out, "<p>") || strings.Contains(out, "<script>") {
+		t.Errorf("unexpected output for bad data: %s", out)
+	}
+}
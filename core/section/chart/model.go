@@ -0,0 +1,196 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package chart
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxCategories = 200
+	maxSeries     = 20
+	defaultHeight = 320 // pixels, the width is always 640 and scales to fit
+	minHeight     = 160
+	maxHeight     = 800
+)
+
+// palette colours the series when the author has not chosen colours.
+var palette = []string{"#4ab765", "#2c85cc", "#f5a623", "#d0021b", "#9013fe", "#50e3c2", "#8b572a", "#7ed321", "#bd10e0", "#4a4a4a"}
+
+var colour = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// chartConfig is how the author set up the chart, the data is kept separately as CSV.
+type chartConfig struct {
+	Type    string   `json:"type"`    // bar, line, pie or area
+	Title   string   `json:"title"`   // shown above the chart
+	Source  string   `json:"source"`  // "data" entered by the author, or "table" to chart a table section
+	PageID  string   `json:"pageId"`  // the table section charted when Source is "table"
+	XLabel  string   `json:"xLabel"`  // category axis title
+	YLabel  string   `json:"yLabel"`  // value axis title
+	Colors  []string `json:"colors"`  // series colours, or slice colours for pie charts
+	Legend  string   `json:"legend"`  // right, bottom or none
+	Stacked bool     `json:"stacked"` // bars and areas stacked rather than side by side
+	Height  int      `json:"height"`
+}
+
+// Clean fills in defaults for the config.
+func (c *chartConfig) Clean() {
+	c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+	switch c.Type {
+	case "bar", "line", "pie", "area":
+	default:
+		c.Type = "bar"
+	}
+
+	if c.Source != "table" {
+		c.Source = "data"
+	}
+
+	switch c.Legend {
+	case "right", "bottom", "none":
+	default:
+		c.Legend = "right"
+	}
+
+	if c.Height <= 0 {
+		c.Height = defaultHeight
+	}
+	if c.Height < minHeight {
+		c.Height = minHeight
+	}
+	if c.Height > maxHeight {
+		c.Height = maxHeight
+	}
+
+	colors := []string{}
+	for _, col := range c.Colors {
+		if col = strings.TrimSpace(col); colour.MatchString(col) {
+			colors = append(colors, col)
+		}
+	}
+	c.Colors = colors
+}
+
+// color returns the colour of the i-th series or slice.
+func (c *chartConfig) color(i int) string {
+	if i < len(c.Colors) {
+		return c.Colors[i]
+	}
+	return palette[i%len(palette)]
+}
+
+// series holds the values of one column of the data, NaN where a cell is not a number.
+type series struct {
+	Name   string
+	Values []float64
+}
+
+// chartData is the data as charted: a category for each row and a series for each column after the first.
+type chartData struct {
+	Categories []string
+	Series     []series
+}
+
+// readData parses CSV whose first row names the series and whose first column names the categories.
+func readData(data string) (d chartData, err error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix([]byte(data), []byte("\xef\xbb\xbf"))))
+	r.Comma = delimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+
+	rows, err := r.ReadAll()
+	if err != nil {
+		return
+	}
+
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		err = errors.New("the data needs a heading row and a row of values, with categories in the first column")
+		return
+	}
+
+	names := rows[0][1:]
+	if len(names) > maxSeries {
+		names = names[:maxSeries]
+	}
+
+	for _, n := range names {
+		d.Series = append(d.Series, series{Name: strings.TrimSpace(n)})
+	}
+
+	for _, row := range rows[1:] {
+		if len(d.Categories) == maxCategories {
+			break
+		}
+		if len(row) == 0 || (len(row) == 1 && len(strings.TrimSpace(row[0])) == 0) {
+			continue
+		}
+
+		d.Categories = append(d.Categories, strings.TrimSpace(row[0]))
+
+		for i := range d.Series {
+			v := math.NaN()
+			if i+1 < len(row) {
+				v = number(row[i+1])
+			}
+			d.Series[i].Values = append(d.Series[i].Values, v)
+		}
+	}
+
+	if len(d.Categories) == 0 {
+		err = errors.New("the data has no rows of values")
+	}
+
+	return
+}
+
+// maxValue caps the values charted, as sums and spans of larger ones overflow.
+const maxValue = 1e15
+
+// number reads a cell such as "1,234.5", "$12" or "45%", NaN when it is not a number.
+// Values beyond maxValue are capped.
+func number(s string) float64 {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "%")
+	s = strings.TrimLeft(s, "$€£¥")
+	s = strings.Replace(s, ",", "", -1)
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(v, 0) {
+		return math.NaN()
+	}
+
+	return math.Max(-maxValue, math.Min(v, maxValue))
+}
+
+// delimiter guesses the field separator from the first line, as pasting from a spreadsheet gives tabs.
+func delimiter(data string) rune {
+	line := data
+	if i := strings.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+
+	best, count := ',', strings.Count(line, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+
+	return best
+}
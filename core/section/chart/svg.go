@@ -0,0 +1,454 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	width        = 640 // of the view box, the chart scales to the width of the page
+	legendWidth  = 140
+	legendRow    = 18
+	labelLength  = 16 // characters of a category or legend label before it is cut short
+	minLabelGap  = 48 // pixels between category labels, some are skipped when there are many
+	fontSize     = 11
+	axisColor    = "#9b9b9b"
+	gridColor    = "#e8e8e8"
+	textColor    = "#4a4a4a"
+	defaultTicks = 5
+)
+
+// plot is the area inside the axes.
+type plot struct {
+	left, top, width, height float64
+	min, max                 float64 // value range of the axis
+}
+
+// y returns the vertical position of a value.
+func (p plot) y(v float64) float64 {
+	return p.top + p.height - (v-p.min)/(p.max-p.min)*p.height
+}
+
+// svg draws the chart as inline SVG, which needs no script so it shows in exports and emails.
+func svg(c chartConfig, d chartData) string {
+	var b bytes.Buffer
+	height := float64(c.Height)
+
+	label := c.Title
+	if len(label) == 0 {
+		label = strings.Title(c.Type) + " chart"
+	}
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" class="section-chart" viewBox="0 0 %d %d" width="100%%" style="max-width: %dpx;" role="img" aria-label="%s" font-family="sans-serif" font-size="%d">`,
+		width, c.Height, width, esc(label), fontSize)
+	fmt.Fprintf(&b, `<title>%s</title>`, esc(label))
+
+	top := 12.0
+	if len(c.Title) > 0 {
+		fmt.Fprintf(&b, `<text x="%d" y="20" text-anchor="middle" font-size="14" font-weight="bold" fill="%s">%s</text>`, width/2, textColor, esc(c.Title))
+		top = 36
+	}
+
+	// the legend names slices for pie charts and series otherwise
+	names := []string{}
+	if c.Type == "pie" {
+		names = d.Categories
+	} else {
+		for _, s := range d.Series {
+			names = append(names, s.Name)
+		}
+	}
+
+	right, bottom := float64(width)-16, height-8
+	if c.Legend == "right" && len(names) > 0 {
+		right = float64(width) - legendWidth
+		legendRight(&b, c, names, right+12, top)
+	}
+	if c.Legend == "bottom" && len(names) > 0 {
+		bottom -= legendBottom(&b, c, names, height-8)
+	}
+
+	if c.Type == "pie" {
+		pie(&b, c, d, 16, top, right-16, bottom)
+	} else {
+		axes(&b, c, d, top, right, bottom)
+	}
+
+	b.WriteString(`</svg>`)
+
+	return b.String()
+}
+
+// axes draws the axes, then the bars, lines or areas within them.
+func axes(b *bytes.Buffer, c chartConfig, d chartData, top, right, bottom float64) {
+	left := 56.0
+	if len(c.YLabel) > 0 {
+		left += 18
+		fmt.Fprintf(b, `<text x="14" y="%s" transform="rotate(-90 14 %s)" text-anchor="middle" fill="%s">%s</text>`,
+			num((top+bottom-20)/2), num((top+bottom-20)/2), textColor, esc(c.YLabel))
+	}
+
+	bottom -= 22 // category labels
+	if len(c.XLabel) > 0 {
+		fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle" fill="%s">%s</text>`, num((left+right)/2), num(bottom+36), textColor, esc(c.XLabel))
+		bottom -= 18
+	}
+
+	p := plot{left: left, top: top, width: right - left, height: bottom - top}
+	p.min, p.max = valueRange(c, d)
+
+	step, ticks := ticks(p.min, p.max, defaultTicks)
+	p.min, p.max = ticks[0], ticks[len(ticks)-1]
+
+	for _, t := range ticks {
+		y := p.y(t)
+		fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`, num(left), num(y), num(right), num(y), gridColor)
+		fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="end" fill="%s">%s</text>`, num(left-6), num(y+4), textColor, tickLabel(t, step))
+	}
+
+	band := p.width / float64(len(d.Categories))
+	every := int(math.Ceil(minLabelGap / band))
+
+	for i, cat := range d.Categories {
+		if i%every != 0 {
+			continue
+		}
+		fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle" fill="%s">%s</text>`, num(left+band*(float64(i)+0.5)), num(bottom+16), textColor, esc(cut(cat)))
+	}
+
+	switch c.Type {
+	case "bar":
+		bars(b, c, d, p, band)
+	case "line":
+		lines(b, c, d, p, band)
+	case "area":
+		areas(b, c, d, p, band)
+	}
+
+	zero := p.y(math.Max(p.min, 0))
+	fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`, num(left), num(zero), num(right), num(zero), axisColor)
+	fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s"/>`, num(left), num(top), num(left), num(bottom), axisColor)
+}
+
+func bars(b *bytes.Buffer, c chartConfig, d chartData, p plot, band float64) {
+	n := float64(len(d.Series))
+	w := band * 0.8 / n
+	if c.Stacked {
+		w = band * 0.8
+	}
+
+	for i := range d.Categories {
+		up, down := 0.0, 0.0
+
+		for s, ser := range d.Series {
+			v := ser.Values[i]
+			if math.IsNaN(v) {
+				continue
+			}
+
+			x := p.left + band*float64(i) + band*0.1
+			from, to := 0.0, v
+
+			if c.Stacked {
+				if v >= 0 {
+					from, to = up, up+v
+					up = to
+				} else {
+					from, to = down, down+v
+					down = to
+				}
+			} else {
+				x += w * float64(s)
+			}
+
+			y1, y2 := p.y(math.Max(from, to)), p.y(math.Min(from, to))
+			fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"><title>%s</title></rect>`,
+				num(x), num(y1), num(w), num(y2-y1), c.color(s), esc(tooltip(ser.Name, d.Categories[i], v)))
+		}
+	}
+}
+
+func lines(b *bytes.Buffer, c chartConfig, d chartData, p plot, band float64) {
+	for s, ser := range d.Series {
+		var path bytes.Buffer
+		move := true
+
+		for i, v := range ser.Values {
+			if math.IsNaN(v) {
+				move = true // a gap in the line
+				continue
+			}
+
+			cmd := "L"
+			if move {
+				cmd, move = "M", false
+			}
+			fmt.Fprintf(&path, "%s%s %s ", cmd, num(p.left+band*(float64(i)+0.5)), num(p.y(v)))
+		}
+
+		fmt.Fprintf(b, `<path d="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.TrimSpace(path.String()), c.color(s))
+
+		for i, v := range ser.Values {
+			if !math.IsNaN(v) {
+				fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="3" fill="%s"><title>%s</title></circle>`,
+					num(p.left+band*(float64(i)+0.5)), num(p.y(v)), c.color(s), esc(tooltip(ser.Name, d.Categories[i], v)))
+			}
+		}
+	}
+}
+
+func areas(b *bytes.Buffer, c chartConfig, d chartData, p plot, band float64) {
+	base := make([]float64, len(d.Categories))
+	opacity := "0.35"
+	if c.Stacked {
+		opacity = "0.8"
+	}
+
+	for s, ser := range d.Series {
+		lower := make([]float64, len(base))
+		copy(lower, base)
+
+		var upper, outline bytes.Buffer
+		for i, v := range ser.Values {
+			if math.IsNaN(v) {
+				v = 0
+			}
+
+			top := v
+			if c.Stacked {
+				top = lower[i] + v
+				base[i] = top
+			}
+
+			cmd := "L"
+			if i == 0 {
+				cmd = "M"
+			}
+			point := fmt.Sprintf("%s %s ", num(p.left+band*(float64(i)+0.5)), num(p.y(top)))
+			upper.WriteString("L" + point)
+			outline.WriteString(cmd + point)
+		}
+
+		// back along the lower edge, the axis or the series below
+		var back bytes.Buffer
+		for i := len(lower) - 1; i >= 0; i-- {
+			from := 0.0
+			if c.Stacked {
+				from = lower[i]
+			}
+			fmt.Fprintf(&back, "L%s %s ", num(p.left+band*(float64(i)+0.5)), num(p.y(math.Max(from, p.min))))
+		}
+
+		area := "M" + strings.TrimPrefix(upper.String(), "L") + back.String() + "Z"
+		fmt.Fprintf(b, `<path d="%s" fill="%s" fill-opacity="%s" stroke="none"><title>%s</title></path>`, area, c.color(s), opacity, esc(ser.Name))
+		fmt.Fprintf(b, `<path d="%s" fill="none" stroke="%s" stroke-width="2"/>`, strings.TrimSpace(outline.String()), c.color(s))
+	}
+}
+
+// pie draws the first series as slices of a circle, ignoring values that are not positive.
+func pie(b *bytes.Buffer, c chartConfig, d chartData, left, top, right, bottom float64) {
+	values := d.Series[0].Values
+	total := 0.0
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+
+	cx, cy := (left+right)/2, (top+bottom)/2
+	r := math.Min(right-left, bottom-top)/2 - 4
+
+	if total == 0 || r <= 0 {
+		fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle" fill="%s">No values to chart</text>`, num(cx), num(cy), textColor)
+		return
+	}
+
+	angle := -math.Pi / 2
+	for i, v := range values {
+		if !(v > 0) {
+			continue
+		}
+
+		share := v / total
+		tip := esc(tooltip(d.Series[0].Name, d.Categories[i], v))
+
+		if share >= 0.9999 {
+			fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="%s" fill="%s"><title>%s</title></circle>`, num(cx), num(cy), num(r), c.color(i), tip)
+		} else {
+			end := angle + share*2*math.Pi
+			large := 0
+			if share > 0.5 {
+				large = 1
+			}
+			fmt.Fprintf(b, `<path d="M%s %s L%s %s A%s %s 0 %d 1 %s %s Z" fill="%s" stroke="#ffffff"><title>%s</title></path>`,
+				num(cx), num(cy), num(cx+r*math.Cos(angle)), num(cy+r*math.Sin(angle)), num(r), num(r), large,
+				num(cx+r*math.Cos(end)), num(cy+r*math.Sin(end)), c.color(i), tip)
+		}
+
+		if share >= 0.05 {
+			mid := angle + share*math.Pi
+			fmt.Fprintf(b, `<text x="%s" y="%s" text-anchor="middle" fill="#ffffff">%s%%</text>`,
+				num(cx+r*0.65*math.Cos(mid)), num(cy+r*0.65*math.Sin(mid)+4), strconv.FormatFloat(share*100, 'f', 0, 64))
+		}
+
+		angle += share * 2 * math.Pi
+	}
+}
+
+func legendRight(b *bytes.Buffer, c chartConfig, names []string, x, top float64) {
+	for i, name := range names {
+		y := top + float64(i)*legendRow
+		fmt.Fprintf(b, `<rect x="%s" y="%s" width="10" height="10" fill="%s"/>`, num(x), num(y), c.color(i))
+		fmt.Fprintf(b, `<text x="%s" y="%s" fill="%s">%s</text>`, num(x+16), num(y+9), textColor, esc(cut(name)))
+	}
+}
+
+// legendBottom lays the names out in rows from the bottom edge, and returns the height it took.
+func legendBottom(b *bytes.Buffer, c chartConfig, names []string, bottom float64) float64 {
+	rows := [][]int{{}}
+	used := 0.0
+
+	for i, name := range names {
+		w := 24 + float64(len([]rune(cut(name))))*6.5
+		if used+w > width-32 && len(rows[len(rows)-1]) > 0 {
+			rows = append(rows, []int{})
+			used = 0
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], i)
+		used += w
+	}
+
+	height := float64(len(rows)) * legendRow
+
+	for r, row := range rows {
+		x := 16.0
+		y := bottom - height + float64(r)*legendRow + 4
+		for _, i := range row {
+			fmt.Fprintf(b, `<rect x="%s" y="%s" width="10" height="10" fill="%s"/>`, num(x), num(y), c.color(i))
+			fmt.Fprintf(b, `<text x="%s" y="%s" fill="%s">%s</text>`, num(x+16), num(y+9), textColor, esc(cut(names[i])))
+			x += 24 + float64(len([]rune(cut(names[i]))))*6.5
+		}
+	}
+
+	return height + 8
+}
+
+// valueRange returns the lowest and highest values to chart, always including zero.
+func valueRange(c chartConfig, d chartData) (lo, hi float64) {
+	for i := range d.Categories {
+		up, down := 0.0, 0.0
+
+		for _, s := range d.Series {
+			v := s.Values[i]
+			if math.IsNaN(v) {
+				continue
+			}
+
+			if c.Stacked && c.Type != "line" {
+				if v >= 0 {
+					up += v
+				} else {
+					down += v
+				}
+			} else {
+				up, down = math.Max(up, v), math.Min(down, v)
+			}
+		}
+
+		hi, lo = math.Max(hi, up), math.Min(lo, down)
+	}
+
+	return
+}
+
+// ticks returns round values that span lo to hi, about n of them, and the step between them.
+// It returns lo and hi themselves when the span is too small or too large for round values.
+func ticks(lo, hi float64, n int) (float64, []float64) {
+	if hi-lo <= 0 {
+		hi = lo + 1
+	}
+
+	raw := (hi - lo) / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+
+	step := mag * 10
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if raw <= m*mag {
+			step = m * mag
+			break
+		}
+	}
+
+	start, end := math.Floor(lo/step)*step, math.Ceil(hi/step)*step
+	out := []float64{}
+	for i := 0; finite(step) && step > 0 && i <= 4*n; i++ {
+		v := math.Floor((start+step*float64(i))/step+0.5) * step
+		if !finite(v) || v > end+step/2 {
+			break
+		}
+		out = append(out, v)
+	}
+
+	if len(out) < 2 {
+		return hi - lo, []float64{lo, hi}
+	}
+
+	return step, out
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// tickLabel formats an axis value with as many decimals as the step needs, and thousands as k or M.
+func tickLabel(v, step float64) string {
+	switch a := math.Abs(v); {
+	case a >= 1e6 && step >= 1e5:
+		return strconv.FormatFloat(v/1e6, 'f', -1, 64) + "M"
+	case a >= 1e4 && step >= 1e3:
+		return strconv.FormatFloat(v/1e3, 'f', -1, 64) + "k"
+	}
+
+	decimals := 0
+	if step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+func tooltip(name, category string, v float64) string {
+	return fmt.Sprintf("%s, %s: %s", name, category, strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// cut shortens long labels so they do not overlap.
+func cut(s string) string {
+	r := []rune(s)
+	if len(r) > labelLength {
+		return string(r[:labelLength-1]) + "…"
+	}
+	return s
+}
+
+// num formats a coordinate with at most one decimal place.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Floor(f*10+0.5)/10, 'f', -1, 64)
+}
+
+func esc(s string) string {
+	return html.EscapeString(s)
+}
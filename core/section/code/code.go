@@ -12,11 +12,20 @@
 package code
 
 import (
+	"encoding/json"
+	"html"
 	"net/http"
+	"strings"
 
 	"github.com/documize/community/core/section/provider"
 )
 
+// codeConfig holds the language and colour theme chosen in the section editor.
+type codeConfig struct {
+	Lang  string `json:"lang"`
+	Theme string `json:"theme"`
+}
+
 // Provider represents code snippet
 type Provider struct {
 }
@@ -40,9 +49,52 @@ func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.R
 	provider.WriteEmpty(w)
 }
 
-// Render just sends back HMTL as-is.
+// Render returns the code highlighted for its language, with colours inline so that exports keep them.
 func (*Provider) Render(ctx *provider.Context, config, data string) string {
-	return data
+	lang, code, ok := parse(data)
+	if !ok {
+		return data
+	}
+
+	var c = codeConfig{}
+	json.Unmarshal([]byte(config), &c)
+
+	if len(c.Lang) == 0 {
+		c.Lang = lang
+	}
+	if _, ok := themes[c.Theme]; !ok {
+		c.Theme = defaultTheme
+	}
+	t := themes[c.Theme]
+
+	return `<pre class="code-mirror cm-s-solarized cm-s-dark code-highlight" data-lang="` + html.EscapeString(c.Lang) +
+		`" data-theme="` + c.Theme + `" style="background-color: ` + t.background + `; color: ` + t.foreground + `;">` +
+		highlight(code, c.Lang, c.Theme) + `</pre>`
+}
+
+// parse returns the language and code held in the PRE element saved by the section editor.
+// The code itself is not escaped, so it is cut out of the element rather than parsed as HTML.
+func parse(data string) (lang, code string, ok bool) {
+	const attr = `data-lang="`
+
+	// already highlighted, or not from the editor at all
+	open := strings.IndexByte(data, '>')
+	if !strings.HasPrefix(data, "<pre") || open < 0 || strings.Contains(data[:open], "code-highlight") {
+		return
+	}
+	start := strings.Index(data, attr)
+	if start < 0 {
+		return
+	}
+	rest := data[start+len(attr):]
+
+	end := strings.Index(rest, `">`)
+	finish := strings.LastIndex(rest, "</pre>")
+	if end < 0 || finish < end {
+		return
+	}
+
+	return rest[:end], rest[end+2 : finish], true
 }
 
 // Refresh just sends back data as-is.
@@ -0,0 +1,78 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package code
+
+import (
+	"strings"
+	"testing"
+)
+
+func pre(lang, code string) string {
+	return `<pre class="code-mirror cm-s-solarized cm-s-dark" data-lang="` + lang + `">` + code + `</pre>`
+}
+
+func TestRender(t *testing.T) {
+	p := &Provider{}
+
+	out := p.Render(nil, "", pre("go", "// sum\nfunc add(a int) int { return a + 0x1F } // <b>\ns := `raw \\`"))
+	for _, want := range []string{
+		`<pre class="code-mirror cm-s-solarized cm-s-dark code-highlight" data-lang="go" data-theme="solarized-dark" style="background-color: #002b36; color: #93a1a1;">`,
+		`<span class="cm-comment" style="color: #586e75;">// sum</span>`,
+		`<span class="cm-keyword" style="color: #859900;">func</span> add(a <span class="cm-builtin" style="color: #b58900;">int</span>)`,
+		`<span class="cm-number" style="color: #d33682;">0x1F</span>`,
+		`<span class="cm-comment" style="color: #586e75;">// &lt;b&gt;</span>`,
+		"<span class=\"cm-string\" style=\"color: #2aa198;\">`raw \\`</span>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+
+	// highlighted output is left alone
+	if p.Render(nil, "", out) != out {
+		t.Error("highlighted code was highlighted again")
+	}
+
+	// the section config overrides the editor mode
+	out = p.Render(nil, `{"lang":"sql","theme":"github"}`, pre("text/plain", "select 'a' from t"))
+	for _, want := range []string{
+		`data-lang="sql" data-theme="github" style="background-color: #f6f8fa; color: #24292e;"`,
+		`<span class="cm-keyword" style="color: #d73a49;">select</span>`,
+		`<span class="cm-string" style="color: #032f62;">&#39;a&#39;</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %s in %s", want, out)
+		}
+	}
+
+	// legacy content is sent back as-is
+	if p.Render(nil, "", "<p>hello</p>") != "<p>hello</p>" {
+		t.Error("legacy content changed")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		code, mode, out string
+	}{
+		{"a < b", "unknown", "a &lt; b"},
+		{`echo "$HOME" $USER`, "shell", `<span class="cm-builtin" style="color: #b58900;">echo</span> <span class="cm-string" style="color: #2aa198;">&#34;$HOME&#34;</span> <span class="cm-variable-2" style="color: #268bd2;">$USER</span>`},
+		{`x1 = 'it\'s'`, "python", `x1 = <span class="cm-string" style="color: #2aa198;">&#39;it\&#39;s&#39;</span>`},
+		{`<a href="x">y</a><!-- c -->`, "htmlmixed", `<span class="cm-tag" style="color: #268bd2;">&lt;a</span> <span class="cm-attribute" style="color: #93a1a1;">href</span>=<span class="cm-string" style="color: #2aa198;">&#34;x&#34;</span><span class="cm-tag" style="color: #268bd2;">&gt;</span>y<span class="cm-tag" style="color: #268bd2;">&lt;/a</span><span class="cm-tag" style="color: #268bd2;">&gt;</span><span class="cm-comment" style="color: #586e75;">&lt;!-- c --&gt;</span>`},
+	}
+
+	for _, tt := range tests {
+		if got := highlight(tt.code, tt.mode, defaultTheme); got != tt.out {
+			t.Errorf("for %s\nexpected: %s\ngot:      %s", tt.code, tt.out, got)
+		}
+	}
+}
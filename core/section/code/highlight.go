@@ -0,0 +1,328 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package code
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token kinds, named as the CodeMirror styles they mirror.
+type kind int
+
+const (
+	plain kind = iota
+	keyword
+	builtin
+	str
+	comment
+	number
+	variable
+	tag
+	attribute
+)
+
+var classes = map[kind]string{
+	keyword:   "cm-keyword",
+	builtin:   "cm-builtin",
+	str:       "cm-string",
+	comment:   "cm-comment",
+	number:    "cm-number",
+	variable:  "cm-variable-2",
+	tag:       "cm-tag",
+	attribute: "cm-attribute",
+}
+
+// theme gives the colours used for each kind of token.
+// Colours are written inline so that exported documents keep them.
+type theme struct {
+	background string
+	foreground string
+	colors     map[kind]string
+}
+
+// defaultTheme matches the colours used by the section editor.
+const defaultTheme = "solarized-dark"
+
+var themes = map[string]theme{
+	"solarized-dark": {
+		background: "#002b36", foreground: "#93a1a1",
+		colors: map[kind]string{keyword: "#859900", builtin: "#b58900", str: "#2aa198", comment: "#586e75", number: "#d33682", variable: "#268bd2", tag: "#268bd2", attribute: "#93a1a1"},
+	},
+	"solarized-light": {
+		background: "#fdf6e3", foreground: "#657b83",
+		colors: map[kind]string{keyword: "#859900", builtin: "#b58900", str: "#2aa198", comment: "#93a1a1", number: "#d33682", variable: "#268bd2", tag: "#268bd2", attribute: "#586e75"},
+	},
+	"github": {
+		background: "#f6f8fa", foreground: "#24292e",
+		colors: map[kind]string{keyword: "#d73a49", builtin: "#005cc5", str: "#032f62", comment: "#6a737d", number: "#005cc5", variable: "#e36209", tag: "#22863a", attribute: "#6f42c1"},
+	},
+}
+
+type token struct {
+	kind kind
+	text string
+}
+
+// highlight returns the code as HTML with each token coloured by the theme.
+// Code in languages we know nothing about is escaped without colour.
+func highlight(code, mode, themeName string) string {
+	t, ok := themes[themeName]
+	if !ok {
+		t = themes[defaultTheme]
+	}
+
+	var tokens []token
+	if lang, ok := languages[mode]; ok {
+		if lang.markup {
+			tokens = lexMarkup(code)
+		} else {
+			tokens = lex(code, lang)
+		}
+	} else {
+		tokens = []token{{plain, code}}
+	}
+
+	var b bytes.Buffer
+	for _, tk := range tokens {
+		text := html.EscapeString(tk.text)
+		color, ok := t.colors[tk.kind]
+		if tk.kind == plain || !ok {
+			b.WriteString(text)
+			continue
+		}
+		b.WriteString(`<span class="` + classes[tk.kind] + `" style="color: ` + color + `;">` + text + `</span>`)
+	}
+
+	return b.String()
+}
+
+// lex splits code into tokens using the rules of the language.
+func lex(code string, lang *language) (tokens []token) {
+	emit := func(k kind, s string) {
+		if len(s) == 0 {
+			return
+		}
+		if n := len(tokens); n > 0 && tokens[n-1].kind == k {
+			tokens[n-1].text += s
+			return
+		}
+		tokens = append(tokens, token{k, s})
+	}
+
+	for i := 0; i < len(code); {
+		rest := code[i:]
+
+		if c, ok := blockComment(rest, lang); ok {
+			emit(comment, c)
+			i += len(c)
+			continue
+		}
+
+		if c, ok := lineComment(rest, lang); ok {
+			emit(comment, c)
+			i += len(c)
+			continue
+		}
+
+		if s, ok := quoted(rest, lang); ok {
+			emit(str, s)
+			i += len(s)
+			continue
+		}
+
+		if n := numberPattern.FindString(rest); len(n) > 0 && (i == 0 || !isWord(rune(code[i-1]), lang)) {
+			emit(number, n)
+			i += len(n)
+			continue
+		}
+
+		if len(lang.variables) > 0 && strings.HasPrefix(rest, lang.variables) {
+			if w := word(rest[len(lang.variables):], lang); len(w) > 0 {
+				emit(variable, lang.variables+w)
+				i += len(lang.variables) + len(w)
+				continue
+			}
+		}
+
+		if w := word(rest, lang); len(w) > 0 {
+			lookup := w
+			if lang.ignoreCase {
+				lookup = strings.ToLower(w)
+			}
+			switch {
+			case lang.keywords[lookup]:
+				emit(keyword, w)
+			case lang.builtins[lookup]:
+				emit(builtin, w)
+			default:
+				emit(plain, w)
+			}
+			i += len(w)
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		emit(plain, rest[:size])
+		i += size
+	}
+
+	return
+}
+
+var numberPattern = regexp.MustCompile(`^(0[xX][0-9a-fA-F_]+|[0-9][0-9_]*(\.[0-9]+)?([eE][+-]?[0-9]+)?)`)
+
+func blockComment(s string, lang *language) (string, bool) {
+	for _, d := range lang.blockComments {
+		if strings.HasPrefix(s, d[0]) {
+			end := strings.Index(s[len(d[0]):], d[1])
+			if end < 0 {
+				return s, true
+			}
+			return s[:len(d[0])+end+len(d[1])], true
+		}
+	}
+	return "", false
+}
+
+func lineComment(s string, lang *language) (string, bool) {
+	for _, d := range lang.lineComments {
+		if strings.HasPrefix(s, d) {
+			if end := strings.IndexByte(s, '\n'); end >= 0 {
+				return s[:end], true
+			}
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// quoted returns the string literal at the start of s, up to and including its closing delimiter.
+// Single character delimiters end at the line, unless the language uses them for raw strings.
+func quoted(s string, lang *language) (string, bool) {
+	if len(lang.raw) > 0 && strings.HasPrefix(s, lang.raw) {
+		end := strings.Index(s[len(lang.raw):], lang.raw)
+		if end < 0 {
+			return s, true
+		}
+		return s[:len(lang.raw)*2+end], true
+	}
+
+	for _, q := range lang.quotes {
+		if !strings.HasPrefix(s, q) {
+			continue
+		}
+		for i := len(q); i < len(s); i++ {
+			switch {
+			case s[i] == '\\':
+				i++
+			case strings.HasPrefix(s[i:], q):
+				return s[:i+len(q)], true
+			case s[i] == '\n' && len(q) == 1:
+				return s[:i], true
+			}
+		}
+		return s, true
+	}
+
+	return "", false
+}
+
+func word(s string, lang *language) string {
+	end := 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !isWord(r, lang) || (end == 0 && unicode.IsDigit(r)) {
+			break
+		}
+		end += size
+	}
+	return s[:end]
+}
+
+func isWord(r rune, lang *language) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(lang.wordChars, r)
+}
+
+// lexMarkup splits HTML or XML into tags, attributes, values and comments.
+func lexMarkup(code string) (tokens []token) {
+	for len(code) > 0 {
+		loc := markupPattern.FindStringIndex(code)
+		if loc == nil {
+			tokens = append(tokens, token{plain, code})
+			break
+		}
+		if loc[0] > 0 {
+			tokens = append(tokens, token{plain, code[:loc[0]]})
+		}
+
+		m := code[loc[0]:loc[1]]
+		if strings.HasPrefix(m, "<!--") {
+			tokens = append(tokens, token{comment, m})
+		} else {
+			tokens = append(tokens, markupTag(m)...)
+		}
+		code = code[loc[1]:]
+	}
+	return
+}
+
+var (
+	markupPattern    = regexp.MustCompile(`(?s)<!--.*?(-->|$)|</?[A-Za-z!?][^<>]*>?`)
+	attributePattern = regexp.MustCompile(`([^\s=/>]+)(\s*=\s*("[^"]*"?|'[^']*'?|[^\s>]+))?`)
+)
+
+// markupTag splits a single tag into its name, attributes and values.
+func markupTag(m string) (tokens []token) {
+	name := 1
+	if strings.HasPrefix(m, "</") {
+		name = 2
+	}
+	end := name
+	for end < len(m) && !unicode.IsSpace(rune(m[end])) && m[end] != '>' && m[end] != '/' {
+		end++
+	}
+	tokens = append(tokens, token{tag, m[:end]})
+
+	rest := m[end:]
+	closing := ""
+	if strings.HasSuffix(rest, "/>") {
+		rest, closing = rest[:len(rest)-2], "/>"
+	} else if strings.HasSuffix(rest, ">") {
+		rest, closing = rest[:len(rest)-1], ">"
+	}
+
+	for len(rest) > 0 {
+		loc := attributePattern.FindStringSubmatchIndex(rest)
+		if loc == nil {
+			tokens = append(tokens, token{plain, rest})
+			break
+		}
+		if loc[0] > 0 {
+			tokens = append(tokens, token{plain, rest[:loc[0]]})
+		}
+		tokens = append(tokens, token{attribute, rest[loc[2]:loc[3]]})
+		if loc[6] >= 0 {
+			tokens = append(tokens, token{plain, rest[loc[3]:loc[6]]}, token{str, rest[loc[6]:loc[7]]})
+		}
+		rest = rest[loc[1]:]
+	}
+
+	if len(closing) > 0 {
+		tokens = append(tokens, token{tag, closing})
+	}
+	return
+}
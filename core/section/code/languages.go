@@ -0,0 +1,131 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package code
+
+import "strings"
+
+// language describes how to pick out the tokens of a programming language.
+// Languages are keyed by CodeMirror mode, as chosen in the section editor.
+type language struct {
+	keywords      map[string]bool
+	builtins      map[string]bool // types, constants and well known functions
+	lineComments  []string
+	blockComments [][2]string
+	quotes        []string // string delimiters, longest first
+	raw           string   // delimiter of strings without escapes that may span lines
+	variables     string   // prefix of variables, such as $ in shell
+	wordChars     string   // characters allowed in words besides letters, digits and underscore
+	ignoreCase    bool
+	markup        bool // tags rather than code
+}
+
+func words(s string) map[string]bool {
+	m := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		m[w] = true
+	}
+	return m
+}
+
+var languages = map[string]*language{
+	"go": {
+		keywords:      words("break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var"),
+		builtins:      words("bool byte complex64 complex128 error float32 float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr true false iota nil append cap close complex copy delete imag len make new panic print println real recover"),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		raw:           "`",
+	},
+	"clike": {
+		keywords:      words("abstract auto break case catch class const continue default delete do else enum explicit extern final finally for friend goto if implements import inline interface namespace new operator override package private protected public register return sizeof static struct switch template this throw throws try typedef typename union using virtual volatile while fun val var when object companion sealed data in is as out ref readonly async await foreach lock"),
+		builtins:      words("bool boolean byte char double float int long short signed unsigned void string String Integer Object var true false null nullptr NULL"),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"""`, `"`, `'`},
+	},
+	"javascript": {
+		keywords:      words("async await break case catch class const continue debugger default delete do else export extends finally for from function get if import in instanceof let new of return set static super switch this throw try typeof var void while with yield interface type enum implements declare readonly abstract"),
+		builtins:      words("true false null undefined NaN Infinity Array Boolean Date Error JSON Map Math Number Object Promise RegExp Set String Symbol console window document require module string number boolean any never unknown"),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		raw:           "`",
+		wordChars:     "$",
+	},
+	"python": {
+		keywords:     words("and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield"),
+		builtins:     words("True False None self abs all any bool bytes dict enumerate filter float format int isinstance len list map max min object open print range repr set sorted str sum super tuple type zip"),
+		lineComments: []string{"#"},
+		quotes:       []string{`"""`, `'''`, `"`, `'`},
+	},
+	"shell": {
+		keywords:     words("if then else elif fi case esac for while until do done in function select return break continue exit export local readonly declare unset shift source"),
+		builtins:     words("echo printf cd pwd read test eval exec set trap wait kill true false cat grep sed awk curl sudo"),
+		lineComments: []string{"#"},
+		quotes:       []string{`"`, `'`},
+		variables:    "$",
+	},
+	"sql": {
+		keywords:      words("add all alter and as asc begin between by case check column commit constraint create cross database default delete desc distinct drop else end exists foreign from full group having if in index inner insert into is join key left like limit not null offset on or order outer primary references replace right rollback select set table then transaction truncate union unique update values view when where with"),
+		builtins:      words("bigint binary bit blob bool boolean char date datetime decimal double float int integer json longtext mediumtext numeric real smallint text time timestamp tinyint varchar avg coalesce count max min now sum true false"),
+		lineComments:  []string{"--", "#"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`'`, `"`},
+		raw:           "`",
+		ignoreCase:    true,
+	},
+	"ruby": {
+		keywords:      words("alias and begin break case class def defined? do else elsif end ensure for if in module next not or redo rescue retry return self super then undef unless until when while yield require attr_accessor attr_reader attr_writer private protected public"),
+		builtins:      words("true false nil puts print p raise lambda proc new"),
+		lineComments:  []string{"#"},
+		blockComments: [][2]string{{"=begin", "=end"}},
+		quotes:        []string{`"`, `'`},
+		variables:     "@",
+		wordChars:     "?!",
+	},
+	"php": {
+		keywords:      words("abstract and as break case catch class clone const continue declare default do echo else elseif empty enddeclare endfor endforeach endif endswitch endwhile extends final finally fn for foreach function global if implements include include_once instanceof insteadof interface isset list namespace new or print private protected public require require_once return static switch throw trait try unset use var while yield"),
+		builtins:      words("true false null TRUE FALSE NULL array bool float int string self parent"),
+		lineComments:  []string{"//", "#"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		variables:     "$",
+	},
+	"rust": {
+		keywords:      words("as async await break const continue crate dyn else enum extern fn for if impl in let loop match mod move mut pub ref return self Self static struct super trait type unsafe use where while"),
+		builtins:      words("bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32 u64 u128 usize String Vec Option Result Some None Ok Err Box true false"),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`},
+	},
+	"swift": {
+		keywords:      words("as associatedtype break case catch class continue default defer deinit do else enum extension fallthrough fileprivate for func guard if import in init inout internal is let open operator private protocol public repeat rethrows return self Self static struct subscript super switch throw throws try typealias var where while"),
+		builtins:      words("Any Bool Character Double Float Int String Array Dictionary Optional true false nil print"),
+		lineComments:  []string{"//"},
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"""`, `"`},
+	},
+	"css": {
+		keywords:      words("@media @import @font-face @keyframes @supports @charset !important"),
+		builtins:      words("auto none inherit initial block inline flex grid absolute relative fixed solid bold normal"),
+		blockComments: [][2]string{{"/*", "*/"}},
+		quotes:        []string{`"`, `'`},
+		wordChars:     "-@!",
+	},
+	"yaml": {
+		builtins:     words("true false null yes no on off"),
+		lineComments: []string{"#"},
+		quotes:       []string{`"`, `'`},
+	},
+	"xml":       {markup: true},
+	"htmlmixed": {markup: true},
+}
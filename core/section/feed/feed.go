@@ -0,0 +1,248 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+)
+
+const me = "feed"
+
+// maxItems caps the number of entries held by a section.
+const maxItems = 50
+
+// maxBytes caps the size of a feed document we are prepared to read.
+const maxBytes = 5 << 20
+
+// maxSummaryText is the length beyond which summaries are shown as shortened plain text.
+const maxSummaryText = 600
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+var errNotModified = errors.New("not modified")
+
+// Provider represents RSS and Atom feeds
+type Provider struct {
+}
+
+// Meta describes us
+func (*Provider) Meta() provider.TypeMeta {
+	section := provider.TypeMeta{}
+
+	section.ID = "b6a0e5b2-9f0c-4c1e-8a47-5e3b1f7d2c90"
+	section.Title = "Feed"
+	section.Description = "Latest entries from an RSS or Atom feed"
+	section.ContentType = "feed"
+	section.PageType = "tab"
+
+	return section
+}
+
+// Command fetches the feed for the editor to preview.
+func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var config = feedConfig{}
+	err = json.Unmarshal(body, &config)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad config")
+		return
+	}
+
+	config.Clean()
+
+	if len(config.URL) == 0 {
+		provider.WriteMessage(w, me, "Missing feed URL")
+		return
+	}
+
+	switch method {
+	case "preview":
+		data, err := fetch(config, feedData{})
+		if err != nil {
+			provider.WriteError(w, me, err)
+			return
+		}
+		provider.WriteJSON(w, data)
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+	}
+}
+
+// Render converts the feed entries into an HTML list.
+func (*Provider) Render(ctx *provider.Context, config, data string) string {
+	var c = feedConfig{}
+	var d = feedData{}
+
+	json.Unmarshal([]byte(config), &c)
+	json.Unmarshal([]byte(data), &d)
+
+	c.Clean()
+
+	return render(c, d)
+}
+
+// Refresh fetches the feed again, keeping what we have when it is unchanged or unavailable.
+func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
+	var c = feedConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil {
+		return data
+	}
+
+	c.Clean()
+
+	if len(c.URL) == 0 {
+		return data
+	}
+
+	var previous = feedData{}
+	json.Unmarshal([]byte(data), &previous)
+
+	result, err := fetch(c, previous)
+
+	if err == errNotModified {
+		return data
+	}
+
+	if err != nil {
+		log.Error("feed refresh: unable to fetch "+c.URL, err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	j, err := json.Marshal(result)
+
+	if err != nil {
+		log.Error("unable to marshal feed entries", err)
+		return data
+	}
+
+	return string(j)
+}
+
+func render(c feedConfig, d feedData) string {
+	payload := feedRender{Title: d.Title, Link: d.Link}
+	if len(payload.Title) == 0 {
+		payload.Title = c.URL
+	}
+
+	items := d.Items
+	if len(items) > c.Max {
+		items = items[:c.Max]
+	}
+
+	for _, i := range items {
+		item := feedRenderItem{Title: i.Title, Link: i.Link}
+		if len(item.Title) == 0 {
+			item.Title = "(untitled)"
+		}
+		if t, err := time.Parse(time.RFC3339, i.Date); err == nil {
+			item.Date = t.Format("2 January 2006")
+		}
+		if c.ShowSummary && len(i.Summary) > 0 {
+			item.Summary = summary(i.Summary)
+		}
+		payload.Items = append(payload.Items, item)
+	}
+
+	payload.HasItems = len(payload.Items) > 0
+
+	t := template.New("feed")
+	t, _ = t.Parse(renderTemplate)
+
+	buffer := new(bytes.Buffer)
+	t.Execute(buffer, payload)
+
+	return buffer.String()
+}
+
+// summary returns the sanitized summary, or shortened plain text when it is long.
+func summary(s string) template.HTML {
+	text := plain(s)
+	if len([]rune(text)) > maxSummaryText {
+		return template.HTML(escapeText(truncate(text, maxSummaryText)))
+	}
+	return template.HTML(sanitize(s))
+}
+
+// fetch reads the feed, asking the server to skip it when unchanged since the previous fetch.
+func fetch(config feedConfig, previous feedData) (data feedData, err error) {
+	req, err := http.NewRequest("GET", config.URL, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if len(previous.ETag) > 0 {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+	if len(previous.LastModified) > 0 {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return previous, errNotModified
+	}
+
+	if res.StatusCode != http.StatusOK {
+		err = fmt.Errorf("error: HTTP status code %d", res.StatusCode)
+		return
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxBytes))
+	if err != nil {
+		return
+	}
+
+	data, err = parse(body, maxItems)
+	if err != nil {
+		return
+	}
+
+	data.ETag = res.Header.Get("ETag")
+	data.LastModified = res.Header.Get("Last-Modified")
+
+	return
+}
@@ -0,0 +1,192 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package feed
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+const rssDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+	<title>Release notes</title>
+	<link>https://example.com/notes</link>
+	<item>
+		<title>Version 2 &amp; more</title>
+		<link>https://example.com/notes/2</link>
+		<pubDate>Mon, 02 May 2016 10:00:00 +0000</pubDate>
+		<description><![CDATA[<p>Now <b>faster</b><script>alert(1)</script> <a href="javascript:alert(2)">x</a> <img src="https://example.com/i.png"></p>]]></description>
+	</item>
+	<item>
+		<title>Version 1</title>
+		<guid>https://example.com/notes/1</guid>
+		<pubDate>Fri, 1 Apr 2016 10:00:00 GMT</pubDate>
+		<content:encoded><![CDATA[<a href="https://example.com/d" onclick="steal()">Download</a>]]></content:encoded>
+	</item>
+</channel>
+</rss>`
+
+const atomDoc = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<title>Blog</title>
+	<link href="https://example.com/blog" rel="alternate"/>
+	<link href="https://example.com/blog/atom" rel="self"/>
+	<entry>
+		<title type="html">&lt;em&gt;Hello&lt;/em&gt; world</title>
+		<link href="https://example.com/blog/hello"/>
+		<updated>2016-05-03T08:30:00Z</updated>
+		<summary>Plain &lt;text&gt; summary</summary>
+	</entry>
+	<entry>
+		<title>Second</title>
+		<link rel="alternate" href="https://example.com/blog/second"/>
+		<published>2016-05-04T08:30:00+02:00</published>
+		<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Rich <strong>content</strong></p></div></content>
+	</entry>
+</feed>`
+
+// standIn serves feeds, answering conditional requests for the RSS feed.
+func standIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(rssDoc))
+		case "/atom":
+			w.Write([]byte(atomDoc))
+		case "/html":
+			w.Write([]byte(`<html><body>Not a feed</body></html>`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestRSS(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	c := feedConfig{URL: s.URL + "/rss", ShowSummary: true}
+	c.Clean()
+
+	data, err := fetch(c, feedData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Title != "Release notes" || len(data.Items) != 2 || data.ETag != `"v1"` {
+		t.Fatalf("feed not read: %+v", data)
+	}
+	if data.Items[0].Title != "Version 2 & more" || data.Items[0].Date != "2016-05-02T10:00:00Z" {
+		t.Errorf("first item not read: %+v", data.Items[0])
+	}
+	if data.Items[1].Link != "https://example.com/notes/1" || data.Items[1].Date != "2016-04-01T10:00:00Z" {
+		t.Errorf("guid and content not used: %+v", data.Items[1])
+	}
+
+	j, _ := json.Marshal(data)
+	cfg, _ := json.Marshal(c)
+
+	html := (&Provider{}).Render(nil, string(cfg), string(j))
+	for _, want := range []string{
+		`<a href="https://example.com/notes">Release notes</a>`,
+		`<a class="feed-item-title" href="https://example.com/notes/2">Version 2 &amp; more</a>`,
+		`<span class="feed-item-date">2 May 2016</span>`,
+		`<p>Now <b>faster</b> <a>x</a> </p>`,
+		`<a href="https://example.com/d" rel="nofollow noopener" target="_blank">Download</a>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in %s", want, html)
+		}
+	}
+	for _, bad := range []string{"script", "javascript", "img", "onclick"} {
+		if strings.Contains(html, bad) {
+			t.Errorf("unsafe %s rendered: %s", bad, html)
+		}
+	}
+
+	// an unchanged feed keeps the data as it is
+	if (&Provider{}).Refresh(nil, string(cfg), string(j)) != string(j) {
+		t.Error("expected unchanged data on refresh")
+	}
+}
+
+func TestAtom(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	c := feedConfig{URL: s.URL + "/atom", Max: 1}
+	c.Clean()
+	cfg, _ := json.Marshal(c)
+
+	refreshed := (&Provider{}).Refresh(nil, string(cfg), "{}")
+
+	var data feedData
+	json.Unmarshal([]byte(refreshed), &data)
+	if data.Title != "Blog" || data.Link != "https://example.com/blog" || len(data.Items) != 2 {
+		t.Fatalf("feed not read: %+v", data)
+	}
+	if data.Items[0].Title != "Hello world" || data.Items[0].Summary != "Plain &lt;text&gt; summary" {
+		t.Errorf("first entry not read: %+v", data.Items[0])
+	}
+	if data.Items[1].Date != "2016-05-04T06:30:00Z" || !strings.Contains(data.Items[1].Summary, "<strong>content</strong>") {
+		t.Errorf("second entry not read: %+v", data.Items[1])
+	}
+
+	html := (&Provider{}).Render(nil, string(cfg), refreshed)
+	if !strings.Contains(html, `href="https://example.com/blog/hello">Hello world</a>`) || strings.Contains(html, "Second") || strings.Contains(html, "summary") {
+		t.Errorf("expected one entry without summary: %s", html)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	for _, path := range []string{"/html", "/missing"} {
+		c := feedConfig{URL: s.URL + path}
+		c.Clean()
+		if _, err := fetch(c, feedData{}); err == nil {
+			t.Errorf("expected an error for %s", path)
+		}
+		cfg, _ := json.Marshal(c)
+		ctx := &provider.Context{}
+		if (&Provider{}).Refresh(ctx, string(cfg), `{"title":"kept"}`) != `{"title":"kept"}` {
+			t.Errorf("expected data kept for %s", path)
+		}
+		if ctx.RefreshError() == nil {
+			t.Errorf("expected the failure recorded for %s", path)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	long := "<p>" + strings.Repeat("word ", 200) + "</p>"
+	got := string(summary(long))
+	if strings.Contains(got, "<p>") || !strings.HasSuffix(got, "…") {
+		t.Errorf("long summary not shortened: %s", got)
+	}
+
+	got = sanitize(`<div style="x"><a href="mailto:a@b.c" title="t">mail</a><style>p{}</style><br/>&lt;ok&gt;</div>`)
+	if got != `<a href="mailto:a@b.c" rel="nofollow noopener" target="_blank">mail</a><br>&lt;ok&gt;` {
+		t.Errorf("unexpected sanitized summary: %s", got)
+	}
+}
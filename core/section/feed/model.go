@@ -0,0 +1,89 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package feed
+
+import (
+	"html/template"
+	"strings"
+)
+
+// the HTML that is rendered by this section.
+const renderTemplate = `
+<div class="section-feed-render">
+{{if .HasItems}}
+	<p class="feed-title">{{if .Link}}<a href="{{.Link}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</p>
+	<ul class="feed-items">
+		{{range $item := .Items}}
+		<li class="feed-item">
+			{{if $item.Link}}<a class="feed-item-title" href="{{$item.Link}}">{{$item.Title}}</a>{{else}}<span class="feed-item-title">{{$item.Title}}</span>{{end}}
+			{{if $item.Date}}<span class="feed-item-date">{{$item.Date}}</span>{{end}}
+			{{if $item.Summary}}<div class="feed-item-summary">{{$item.Summary}}</div>{{end}}
+		</li>
+		{{end}}
+	</ul>
+{{else}}
+	<p>There are no feed entries to see.</p>
+{{end}}
+</div>
+`
+
+type feedConfig struct {
+	URL         string `json:"url"`
+	Max         int    `json:"max"`
+	ShowSummary bool   `json:"showSummary"`
+}
+
+func (c *feedConfig) Clean() {
+	c.URL = strings.TrimSpace(c.URL)
+	if len(c.URL) > 0 && !strings.Contains(c.URL, "://") {
+		c.URL = "http://" + c.URL
+	}
+
+	if c.Max <= 0 {
+		c.Max = 10
+	}
+	if c.Max > maxItems {
+		c.Max = maxItems
+	}
+}
+
+// feedItem is an RSS item or Atom entry.
+type feedItem struct {
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Date    string `json:"date"`    // RFC 3339 when the feed date could be read
+	Summary string `json:"summary"` // HTML as found in the feed, sanitized when rendered
+}
+
+// feedData is what we store as the section data.
+// The validators let a refresh skip feeds that have not changed.
+type feedData struct {
+	Title        string     `json:"title"`
+	Link         string     `json:"link"`
+	Items        []feedItem `json:"items"`
+	ETag         string     `json:"etag,omitempty"`
+	LastModified string     `json:"lastModified,omitempty"`
+}
+
+type feedRenderItem struct {
+	Title   string
+	Link    string // left to the template to make safe
+	Date    string
+	Summary template.HTML
+}
+
+type feedRender struct {
+	Title    string
+	Link     string
+	Items    []feedRenderItem
+	HasItems bool
+}
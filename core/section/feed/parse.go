@@ -0,0 +1,276 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxSummary caps the size of a stored summary.
+const maxSummary = 20000
+
+type rssFeed struct {
+	Channel struct {
+		Title string    `xml:"title"`
+		Link  string    `xml:"link"`
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items []rssItem `xml:"item"` // RSS 1.0 puts items beside the channel
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Description string `xml:"description"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+type atomFeed struct {
+	Title   atomText    `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	Title     atomText   `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// atomText is text, HTML or XHTML content.
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Body  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// html returns the content as HTML.
+func (t atomText) html() string {
+	switch t.Type {
+	case "xhtml":
+		return t.Inner
+	case "html", "text/html":
+		return t.Body
+	}
+	return escapeText(t.Body)
+}
+
+// text returns the content as plain text, as used for titles.
+func (t atomText) text() string {
+	if t.Type == "xhtml" {
+		return plain(t.Inner)
+	}
+	if t.Type == "html" || t.Type == "text/html" {
+		return plain(t.Body)
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+func alternate(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// parse reads an RSS or Atom document, keeping at most max items.
+func parse(data []byte, max int) (f feedData, err error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return
+	}
+
+	switch root {
+	case "rss", "RDF":
+		var rss rssFeed
+		if err = decode(data, &rss); err != nil {
+			return
+		}
+		f.Title = strings.TrimSpace(rss.Channel.Title)
+		f.Link = strings.TrimSpace(rss.Channel.Link)
+		items := rss.Channel.Items
+		if len(items) == 0 {
+			items = rss.Items
+		}
+		for _, i := range items {
+			item := feedItem{
+				Title:   plain(i.Title),
+				Link:    strings.TrimSpace(i.Link),
+				Date:    date(i.PubDate, i.Date),
+				Summary: i.Description,
+			}
+			if len(item.Link) == 0 && strings.HasPrefix(i.GUID, "http") {
+				item.Link = strings.TrimSpace(i.GUID)
+			}
+			if len(item.Summary) == 0 {
+				item.Summary = i.Content
+			}
+			f.Items = append(f.Items, item)
+		}
+
+	case "feed":
+		var atom atomFeed
+		if err = decode(data, &atom); err != nil {
+			return
+		}
+		f.Title = atom.Title.text()
+		f.Link = alternate(atom.Links)
+		for _, e := range atom.Entries {
+			item := feedItem{
+				Title:   e.Title.text(),
+				Link:    alternate(e.Links),
+				Date:    date(e.Published, e.Updated),
+				Summary: e.Summary.html(),
+			}
+			if len(strings.TrimSpace(item.Summary)) == 0 {
+				item.Summary = e.Content.html()
+			}
+			f.Items = append(f.Items, item)
+		}
+
+	default:
+		return f, fmt.Errorf("not an RSS or Atom feed, found <%s>", root)
+	}
+
+	if len(f.Items) > max {
+		f.Items = f.Items[:max]
+	}
+	for i := range f.Items {
+		f.Items[i].Summary = strings.TrimSpace(f.Items[i].Summary)
+		if len(f.Items[i].Summary) > maxSummary {
+			f.Items[i].Summary = plain(f.Items[i].Summary)
+		}
+		if len(f.Items[i].Summary) > maxSummary {
+			f.Items[i].Summary = f.Items[i].Summary[:maxSummary]
+		}
+	}
+	if f.Items == nil {
+		f.Items = []feedItem{}
+	}
+
+	return
+}
+
+func decode(data []byte, v interface{}) error {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+	d.CharsetReader = charsetReader
+	return d.Decode(v)
+}
+
+// rootElement returns the local name of the document element.
+func rootElement(data []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	d.CharsetReader = charsetReader
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return "", errors.New("not an RSS or Atom feed")
+		}
+		if s, ok := t.(xml.StartElement); ok {
+			return s.Name.Local, nil
+		}
+	}
+}
+
+// charsetReader supports the Latin encodings, the only common alternatives to UTF-8 in feeds.
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "latin-1", "windows-1252", "cp1252":
+		data, err := readAll(input)
+		if err != nil {
+			return nil, err
+		}
+		var b bytes.Buffer
+		for _, c := range data {
+			b.WriteRune(rune(c))
+		}
+		return &b, nil
+	}
+	return nil, fmt.Errorf("unsupported feed encoding %s", label)
+}
+
+func readAll(r io.Reader) ([]byte, error) {
+	var b bytes.Buffer
+	_, err := b.ReadFrom(r)
+	return b.Bytes(), err
+}
+
+var dateFormats = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// date returns the first date that can be read, in RFC 3339 format.
+func date(values ...string) string {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		for _, f := range dateFormats {
+			if t, err := time.Parse(f, v); err == nil {
+				return t.UTC().Format(time.RFC3339)
+			}
+		}
+	}
+	return ""
+}
+
+// escapeText makes plain text safe to use as HTML.
+func escapeText(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// truncate shortens text to at most n runes.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return strings.TrimSpace(string(r[:n])) + "…"
+}
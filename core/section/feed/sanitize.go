@@ -0,0 +1,38 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package feed
+
+import (
+	"strings"
+
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/html/atom"
+)
+
+// summaries keeps simple formatting and links in summaries, anything else is replaced by its content.
+var summaries = stringutil.Sanitizer{
+	Elements: map[atom.Atom]bool{
+		atom.A: true, atom.B: true, atom.Blockquote: true, atom.Br: true, atom.Code: true, atom.Em: true,
+		atom.I: true, atom.Li: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Strong: true, atom.Ul: true,
+	},
+	NewWindow: true,
+}
+
+// sanitize returns feed HTML with only simple formatting and links, without scripts, styles or images.
+func sanitize(s string) string {
+	return strings.TrimSpace(summaries.Sanitize(s))
+}
+
+// plain returns the text of HTML, with runs of whitespace collapsed.
+func plain(s string) string {
+	return stringutil.PlainText(s)
+}
@@ -19,11 +19,15 @@ import (
 	"html/template"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/documize/community/core/log"
 	"github.com/documize/community/core/section/provider"
 )
 
+// client times out, as refreshes run one after another and must not wait on an unresponsive server.
+var client = &http.Client{Timeout: 30 * time.Second}
+
 // Provider represents Gemini
 type Provider struct {
 }
@@ -119,7 +123,6 @@ func (*Provider) Refresh(ctx *provider.Context, config, data string) (newData st
 	creds := []byte(fmt.Sprintf("%s:%s", c.Username, c.APIKey))
 	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(creds))
 
-	client := &http.Client{}
 	res, err := client.Do(req)
 
 	if err != nil {
@@ -192,7 +195,6 @@ func auth(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/users/username/%s", config.URL, config.Username), nil)
 	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(creds))
 
-	client := &http.Client{}
 	res, err := client.Do(req)
 
 	if err != nil {
@@ -267,7 +269,6 @@ func workspace(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
 	creds := []byte(fmt.Sprintf("%s:%s", config.Username, config.APIKey))
 	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(creds))
 
-	client := &http.Client{}
 	res, err := client.Do(req)
 
 	if err != nil {
@@ -344,7 +345,6 @@ func items(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString(creds))
 
-	client := &http.Client{}
 	res, err := client.Do(req)
 
 	if err != nil {
@@ -373,8 +373,21 @@ func items(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
 	provider.WriteJSON(w, items)
 }
 
+// secs returns the saved credentials for the editor, only telling which are set when they are those of a shared connection.
 func secs(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
 	sec, err := getSecrets(ctx)
 	log.IfErr(err)
+
+	if len(ctx.Connection()) > 0 {
+		sec = secrets{URL: mask(sec.URL), Username: mask(sec.Username), APIKey: mask(sec.APIKey)}
+	}
+
 	provider.WriteJSON(w, sec)
 }
+
+func mask(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	return provider.SecretReplacement
+}
@@ -41,6 +41,7 @@ func init() {
 	meta.ContentType = "github"
 	meta.PageType = "tab"
 	meta.Callback = Callback
+	meta.Webhook = Webhook
 }
 
 // Provider represents GitHub
@@ -148,7 +149,7 @@ func (p *Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http
 	}
 }
 
-// Refresh ... gets the latest version
+// Refresh ... gets the latest version, keeping the data as it was when GitHub cannot be reached
 func (p *Provider) Refresh(ctx *provider.Context, configJSON, data string) string {
 	var c = githubConfig{}
 
@@ -156,30 +157,47 @@ func (p *Provider) Refresh(ctx *provider.Context, configJSON, data string) strin
 
 	if err != nil {
 		log.Error("unable to unmarshall github config", err)
-		return "internal configuration error '" + err.Error() + "'"
+		ctx.RefreshFailed(err)
+		return data
 	}
 
 	c.Clean()
 	c.Token = ctx.GetSecrets("token")
 
+	if len(c.Token) == 0 {
+		ctx.RefreshFailed(errors.New("missing github token"))
+		return data
+	}
+
 	client := p.githubClient(&c)
 
-	byts, err := json.Marshal(refreshReportData(&c, client))
+	gr, err := refreshReportData(&c, client)
+	if err != nil {
+		log.Error("github refresh: unable to fetch data", err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	byts, err := json.Marshal(gr)
 	if err != nil {
 		log.Error("unable to marshall github data", err)
-		return "internal configuration error '" + err.Error() + "'"
+		return data
 	}
 
 	return string(byts)
 
 }
 
-func refreshReportData(c *githubConfig, client *gogithub.Client) *githubRender {
+// refreshReportData fetches every report, returning the first error met along with what was fetched.
+func refreshReportData(c *githubConfig, client *gogithub.Client) (*githubRender, error) {
 	var gr = githubRender{}
+	var first error
 	for _, rep := range reports {
-		log.IfErr(rep.refresh(&gr, c, client))
+		if err := rep.refresh(&gr, c, client); err != nil && first == nil {
+			first = err
+		}
 	}
-	return &gr
+	return &gr, first
 }
 
 // Render ... just returns the data given, suitably formatted
@@ -97,7 +97,9 @@ func listFailed(method string, config githubConfig, client *gogithub.Client, w h
 
 	case "content":
 
-		provider.WriteJSON(w, refreshReportData(&config, client))
+		gr, err := refreshReportData(&config, client)
+		log.IfErr(err)
+		provider.WriteJSON(w, gr)
 
 	default:
 		return true // failed to get a list
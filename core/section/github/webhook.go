@@ -0,0 +1,93 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+// webhookEvents are those that change what a section shows, others such as ping are acknowledged and ignored.
+var webhookEvents = map[string]bool{"push": true, "issues": true, "milestone": true, "create": true, "delete": true}
+
+// Webhook handles an event GitHub posts for a repository, signed with the secret in the X-Hub-Signature-256
+// header (or X-Hub-Signature by older servers), and matches the sections that report on that repository.
+func Webhook(r *http.Request, body []byte, secret string) (provider.WebhookMatch, error) {
+	if !validSignature(r, body, secret) {
+		return nil, provider.ErrWebhookSignature
+	}
+
+	if !webhookEvents[r.Header.Get("X-GitHub-Event")] {
+		return nil, nil
+	}
+
+	var event struct {
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	repo := event.Repository.FullName
+	if len(repo) == 0 {
+		return nil, nil
+	}
+
+	return func(config string) bool {
+		var c githubConfig
+		if json.Unmarshal([]byte(config), &c) != nil {
+			return false
+		}
+
+		for _, l := range c.Lists {
+			if l.Included && strings.EqualFold(l.Owner+"/"+l.Repo, repo) {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+// validSignature checks the HMAC of the body, as "sha256=<hex>" or "sha1=<hex>".
+func validSignature(r *http.Request, body []byte, secret string) bool {
+	sig, algorithm, hash := r.Header.Get("X-Hub-Signature-256"), "sha256", sha256.New
+
+	if len(sig) == 0 {
+		sig, algorithm, hash = r.Header.Get("X-Hub-Signature"), "sha1", sha1.New
+	}
+
+	parts := strings.SplitN(sig, "=", 2)
+	if len(secret) == 0 || len(parts) != 2 || parts[0] != algorithm {
+		return false
+	}
+
+	got, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(hash, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(got, mac.Sum(nil))
+}
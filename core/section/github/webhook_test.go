@@ -0,0 +1,103 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+func sign(h func() hash.Hash, secret string, body []byte) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"repository":{"full_name":"documize/community"}}`)
+
+	post := func(headers ...string) *http.Request {
+		r := httptest.NewRequest("POST", "/api/public/webhooks/x", nil)
+		for i := 0; i+1 < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		return r
+	}
+
+	for _, tc := range []struct {
+		name   string
+		r      *http.Request
+		secret string
+		want   bool
+	}{
+		{"sha256", post("X-Hub-Signature-256", "sha256="+sign(sha256.New, "s3cret", body)), "s3cret", true},
+		{"sha1 fallback", post("X-Hub-Signature", "sha1="+sign(sha1.New, "s3cret", body)), "s3cret", true},
+		{"sha256 preferred", post("X-Hub-Signature-256", "sha256=00", "X-Hub-Signature", "sha1="+sign(sha1.New, "s3cret", body)), "s3cret", false},
+		{"wrong secret", post("X-Hub-Signature-256", "sha256="+sign(sha256.New, "guess", body)), "s3cret", false},
+		{"wrong algorithm", post("X-Hub-Signature-256", "sha1="+sign(sha1.New, "s3cret", body)), "s3cret", false},
+		{"missing header", post(), "s3cret", false},
+		{"bad hex", post("X-Hub-Signature-256", "sha256=zz"), "s3cret", false},
+		{"no value", post("X-Hub-Signature-256", "sha256"), "s3cret", false},
+		{"empty secret", post("X-Hub-Signature-256", "sha256="+sign(sha256.New, "", body)), "", false},
+	} {
+		if got := validSignature(tc.r, body, tc.secret); got != tc.want {
+			t.Errorf("%s: got %v", tc.name, got)
+		}
+	}
+
+	// a body changed after signing is refused
+	r := post("X-Hub-Signature-256", "sha256="+sign(sha256.New, "s3cret", body))
+	if validSignature(r, append(body, ' '), "s3cret") {
+		t.Error("expected a changed body to be refused")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	config := `{"lists":[{"owner":"documize","repo":"community","included":true},{"owner":"documize","repo":"site","included":false}]}`
+
+	post := func(event, secret string, body []byte) *http.Request {
+		r := httptest.NewRequest("POST", "/api/public/webhooks/x", nil)
+		r.Header.Set("X-GitHub-Event", event)
+		r.Header.Set("X-Hub-Signature-256", "sha256="+sign(sha256.New, secret, body))
+		return r
+	}
+	body := func(repo string) []byte {
+		return []byte(`{"repository":{"full_name":"` + repo + `"}}`)
+	}
+
+	if _, err := Webhook(post("push", "forged", body("documize/community")), body("documize/community"), "s3cret"); err != provider.ErrWebhookSignature {
+		t.Errorf("expected a forged signature to be refused, got %v", err)
+	}
+
+	match, err := Webhook(post("push", "s3cret", body("Documize/Community")), body("Documize/Community"), "s3cret")
+	if err != nil || match == nil || !match(config) {
+		t.Errorf("expected the section to match, got %v", err)
+	}
+
+	match, _ = Webhook(post("issues", "s3cret", body("documize/site")), body("documize/site"), "s3cret")
+	if match == nil || match(config) {
+		t.Error("expected a repository that is not included not to match")
+	}
+
+	match, err = Webhook(post("ping", "s3cret", body("documize/community")), body("documize/community"), "s3cret")
+	if err != nil || match != nil {
+		t.Errorf("expected other events to be ignored, got %v", err)
+	}
+}
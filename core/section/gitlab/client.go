@@ -0,0 +1,117 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var errForbidden = errors.New("forbidden")
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// client calls the GitLab REST API of a single instance.
+type client struct {
+	base  string // such as https://gitlab.example.com
+	token string
+}
+
+func newClient(config *gitlabConfig) *client {
+	return &client{base: config.URL, token: config.Token}
+}
+
+// projectPath returns the API path of a project.
+func projectPath(id int) string {
+	return "/projects/" + strconv.Itoa(id)
+}
+
+// get decodes a single page of results, returning the number of the next page or zero on the last.
+func (c *client) get(path string, params url.Values, v interface{}) (next int, err error) {
+	if len(c.base) == 0 || len(c.token) == 0 {
+		return 0, errors.New("missing GitLab URL or access token")
+	}
+
+	u := c.base + "/api/v4" + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return 0, errForbidden
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("error: HTTP status code %d", res.StatusCode)
+	}
+
+	next, _ = strconv.Atoi(res.Header.Get("X-Next-Page"))
+
+	return next, json.NewDecoder(res.Body).Decode(v)
+}
+
+// user returns the owner of the access token.
+func (c *client) user() (u apiUser, err error) {
+	_, err = c.get("/user", nil, &u)
+	return
+}
+
+// projects returns the projects the token owner is a member of.
+func (c *client) projects() (projects []gitlabProject, err error) {
+	params := url.Values{}
+	params.Set("membership", "true")
+	params.Set("simple", "true")
+	params.Set("order_by", "path")
+	params.Set("sort", "asc")
+	params.Set("per_page", "100")
+
+	projects = []gitlabProject{}
+	for {
+		var page []apiProject
+		next, err := c.get("/projects", params, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range page {
+			projects = append(projects, gitlabProject{
+				ID:     p.ID,
+				Name:   p.Name,
+				Path:   p.PathWithNamespace,
+				URL:    p.WebURL,
+				Branch: p.DefaultBranch,
+			})
+		}
+
+		if next == 0 {
+			return projects, nil
+		}
+		params.Set("page", strconv.Itoa(next))
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"html/template"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type gitlabCommit struct {
+	ID      string       `json:"id"`
+	Message string       `json:"message"`
+	URL     template.URL `json:"url"`
+	Project string       `json:"project"`
+	Branch  string       `json:"branch"`
+	Author  string       `json:"author"`
+	Date    string       `json:"date"`
+	BinDate time.Time    `json:"-"` // only used for sorting
+}
+
+// order commits by project and branch, most recent first.
+type orderCommits []gitlabCommit
+
+func (s orderCommits) Len() int      { return len(s) }
+func (s orderCommits) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s orderCommits) Less(i, j int) bool {
+	if s[i].Project != s[j].Project {
+		return s[i].Project < s[j].Project
+	}
+	if s[i].Branch != s[j].Branch {
+		return s[i].Branch < s[j].Branch
+	}
+	return s[i].BinDate.After(s[j].BinDate)
+}
+
+const tagCommitsData = "commitsData"
+
+func init() {
+	reports[tagCommitsData] = report{refreshCommits, renderCommits, commitsTemplate}
+}
+
+func getCommits(c *client, config *gitlabConfig) ([]gitlabCommit, error) {
+	ret := []gitlabCommit{}
+
+	for _, p := range config.included() {
+		params := url.Values{}
+		params.Set("since", config.SincePtr.Format(time.RFC3339))
+		params.Set("per_page", strconv.Itoa(maxItems))
+		if len(p.Branch) > 0 {
+			params.Set("ref_name", p.Branch)
+		}
+
+		var commits []apiCommit
+		if _, err := c.get(projectPath(p.ID)+"/repository/commits", params, &commits); err != nil {
+			return ret, err
+		}
+
+		for _, v := range commits {
+			ret = append(ret, gitlabCommit{
+				ID:      v.ShortID,
+				Message: v.Title,
+				URL:     template.URL(v.WebURL),
+				Project: p.Name,
+				Branch:  p.Branch,
+				Author:  v.AuthorName,
+				Date:    v.CommittedAt.Format(timeFormat),
+				BinDate: v.CommittedAt,
+			})
+		}
+	}
+
+	sort.Sort(orderCommits(ret))
+
+	return ret, nil
+}
+
+func refreshCommits(gr *gitlabRender, config *gitlabConfig, c *client) (err error) {
+	if !config.ShowCommits {
+		return nil
+	}
+
+	gr.Commits, err = getCommits(c, config)
+	if err != nil {
+		return err
+	}
+	gr.HasCommits = len(gr.Commits) > 0
+
+	return nil
+}
+
+func renderCommits(payload *gitlabRender, c *gitlabConfig) error {
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+const commitsTemplate = `
+<div class="section-gitlab-render">
+{{if .HasCommits}}
+	<table class="gitlab-table" style="width: 100%;">
+		<thead>
+			<tr>
+				<th class="title">Commits <span>&middot; {{len .Commits}} commits</span></th>
+				<th></th>
+			</tr>
+		</thead>
+		<tbody>
+			{{range $commit := .Commits}}
+				<tr>
+					<td>
+						<a href="{{$commit.URL}}">{{$commit.Message}}</a>
+						<span class="data">{{$commit.ID}} &middot; {{$commit.Project}}{{if $commit.Branch}}:{{$commit.Branch}}{{end}}</span>
+					</td>
+					<td class="right-column">
+						<span class="meta-creator">{{$commit.Author}}</span> &middot; <span class="meta-date">{{$commit.Date}}</span>
+					</td>
+				</tr>
+			{{end}}
+		</tbody>
+	</table>
+{{end}}
+</div>
+`
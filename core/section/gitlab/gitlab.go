@@ -0,0 +1,223 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+)
+
+const me = "gitlab"
+
+// Provider represents GitLab
+type Provider struct {
+}
+
+// Meta describes us.
+func (*Provider) Meta() provider.TypeMeta {
+	section := provider.TypeMeta{}
+
+	section.ID = "a7a4ac62-f1c4-4bd3-a7e6-3d1f9f5b1b0c"
+	section.Title = "GitLab"
+	section.Description = "Merge requests, commits, issues and milestones"
+	section.ContentType = "gitlab"
+	section.PageType = "tab"
+	section.Webhook = Webhook
+
+	return section
+}
+
+// Command handles authentication, listing projects and fetching report content.
+func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var config = gitlabConfig{}
+	err = json.Unmarshal(body, &config)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad config")
+		return
+	}
+
+	config.Clean()
+
+	typed := config.Token != provider.SecretReplacement && len(config.Token) > 0
+	if !typed {
+		var s secrets
+		ctx.UnmarshalSecrets(&s) // ignore error, there are none until authenticated
+		if len(config.URL) == 0 {
+			config.URL = s.URL
+		}
+		// only send the saved token to the server it was saved for
+		config.Token = ""
+		if config.URL == s.URL {
+			config.Token = s.Token
+		}
+	}
+
+	if len(config.URL) == 0 || len(config.Token) == 0 {
+		provider.WriteMessage(w, me, "Missing GitLab URL or access token")
+		return
+	}
+
+	c := newClient(&config)
+	var result interface{}
+
+	switch method {
+	case "auth", "projects":
+		if _, err = c.user(); err == nil {
+			result, err = c.projects()
+		}
+	case "content":
+		result, err = refreshReportData(&config, c)
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+		return
+	}
+
+	if err == errForbidden {
+		log.IfErr(ctx.MarshalSecrets(secrets{URL: config.URL})) // invalid token, so reset it
+		provider.WriteForbidden(w)
+		return
+	}
+
+	if err != nil {
+		log.Error("gitlab command "+method, err)
+		provider.WriteError(w, me, err)
+		return
+	}
+
+	// the token has just worked, so save it as our secret along with the server it works for
+	if typed {
+		log.IfErr(ctx.MarshalSecrets(secrets{URL: config.URL, Token: config.Token}))
+	}
+
+	provider.WriteJSON(w, result)
+}
+
+// Refresh fetches the latest data for the chosen reports.
+func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
+	var c = gitlabConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil {
+		log.Error("unable to unmarshall gitlab config", err)
+		return data
+	}
+
+	c.Clean()
+
+	var s secrets
+	ctx.UnmarshalSecrets(&s) // ignore error, the refresh fails below without them
+	c.URL, c.Token = s.URL, s.Token
+
+	gr, err := refreshReportData(&c, newClient(&c))
+	if err != nil {
+		log.Error("gitlab refresh: unable to fetch data", err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	j, err := json.Marshal(gr)
+	if err != nil {
+		log.Error("unable to marshall gitlab data", err)
+		return data
+	}
+
+	return string(j)
+}
+
+func refreshReportData(config *gitlabConfig, c *client) (*gitlabRender, error) {
+	var gr = gitlabRender{}
+	for _, repID := range config.ReportOrder {
+		if err := reports[repID].refresh(&gr, config, c); err != nil {
+			return nil, err
+		}
+	}
+	return &gr, nil
+}
+
+// Render returns the chosen reports as HTML.
+func (*Provider) Render(ctx *provider.Context, config, data string) string {
+	var c = gitlabConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil {
+		log.Error("unable to unmarshall gitlab config", err)
+		return "Please delete and recreate this GitLab section."
+	}
+
+	c.Clean()
+
+	data = strings.TrimSpace(data)
+	if len(data) == 0 {
+		return ""
+	}
+
+	payload := gitlabRender{}
+	err = json.Unmarshal([]byte(data), &payload)
+
+	if err != nil {
+		log.Error("unable to unmarshall gitlab data", err)
+		return "Please delete and recreate this GitLab section."
+	}
+
+	c.Token = "" // never part of the page
+	payload.Config = c
+
+	ret := ""
+	for _, repID := range c.ReportOrder {
+		rep := reports[repID]
+
+		if err = rep.render(&payload, &c); err != nil {
+			log.Error("unable to render gitlab "+repID, err)
+			return "Documize internal gitlab render " + repID + " error: " + err.Error()
+		}
+
+		t, err := template.New("gitlab").Parse(rep.template)
+		if err != nil {
+			log.Error("gitlab render template.Parse error:", err)
+			return "Documize internal gitlab template.Parse error: " + err.Error()
+		}
+
+		buffer := new(bytes.Buffer)
+		if err = t.Execute(buffer, payload); err != nil {
+			log.Error("gitlab render template.Execute error:", err)
+			return "Documize internal gitlab template.Execute error: " + err.Error()
+		}
+
+		ret += buffer.String()
+	}
+
+	return ret
+}
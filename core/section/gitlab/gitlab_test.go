@@ -0,0 +1,181 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// standIn serves the parts of the GitLab API used by the section.
+func standIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/v4/user":
+			w.Write([]byte(`{"id":1,"username":"jane","name":"Jane Doe"}`))
+		case "/api/v4/projects":
+			if q.Get("page") == "" {
+				w.Header().Set("X-Next-Page", "2")
+				w.Write([]byte(`[{"id":7,"name":"api","path_with_namespace":"ops/api","web_url":"https://git.example.com/ops/api","default_branch":"main"}]`))
+			} else {
+				w.Write([]byte(`[{"id":8,"name":"web","path_with_namespace":"ops/web","web_url":"https://git.example.com/ops/web","default_branch":"master"}]`))
+			}
+		case "/api/v4/projects/7/merge_requests":
+			if q.Get("state") != "all" || q.Get("updated_after") == "" {
+				t.Errorf("unexpected merge request query %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[
+				{"iid":3,"title":"Add <b>metrics</b>","state":"merged","web_url":"https://git.example.com/ops/api/-/merge_requests/3","source_branch":"metrics","target_branch":"main","author":{"name":"Jane Doe"},"updated_at":"2016-05-02T10:00:00Z"},
+				{"iid":4,"title":"Fix login","state":"opened","web_url":"https://git.example.com/ops/api/-/merge_requests/4","source_branch":"login","target_branch":"main","author":{"name":"Sam"},"milestone":{"title":"v1.0"},"updated_at":"2016-05-01T10:00:00Z"}
+			]`))
+		case "/api/v4/projects/7/repository/commits":
+			if q.Get("ref_name") != "main" {
+				t.Errorf("branch not requested: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"short_id":"abc123","title":"Tidy up","author_name":"Jane Doe","committed_date":"2016-05-02T09:00:00Z","web_url":"https://git.example.com/ops/api/-/commit/abc123"}]`))
+		case "/api/v4/projects/7/issues":
+			if q.Get("state") == "opened" {
+				w.Write([]byte(`[{"iid":12,"title":"Slow search","state":"opened","web_url":"https://git.example.com/ops/api/-/issues/12","labels":["bug"],"author":{"name":"Sam"},"milestone":{"title":"v1.0"},"updated_at":"2016-05-02T10:00:00Z"}]`))
+			} else {
+				w.Write([]byte(`[{"iid":9,"title":"Crash","state":"closed","web_url":"https://git.example.com/ops/api/-/issues/9","assignee":{"name":"Jane Doe"},"milestone":{"title":"v1.0"},"updated_at":"2016-05-01T10:00:00Z"}]`))
+			}
+		case "/api/v4/projects/7/milestones":
+			w.Write([]byte(`[{"id":70,"title":"v1.0","state":"active","due_date":"2016-06-01","web_url":"https://git.example.com/ops/api/-/milestones/1","updated_at":"2016-05-01T10:00:00Z"}]`))
+		case "/api/v4/projects/7/milestones/70/issues":
+			w.Write([]byte(`[{"iid":12,"state":"opened"},{"iid":9,"state":"closed"},{"iid":5,"state":"closed"},{"iid":2,"state":"closed"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestProjects(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	config := gitlabConfig{URL: s.URL + "/", Token: "glpat-123"}
+	config.Clean()
+	c := newClient(&config)
+
+	u, err := c.user()
+	if err != nil || u.Username != "jane" {
+		t.Fatalf("user not read: %+v %v", u, err)
+	}
+
+	projects, err := c.projects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 || projects[1].Path != "ops/web" || projects[0].Branch != "main" {
+		t.Errorf("projects not paged: %+v", projects)
+	}
+
+	c.token = "wrong"
+	if _, err = c.user(); err != errForbidden {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+}
+
+func TestRefreshAndRender(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	config := gitlabConfig{
+		URL:               s.URL,
+		Token:             "glpat-123",
+		Since:             "2016/04/01",
+		Projects:          []gitlabProject{{ID: 8, Path: "ops/web", Name: "web"}, {ID: 7, Path: "ops/api", Name: "api", URL: "https://git.example.com/ops/api", Branch: "main", Included: true}},
+		ShowMergeRequests: true,
+		ShowCommits:       true,
+		ShowIssues:        true,
+		ShowMilestones:    true,
+	}
+	config.Clean()
+
+	gr, err := refreshReportData(&config, newClient(&config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gr.OpenMRs != 1 || gr.MergedMRs != 1 || gr.MergeRequests[0].ID != 4 {
+		t.Errorf("merge requests not read: %+v", gr.MergeRequests)
+	}
+	if gr.OpenIssues != 1 || gr.ClosedIssues != 1 || gr.Issues[0].Assignee != unassignedIssue {
+		t.Errorf("issues not read: %+v", gr.Issues)
+	}
+	if len(gr.Milestones) != 1 || gr.Milestones[0].Progress != 75 {
+		t.Errorf("milestones not read: %+v", gr.Milestones)
+	}
+
+	data, _ := json.Marshal(gr)
+	cfg, _ := json.Marshal(config)
+
+	html := (&Provider{}).Render(nil, string(cfg), string(data))
+	for _, want := range []string{
+		`<a class="link" href="https://git.example.com/ops/api">ops/api (main)</a>`,
+		`Merge Requests <span>&middot; 1 open, 1 merged and 0 closed</span>`,
+		`<a href="https://git.example.com/ops/api/-/merge_requests/3">Add &lt;b&gt;metrics&lt;/b&gt;</a>`,
+		`metrics → main`,
+		`<a href="https://git.example.com/ops/api/-/commit/abc123">Tidy up</a>`,
+		`<span class="issue-label">bug</span>`,
+		`<span class="bold color-off-black">75%</span> complete`,
+		`due June 1 2016`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in %s", want, html)
+		}
+	}
+	if strings.Contains(html, "ops/web") || strings.Contains(html, "glpat") {
+		t.Error("unexpected project or token rendered")
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	config := `{"projects":[{"id":7,"path":"ops/api","included":true},{"id":8,"path":"ops/web","included":false}]}`
+
+	post := func(token, event string) *http.Request {
+		r := httptest.NewRequest("POST", "/api/public/webhooks/x", nil)
+		r.Header.Set("X-Gitlab-Token", token)
+		r.Header.Set("X-Gitlab-Event", event)
+		return r
+	}
+	body := func(project string) []byte {
+		return []byte(`{"object_kind":"push","project":{"path_with_namespace":"` + project + `"}}`)
+	}
+
+	if _, err := Webhook(post("wrong", "Push Hook"), body("ops/api"), "s3cret"); err == nil {
+		t.Error("expected a bad token to be refused")
+	}
+
+	match, err := Webhook(post("s3cret", "Push Hook"), body("OPS/api"), "s3cret")
+	if err != nil || match == nil || !match(config) {
+		t.Errorf("expected the section to match, got %v", err)
+	}
+
+	match, _ = Webhook(post("s3cret", "Issue Hook"), body("ops/web"), "s3cret")
+	if match == nil || match(config) {
+		t.Error("expected a project that is not included not to match")
+	}
+
+	match, err = Webhook(post("s3cret", "Wiki Page Hook"), body("ops/api"), "s3cret")
+	if err != nil || match != nil {
+		t.Errorf("expected other events to be ignored, got %v", err)
+	}
+}
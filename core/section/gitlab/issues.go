@@ -0,0 +1,139 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"html/template"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type gitlabIssue struct {
+	ID        int          `json:"id"`
+	Title     string       `json:"title"`
+	URL       template.URL `json:"url"`
+	IsOpen    bool         `json:"isopen"`
+	Project   string       `json:"project"`
+	Labels    []string     `json:"labels"`
+	Creator   string       `json:"creator"`
+	Assignee  string       `json:"assignee"`
+	Milestone string       `json:"milestone"`
+	Updated   string       `json:"updated"`
+	BinDate   time.Time    `json:"-"` // only used for sorting
+}
+
+// sort issues by milestone, with open issues first and then the most recently updated.
+type issuesToSort []gitlabIssue
+
+func (s issuesToSort) Len() int      { return len(s) }
+func (s issuesToSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s issuesToSort) Less(i, j int) bool {
+	if (s[i].Milestone == noMilestone) != (s[j].Milestone == noMilestone) {
+		return s[j].Milestone == noMilestone
+	}
+	if s[i].Milestone != s[j].Milestone {
+		return s[i].Milestone < s[j].Milestone
+	}
+	if s[i].IsOpen != s[j].IsOpen {
+		return s[i].IsOpen
+	}
+	return s[i].BinDate.After(s[j].BinDate)
+}
+
+const (
+	tagIssuesData   = "issuesData"
+	unassignedIssue = "(unassigned)"
+)
+
+func init() {
+	reports[tagIssuesData] = report{refreshIssues, renderIssues, issuesTemplate}
+}
+
+func getIssues(c *client, config *gitlabConfig) ([]gitlabIssue, error) {
+	ret := []gitlabIssue{}
+
+	for _, p := range config.included() {
+		for _, state := range []string{"opened", "closed"} {
+			params := url.Values{}
+			params.Set("state", state)
+			params.Set("order_by", "updated_at")
+			params.Set("sort", "desc")
+			params.Set("per_page", strconv.Itoa(maxItems))
+			if state == "closed" { // we want all the open ones
+				params.Set("updated_after", config.SincePtr.Format(time.RFC3339))
+			}
+
+			var issues []apiIssue
+			if _, err := c.get(projectPath(p.ID)+"/issues", params, &issues); err != nil {
+				return ret, err
+			}
+
+			for _, v := range issues {
+				issue := gitlabIssue{
+					ID:        v.IID,
+					Title:     v.Title,
+					URL:       template.URL(v.WebURL),
+					IsOpen:    v.State == "opened",
+					Project:   p.Name,
+					Labels:    v.Labels,
+					Assignee:  unassignedIssue,
+					Milestone: noMilestone,
+					Updated:   v.UpdatedAt.Format(timeFormat),
+					BinDate:   v.UpdatedAt,
+				}
+				if v.Author != nil {
+					issue.Creator = v.Author.Name
+				}
+				if v.Assignee != nil {
+					issue.Assignee = v.Assignee.Name
+				}
+				if v.Milestone != nil {
+					issue.Milestone = v.Milestone.Title
+				}
+				ret = append(ret, issue)
+			}
+		}
+	}
+
+	sort.Sort(issuesToSort(ret))
+
+	return ret, nil
+}
+
+func refreshIssues(gr *gitlabRender, config *gitlabConfig, c *client) (err error) {
+	if !config.ShowIssues {
+		return nil
+	}
+
+	gr.Issues, err = getIssues(c, config)
+	if err != nil {
+		return err
+	}
+
+	gr.OpenIssues, gr.ClosedIssues = 0, 0
+	for _, v := range gr.Issues {
+		if v.IsOpen {
+			gr.OpenIssues++
+		} else {
+			gr.ClosedIssues++
+		}
+	}
+	gr.HasIssues = len(gr.Issues) > 0
+
+	return nil
+}
+
+func renderIssues(payload *gitlabRender, c *gitlabConfig) error {
+	return nil
+}
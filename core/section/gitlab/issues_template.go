@@ -0,0 +1,44 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+const issuesTemplate = `
+<div class="section-gitlab-render">
+{{if .HasIssues}}
+	<table class="gitlab-table" style="width: 100%;">
+		<thead>
+			<tr>
+				<th class="title">
+					Issues <span>&middot; {{.ClosedIssues}} closed and {{.OpenIssues}} open</span>
+				</th>
+				<th></th>
+			</tr>
+		</thead>
+		<tbody>
+			{{range $data := .Issues}}
+				<tr>
+					<td>
+						<span class="issue-state issue-{{if $data.IsOpen}}open{{else}}closed{{end}}">{{if $data.IsOpen}}open{{else}}closed{{end}}</span>
+						<a href="{{$data.URL}}">{{$data.Title}}</a> <span class="data">#{{$data.ID}} &middot; {{$data.Project}}</span>
+						{{range $label := $data.Labels}}<span class="issue-label">{{$label}}</span>{{end}}
+					</td>
+					<td class="right-column">
+						<span class="meta-milestone">{{$data.Milestone}}</span> &middot;
+						<span class="meta-assignee">{{$data.Assignee}}</span> &middot; <span class="meta-date">{{$data.Updated}}</span>
+					</td>
+				</tr>
+			{{end}}
+		</tbody>
+	</table>
+{{end}}
+</div>
+`
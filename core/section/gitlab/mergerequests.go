@@ -0,0 +1,128 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"html/template"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type gitlabMergeRequest struct {
+	ID        int          `json:"id"`
+	Title     string       `json:"title"`
+	URL       template.URL `json:"url"`
+	State     string       `json:"state"` // opened, merged or closed
+	IsOpen    bool         `json:"isopen"`
+	IsMerged  bool         `json:"ismerged"`
+	Project   string       `json:"project"`
+	Branches  string       `json:"branches"`
+	Author    string       `json:"author"`
+	Milestone string       `json:"milestone"`
+	Updated   string       `json:"updated"`
+	BinDate   time.Time    `json:"-"` // only used for sorting
+}
+
+// sort merge requests with the open ones first, then by most recently updated.
+type mergeRequestsToSort []gitlabMergeRequest
+
+func (s mergeRequestsToSort) Len() int      { return len(s) }
+func (s mergeRequestsToSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s mergeRequestsToSort) Less(i, j int) bool {
+	if s[i].IsOpen != s[j].IsOpen {
+		return s[i].IsOpen
+	}
+	return s[i].BinDate.After(s[j].BinDate)
+}
+
+const tagMergeRequestsData = "mergeRequestsData"
+
+func init() {
+	reports[tagMergeRequestsData] = report{refreshMergeRequests, renderMergeRequests, mergeRequestsTemplate}
+}
+
+func getMergeRequests(c *client, config *gitlabConfig) ([]gitlabMergeRequest, error) {
+	ret := []gitlabMergeRequest{}
+
+	for _, p := range config.included() {
+		params := url.Values{}
+		params.Set("state", "all")
+		params.Set("order_by", "updated_at")
+		params.Set("sort", "desc")
+		params.Set("updated_after", config.SincePtr.Format(time.RFC3339))
+		params.Set("per_page", strconv.Itoa(maxItems))
+
+		var mrs []apiMergeRequest
+		if _, err := c.get(projectPath(p.ID)+"/merge_requests", params, &mrs); err != nil {
+			return ret, err
+		}
+
+		for _, v := range mrs {
+			mr := gitlabMergeRequest{
+				ID:        v.IID,
+				Title:     v.Title,
+				URL:       template.URL(v.WebURL),
+				State:     v.State,
+				IsOpen:    v.State == "opened",
+				IsMerged:  v.State == "merged",
+				Project:   p.Name,
+				Branches:  v.SourceBranch + " → " + v.TargetBranch,
+				Milestone: noMilestone,
+				Updated:   v.UpdatedAt.Format(timeFormat),
+				BinDate:   v.UpdatedAt,
+			}
+			if v.Author != nil {
+				mr.Author = v.Author.Name
+			}
+			if v.Milestone != nil {
+				mr.Milestone = v.Milestone.Title
+			}
+			ret = append(ret, mr)
+		}
+	}
+
+	sort.Sort(mergeRequestsToSort(ret))
+
+	return ret, nil
+}
+
+func refreshMergeRequests(gr *gitlabRender, config *gitlabConfig, c *client) (err error) {
+	if !config.ShowMergeRequests {
+		return nil
+	}
+
+	gr.MergeRequests, err = getMergeRequests(c, config)
+	if err != nil {
+		return err
+	}
+
+	gr.OpenMRs, gr.MergedMRs, gr.ClosedMRs = 0, 0, 0
+	for _, mr := range gr.MergeRequests {
+		switch {
+		case mr.IsOpen:
+			gr.OpenMRs++
+		case mr.IsMerged:
+			gr.MergedMRs++
+		default:
+			gr.ClosedMRs++
+		}
+	}
+	gr.HasMergeRequests = len(gr.MergeRequests) > 0
+
+	return nil
+}
+
+func renderMergeRequests(payload *gitlabRender, c *gitlabConfig) error {
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+const mergeRequestsTemplate = `
+<div class="section-gitlab-render">
+{{if .HasMergeRequests}}
+	<table class="gitlab-table" style="width: 100%;">
+		<thead>
+			<tr>
+				<th class="title">
+					Merge Requests <span>&middot; {{.OpenMRs}} open, {{.MergedMRs}} merged and {{.ClosedMRs}} closed</span>
+				</th>
+				<th></th>
+			</tr>
+		</thead>
+		<tbody>
+			{{range $data := .MergeRequests}}
+				<tr>
+					<td>
+						<span class="mr-state mr-{{$data.State}}">{{$data.State}}</span>
+						<a href="{{$data.URL}}">{{$data.Title}}</a> <span class="data">!{{$data.ID}} &middot; {{$data.Project}} &middot; {{$data.Branches}}</span>
+					</td>
+					<td class="right-column">
+						<span class="meta-milestone">{{$data.Milestone}}</span> &middot;
+						<span class="meta-creator">{{$data.Author}}</span> &middot; <span class="meta-date">{{$data.Updated}}</span>
+					</td>
+				</tr>
+			{{end}}
+		</tbody>
+	</table>
+{{end}}
+</div>
+`
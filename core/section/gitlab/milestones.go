@@ -0,0 +1,144 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type gitlabMilestone struct {
+	Name         string       `json:"name"`
+	URL          template.URL `json:"url"`
+	Project      string       `json:"project"`
+	IsOpen       bool         `json:"isopen"`
+	OpenIssues   int          `json:"openIssues"`
+	ClosedIssues int          `json:"closedIssues"`
+	CompleteMsg  string       `json:"completeMsg"`
+	DueDate      string       `json:"dueDate"`
+	Progress     uint         `json:"progress"`
+}
+
+// sort milestones by project, open ones first, then the most complete.
+type milestonesToSort []gitlabMilestone
+
+func (s milestonesToSort) Len() int      { return len(s) }
+func (s milestonesToSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s milestonesToSort) Less(i, j int) bool {
+	if s[i].Project != s[j].Project {
+		return s[i].Project < s[j].Project
+	}
+	if s[i].IsOpen != s[j].IsOpen {
+		return s[i].IsOpen
+	}
+	if s[i].Progress == s[j].Progress {
+		return s[i].Name < s[j].Name
+	}
+	return s[i].Progress > s[j].Progress
+}
+
+const (
+	tagMilestonesData    = "milestonesData"
+	milestonesTimeFormat = "January 2 2006"
+	noMilestone          = "no milestone"
+)
+
+func init() {
+	reports[tagMilestonesData] = report{refreshMilestones, renderMilestones, milestonesTemplate}
+}
+
+func getMilestones(c *client, config *gitlabConfig) ([]gitlabMilestone, error) {
+	ret := []gitlabMilestone{}
+
+	for _, p := range config.included() {
+		params := url.Values{}
+		params.Set("per_page", strconv.Itoa(maxItems))
+
+		var milestones []apiMilestone
+		if _, err := c.get(projectPath(p.ID)+"/milestones", params, &milestones); err != nil {
+			return ret, err
+		}
+
+		for _, v := range milestones {
+			isOpen := v.State == "active"
+			if !isOpen && v.UpdatedAt.Before(*config.SincePtr) {
+				continue // closed before the reporting period
+			}
+
+			var issues []apiIssue
+			issueParams := url.Values{}
+			issueParams.Set("per_page", "100")
+			if _, err := c.get(projectPath(p.ID)+"/milestones/"+strconv.Itoa(v.ID)+"/issues", issueParams, &issues); err != nil {
+				return ret, err
+			}
+
+			ms := gitlabMilestone{
+				Name:    v.Title,
+				URL:     template.URL(v.WebURL),
+				Project: p.Name,
+				IsOpen:  isOpen,
+				DueDate: "no due date",
+			}
+			if due, err := time.Parse("2006-01-02", v.DueDate); err == nil {
+				ms.DueDate = "due " + due.Format(milestonesTimeFormat)
+			}
+			for _, i := range issues {
+				if i.State == "opened" {
+					ms.OpenIssues++
+				} else {
+					ms.ClosedIssues++
+				}
+			}
+			if total := ms.OpenIssues + ms.ClosedIssues; total > 0 {
+				ms.Progress = uint(ms.ClosedIssues * 100 / total)
+			}
+			ms.CompleteMsg = fmt.Sprintf("%d%%", ms.Progress)
+
+			ret = append(ret, ms)
+		}
+	}
+
+	sort.Sort(milestonesToSort(ret))
+
+	return ret, nil
+}
+
+func refreshMilestones(gr *gitlabRender, config *gitlabConfig, c *client) (err error) {
+	if !config.ShowMilestones {
+		return nil
+	}
+
+	gr.Milestones, err = getMilestones(c, config)
+	if err != nil {
+		return err
+	}
+
+	gr.OpenMS, gr.ClosedMS = 0, 0
+	for _, v := range gr.Milestones {
+		if v.IsOpen {
+			gr.OpenMS++
+		} else {
+			gr.ClosedMS++
+		}
+	}
+	gr.HasMilestones = len(gr.Milestones) > 0
+
+	return nil
+}
+
+func renderMilestones(payload *gitlabRender, c *gitlabConfig) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+const milestonesTemplate = `
+<div class="section-gitlab-render">
+{{if .HasMilestones}}
+	<table class="gitlab-table" style="width: 100%;">
+		<thead>
+			<tr>
+				<th class="title">Milestones <span>&middot; {{.ClosedMS}} closed and {{.OpenMS}} open</span></th>
+				<th></th>
+			</tr>
+		</thead>
+		<tbody>
+			{{range $data := .Milestones}}
+				<tr>
+					<td>
+						<span class="issue-state issue-{{if $data.IsOpen}}open{{else}}closed{{end}}">{{if $data.IsOpen}}open{{else}}closed{{end}}</span>
+						<a class="link" href="{{$data.URL}}">{{$data.Name}}</a>
+						<span class="data"> &middot; {{$data.Project}} &middot; {{$data.DueDate}}</span>
+					</td>
+					<td class="right-column">
+						<span class="bold color-off-black">{{$data.CompleteMsg}}</span> complete
+						<span class="bold color-off-black">{{$data.OpenIssues}}</span> open
+						<span class="bold color-off-black">{{$data.ClosedIssues}}</span> closed
+						<div class="progress-bar">
+							<div class="progress" style="width:{{$data.Progress}}%;"></div>
+						</div>
+					</td>
+				</tr>
+			{{end}}
+		</tbody>
+	</table>
+{{end}}
+</div>
+`
@@ -0,0 +1,207 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	timeFormat = "January 2 2006, 15:04"
+	maxItems   = 100 // per project, for each report
+)
+
+type gitlabRender struct {
+	Config           gitlabConfig         `json:"config"`
+	MergeRequests    []gitlabMergeRequest `json:"mergeRequests"`
+	HasMergeRequests bool                 `json:"hasMergeRequests"`
+	OpenMRs          int                  `json:"openMRs"`
+	MergedMRs        int                  `json:"mergedMRs"`
+	ClosedMRs        int                  `json:"closedMRs"`
+	Commits          []gitlabCommit       `json:"commits"`
+	HasCommits       bool                 `json:"hasCommits"`
+	Issues           []gitlabIssue        `json:"issues"`
+	HasIssues        bool                 `json:"hasIssues"`
+	OpenIssues       int                  `json:"openIssues"`
+	ClosedIssues     int                  `json:"closedIssues"`
+	Milestones       []gitlabMilestone    `json:"milestones"`
+	HasMilestones    bool                 `json:"hasMilestones"`
+	OpenMS           int                  `json:"openMS"`
+	ClosedMS         int                  `json:"closedMS"`
+}
+
+type report struct {
+	refresh  func(*gitlabRender, *gitlabConfig, *client) error
+	render   func(*gitlabRender, *gitlabConfig) error
+	template string
+}
+
+var reports = make(map[string]report)
+
+type secrets struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+type gitlabProject struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"` // namespace and project, such as group/project
+	URL      string `json:"url"`
+	Branch   string `json:"branch"`
+	Included bool   `json:"included"`
+	Comma    bool   `json:"comma"`
+}
+
+type gitlabConfig struct {
+	Token             string          `json:"token"` // only contains the correct token just after it is typed in
+	URL               string          `json:"url"`
+	Since             string          `json:"since,omitempty"` // yyyy/mm/dd
+	SincePtr          *time.Time      `json:"-"`
+	DateMessage       string          `json:"-"`
+	Projects          []gitlabProject `json:"projects,omitempty"`
+	ShowMergeRequests bool            `json:"showMergeRequests,omitempty"`
+	ShowCommits       bool            `json:"showCommits,omitempty"`
+	ShowIssues        bool            `json:"showIssues,omitempty"`
+	ShowMilestones    bool            `json:"showMilestones,omitempty"`
+	ReportOrder       []string        `json:"-"`
+	SinceDisplay      string          `json:"-"`
+}
+
+func (c *gitlabConfig) Clean() {
+	c.Token = strings.TrimSpace(c.Token)
+	c.URL = strings.TrimRight(strings.TrimSpace(c.URL), "/")
+	if len(c.URL) > 0 && !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		c.URL = "https://" + c.URL
+	}
+
+	c.SincePtr = nil
+	if since, err := time.Parse("2006/01/02", strings.TrimSpace(c.Since)); err == nil {
+		c.SincePtr = &since
+		c.DateMessage = ""
+	} else {
+		since := time.Now().AddDate(0, 0, -7)
+		c.SincePtr = &since
+		c.DateMessage = " (the last 7 days)"
+	}
+	c.SinceDisplay = c.SincePtr.Format(timeFormat)
+
+	c.ReportOrder = []string{tagSummaryData}
+	if c.ShowMilestones {
+		c.ReportOrder = append(c.ReportOrder, tagMilestonesData)
+	}
+	if c.ShowMergeRequests {
+		c.ReportOrder = append(c.ReportOrder, tagMergeRequestsData)
+	}
+	if c.ShowIssues {
+		c.ReportOrder = append(c.ReportOrder, tagIssuesData)
+	}
+	if c.ShowCommits {
+		c.ReportOrder = append(c.ReportOrder, tagCommitsData)
+	}
+
+	sort.Sort(projectsToSort(c.Projects))
+
+	lastItem := -1
+	for i := range c.Projects {
+		c.Projects[i].Comma = true
+		if c.Projects[i].Included {
+			lastItem = i
+		}
+	}
+	if lastItem >= 0 {
+		c.Projects[lastItem].Comma = false
+	}
+}
+
+// included returns the projects chosen for the section.
+func (c *gitlabConfig) included() (projects []gitlabProject) {
+	for _, p := range c.Projects {
+		if p.Included {
+			projects = append(projects, p)
+		}
+	}
+	return
+}
+
+// sort projects in the order they should be presented.
+type projectsToSort []gitlabProject
+
+func (s projectsToSort) Len() int      { return len(s) }
+func (s projectsToSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s projectsToSort) Less(i, j int) bool {
+	return s[i].Path < s[j].Path
+}
+
+// API types, holding only the fields we use.
+
+type apiUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type apiProject struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+type apiMilestoneRef struct {
+	Title string `json:"title"`
+}
+
+type apiMergeRequest struct {
+	IID          int              `json:"iid"`
+	Title        string           `json:"title"`
+	State        string           `json:"state"`
+	WebURL       string           `json:"web_url"`
+	SourceBranch string           `json:"source_branch"`
+	TargetBranch string           `json:"target_branch"`
+	Author       *apiUser         `json:"author"`
+	Milestone    *apiMilestoneRef `json:"milestone"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+type apiCommit struct {
+	ShortID     string    `json:"short_id"`
+	Title       string    `json:"title"`
+	AuthorName  string    `json:"author_name"`
+	AuthoredAt  time.Time `json:"authored_date"`
+	WebURL      string    `json:"web_url"`
+	CommittedAt time.Time `json:"committed_date"`
+}
+
+type apiIssue struct {
+	IID       int              `json:"iid"`
+	Title     string           `json:"title"`
+	State     string           `json:"state"`
+	WebURL    string           `json:"web_url"`
+	Labels    []string         `json:"labels"`
+	Author    *apiUser         `json:"author"`
+	Assignee  *apiUser         `json:"assignee"`
+	Milestone *apiMilestoneRef `json:"milestone"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+type apiMilestone struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	DueDate   string    `json:"due_date"`
+	WebURL    string    `json:"web_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+const tagSummaryData = "summaryData"
+
+func init() {
+	reports[tagSummaryData] = report{refreshSummary, renderSummary, summaryTemplate}
+}
+
+func refreshSummary(gr *gitlabRender, config *gitlabConfig, c *client) (err error) {
+	return nil
+}
+
+func renderSummary(payload *gitlabRender, c *gitlabConfig) error {
+	return nil
+}
@@ -0,0 +1,24 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+const summaryTemplate = `
+<div class="section-gitlab-render">
+	<p>Activity since {{.Config.SinceDisplay}}{{.Config.DateMessage}} for
+		{{range $data := .Config.Projects}}
+			{{if $data.Included}}
+				<a class="link" href="{{$data.URL}}">{{$data.Path}}{{if $data.Branch}} ({{$data.Branch}}){{end}}{{if $data.Comma}},{{end}}</a>
+			{{end}}
+		{{end}}
+	</p>
+</div>
+`
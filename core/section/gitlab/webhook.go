@@ -0,0 +1,68 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package gitlab
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+// webhookEvents are those that change what a section shows, others are acknowledged and ignored.
+var webhookEvents = map[string]bool{"Push Hook": true, "Tag Push Hook": true, "Issue Hook": true, "Merge Request Hook": true}
+
+// Webhook handles an event GitLab posts for a project, which sends the secret in the X-Gitlab-Token header,
+// and matches the sections that report on that project.
+func Webhook(r *http.Request, body []byte, secret string) (provider.WebhookMatch, error) {
+	token := r.Header.Get("X-Gitlab-Token")
+
+	if len(secret) == 0 || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return nil, provider.ErrWebhookSignature
+	}
+
+	if !webhookEvents[r.Header.Get("X-Gitlab-Event")] {
+		return nil, nil
+	}
+
+	var event struct {
+		Project struct {
+			Path string `json:"path_with_namespace"`
+		} `json:"project"`
+	}
+
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+
+	path := event.Project.Path
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	return func(config string) bool {
+		var c gitlabConfig
+		if json.Unmarshal([]byte(config), &c) != nil {
+			return false
+		}
+
+		for _, p := range c.Projects {
+			if p.Included && strings.EqualFold(p.Path, path) {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
@@ -0,0 +1,344 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+)
+
+const me = "jira"
+
+// maxIssues caps the number of issues held by a section.
+const maxIssues = 200
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+var errForbidden = errors.New("forbidden")
+
+// Provider represents Jira
+type Provider struct {
+}
+
+// Meta describes us
+func (*Provider) Meta() provider.TypeMeta {
+	section := provider.TypeMeta{}
+
+	section.ID = "5a2ad4d8-3b9c-4bcf-a36c-2f6a4d3e8b51"
+	section.Title = "Jira"
+	section.Description = "Issues found by a JQL query"
+	section.ContentType = "jira"
+	section.PageType = "tab"
+
+	return section
+}
+
+// Command handles authentication and running the JQL query.
+func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var config = jiraConfig{}
+	err = json.Unmarshal(body, &config)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad config")
+		return
+	}
+
+	config.Clean()
+
+	typed := config.Token != provider.SecretReplacement && len(config.Token) > 0
+	if !typed {
+		var s secrets
+		ctx.UnmarshalSecrets(&s) // ignore error, there are none until authenticated
+		if len(config.URL) == 0 {
+			config.URL, config.Username = s.URL, s.Username
+		}
+		// only send the saved credentials to the server they were saved for
+		config.Token = ""
+		if config.URL == s.URL && config.Username == s.Username {
+			config.Token = s.Token
+		}
+	}
+
+	if len(config.URL) == 0 || len(config.Username) == 0 || len(config.Token) == 0 {
+		provider.WriteMessage(w, me, "Missing Jira URL or credentials")
+		return
+	}
+
+	var result interface{}
+
+	switch method {
+	case "auth":
+		var user apiName
+		err = get(config, "/rest/api/2/myself", nil, &user)
+		result = user
+	case "issues":
+		result, err = search(config)
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+		return
+	}
+
+	if err == errForbidden {
+		log.IfErr(ctx.SaveSecrets("")) // invalid credentials, so reset them
+		provider.WriteForbidden(w)
+		return
+	}
+
+	if err != nil {
+		provider.WriteError(w, me, err)
+		return
+	}
+
+	// the credentials have just worked, so save them as our secret
+	if typed {
+		log.IfErr(ctx.MarshalSecrets(secrets{URL: config.URL, Username: config.Username, Token: config.Token}))
+	}
+
+	provider.WriteJSON(w, result)
+}
+
+// Render converts the issues into an HTML table.
+func (*Provider) Render(ctx *provider.Context, config, data string) string {
+	var c = jiraConfig{}
+	var d = jiraData{}
+
+	json.Unmarshal([]byte(config), &c)
+	json.Unmarshal([]byte(data), &d)
+
+	c.Clean()
+
+	return render(c, d)
+}
+
+// Refresh runs the JQL query again.
+func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
+	var c = jiraConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil || len(c.JQL) == 0 {
+		return data
+	}
+
+	c.Clean()
+
+	var s secrets
+	if err = ctx.UnmarshalSecrets(&s); err != nil || len(s.Token) == 0 {
+		log.ErrorString("jira refresh: missing credentials")
+		ctx.RefreshFailed(errors.New("missing credentials"))
+		return data
+	}
+	c.URL, c.Username, c.Token = s.URL, s.Username, s.Token
+
+	result, err := search(c)
+
+	if err != nil {
+		log.Error("jira refresh: unable to run query", err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	j, err := json.Marshal(result)
+
+	if err != nil {
+		log.Error("unable to marshal jira issues", err)
+		return data
+	}
+
+	return string(j)
+}
+
+func render(c jiraConfig, d jiraData) string {
+	payload := jiraRender{Config: c, Total: d.Total}
+
+	for _, id := range c.Columns {
+		col, _ := find(id)
+		payload.Columns = append(payload.Columns, col)
+	}
+
+	issues := d.Issues
+	if len(issues) > c.Max {
+		issues = issues[:c.Max]
+	}
+
+	for _, issue := range issues {
+		row := make([]jiraCell, len(c.Columns))
+		for i, id := range c.Columns {
+			row[i] = cell(issue, id)
+		}
+		payload.Rows = append(payload.Rows, row)
+	}
+
+	payload.Count = len(payload.Rows)
+	payload.HasData = payload.Count > 0
+	if payload.Total < payload.Count {
+		payload.Total = payload.Count
+	}
+
+	t := template.New("jira")
+	t, _ = t.Parse(renderTemplate)
+
+	buffer := new(bytes.Buffer)
+	t.Execute(buffer, payload)
+
+	return buffer.String()
+}
+
+// cell returns the content of a table cell, the key links to the issue.
+func cell(issue jiraIssue, id string) jiraCell {
+	switch id {
+	case "key":
+		return jiraCell{Text: issue.Key, URL: issue.URL}
+	case "summary":
+		return jiraCell{Text: issue.Summary}
+	case "status":
+		return jiraCell{Text: issue.Status}
+	case "assignee":
+		return jiraCell{Text: issue.Assignee}
+	case "fixVersions":
+		return jiraCell{Text: strings.Join(issue.FixVersions, ", ")}
+	case "issuetype":
+		return jiraCell{Text: issue.Type}
+	case "priority":
+		return jiraCell{Text: issue.Priority}
+	case "reporter":
+		return jiraCell{Text: issue.Reporter}
+	case "updated":
+		return jiraCell{Text: issue.Updated}
+	}
+	return jiraCell{}
+}
+
+// search runs the JQL query, reading pages of results until we have enough issues.
+func search(config jiraConfig) (data jiraData, err error) {
+	if len(config.JQL) == 0 {
+		err = errors.New("missing JQL query")
+		return
+	}
+
+	params := url.Values{}
+	params.Set("jql", config.JQL)
+	params.Set("fields", "summary,status,assignee,reporter,fixVersions,issuetype,priority,updated")
+
+	data.Issues = []jiraIssue{}
+
+	for {
+		params.Set("startAt", strconv.Itoa(len(data.Issues)))
+		params.Set("maxResults", strconv.Itoa(config.Max-len(data.Issues)))
+
+		var page apiSearch
+		err = get(config, "/rest/api/2/search", params, &page)
+		if err != nil {
+			return
+		}
+
+		for _, i := range page.Issues {
+			issue := jiraIssue{
+				Key:     i.Key,
+				URL:     config.URL + "/browse/" + url.PathEscape(i.Key),
+				Summary: i.Fields.Summary,
+				Status:  name(i.Fields.Status),
+				Updated: i.Fields.Updated,
+			}
+			issue.Assignee = name(i.Fields.Assignee)
+			issue.Reporter = name(i.Fields.Reporter)
+			issue.Type = name(i.Fields.IssueType)
+			issue.Priority = name(i.Fields.Priority)
+			for _, v := range i.Fields.FixVersions {
+				issue.FixVersions = append(issue.FixVersions, v.Name)
+			}
+			if t, err := time.Parse("2006-01-02T15:04:05.000-0700", i.Fields.Updated); err == nil {
+				issue.Updated = t.Format("2006-01-02")
+			}
+			data.Issues = append(data.Issues, issue)
+		}
+		data.Total = page.Total
+
+		if len(page.Issues) == 0 || len(data.Issues) >= config.Max || len(data.Issues) >= page.Total {
+			break
+		}
+	}
+
+	return
+}
+
+func name(n *apiName) string {
+	if n == nil {
+		return ""
+	}
+	if len(n.DisplayName) > 0 {
+		return n.DisplayName
+	}
+	return n.Name
+}
+
+func get(config jiraConfig, path string, params url.Values, v interface{}) error {
+	u := config.URL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(config.Username, config.Token)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return errForbidden
+	}
+
+	if res.StatusCode != http.StatusOK {
+		// an invalid query is reported with messages worth passing on
+		var e apiErrors
+		if json.NewDecoder(res.Body).Decode(&e) == nil && len(e.ErrorMessages) > 0 {
+			return errors.New(strings.Join(e.ErrorMessages, " "))
+		}
+		return fmt.Errorf("error: HTTP status code %d", res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
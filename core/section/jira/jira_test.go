@@ -0,0 +1,111 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// standIn serves the parts of the Jira REST API used by the section.
+func standIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "jane@example.com" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/rest/api/2/myself":
+			w.Write([]byte(`{"name":"jane","displayName":"Jane Doe"}`))
+		case "/rest/api/2/search":
+			if q.Get("jql") == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errorMessages":["Error in the JQL Query"]}`))
+				return
+			}
+			if q.Get("jql") != "project = DOC ORDER BY key" {
+				t.Errorf("unexpected query %s", q.Get("jql"))
+			}
+			if q.Get("startAt") == "0" {
+				w.Write([]byte(`{"startAt":0,"maxResults":1,"total":3,"issues":[
+					{"key":"DOC-1","fields":{"summary":"<b>Spec</b> review","status":{"name":"Done"},"assignee":{"displayName":"Jane Doe"},"fixVersions":[{"name":"1.0"},{"name":"1.1"}],"updated":"2016-05-01T10:00:00.000+0000"}}
+				]}`))
+			} else {
+				w.Write([]byte(`{"startAt":1,"maxResults":1,"total":3,"issues":[
+					{"key":"DOC-2","fields":{"summary":"Draft","status":{"name":"Open"},"issuetype":{"name":"Task"}}}
+				]}`))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestSearchAndRender(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	c := jiraConfig{URL: s.URL, Username: "jane@example.com", Token: "secret", JQL: "project = DOC ORDER BY key", Max: 2}
+	c.Clean()
+
+	data, err := search(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Issues) != 2 || data.Total != 3 || data.Issues[0].Updated != "2016-05-01" {
+		t.Fatalf("issues not read: %+v", data)
+	}
+
+	j, _ := json.Marshal(data)
+	cfg, _ := json.Marshal(c)
+
+	html := (&Provider{}).Render(nil, string(cfg), string(j))
+	for _, want := range []string{
+		`<th class="bordered">Key</th><th class="bordered">Summary</th><th class="bordered">Status</th><th class="bordered">Assignee</th><th class="bordered">Fix Version</th>`,
+		`<td class="bordered"><a href="` + s.URL + `/browse/DOC-1">DOC-1</a></td><td class="bordered">&lt;b&gt;Spec&lt;/b&gt; review</td><td class="bordered">Done</td><td class="bordered">Jane Doe</td><td class="bordered">1.0, 1.1</td>`,
+		`showing the first 2 of 3`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in %s", want, html)
+		}
+	}
+
+	// chosen columns, in the chosen order
+	c.Columns = []string{"issuetype", "key", "nonsense"}
+	cfg, _ = json.Marshal(c)
+	html = (&Provider{}).Render(nil, string(cfg), string(j))
+	if !strings.Contains(html, `<td class="bordered">Task</td><td class="bordered"><a href="`+s.URL+`/browse/DOC-2">DOC-2</a></td>`) || strings.Contains(html, "Jane") {
+		t.Errorf("columns not chosen: %s", html)
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	c := jiraConfig{URL: s.URL, Username: "jane@example.com", Token: "secret", JQL: "bad"}
+	c.Clean()
+
+	if _, err := search(c); err == nil || err.Error() != "Error in the JQL Query" {
+		t.Errorf("expected the query error, got %v", err)
+	}
+
+	c.Token = "wrong"
+	if _, err := search(c); err != errForbidden {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+}
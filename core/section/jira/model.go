@@ -0,0 +1,171 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package jira
+
+import "strings"
+
+// the HTML that is rendered by this section.
+const renderTemplate = `
+{{if .HasData}}
+<p>{{.Count}} {{if eq 1 .Count}}issue matches{{else}}issues match{{end}} <em>{{.Config.JQL}}</em>{{if gt .Total .Count}}, showing the first {{.Count}} of {{.Total}}{{end}}.</p>
+<table class="basic-table section-jira-table">
+	<thead>
+		<tr>
+			{{range $column := .Columns}}<th class="bordered">{{$column.Title}}</th>{{end}}
+		</tr>
+	</thead>
+	<tbody>
+		{{range $row := .Rows}}
+		<tr>
+			{{range $cell := $row}}<td class="bordered">{{if $cell.URL}}<a href="{{$cell.URL}}">{{$cell.Text}}</a>{{else}}{{$cell.Text}}{{end}}</td>{{end}}
+		</tr>
+		{{end}}
+	</tbody>
+</table>
+{{else}}
+<p>There are no Jira issues matching <em>{{.Config.JQL}}</em>.</p>
+{{end}}
+`
+
+type secrets struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// column is an issue field that can be shown in the table.
+type column struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// columns lists the fields that can be shown, in their default order.
+var columns = []column{
+	{"key", "Key"},
+	{"summary", "Summary"},
+	{"status", "Status"},
+	{"assignee", "Assignee"},
+	{"fixVersions", "Fix Version"},
+	{"issuetype", "Type"},
+	{"priority", "Priority"},
+	{"reporter", "Reporter"},
+	{"updated", "Updated"},
+}
+
+var defaultColumns = []string{"key", "summary", "status", "assignee", "fixVersions"}
+
+type jiraConfig struct {
+	URL      string   `json:"url"`
+	Username string   `json:"username"`
+	Token    string   `json:"token"` // API token or password, only contains the correct value just after it is typed in
+	JQL      string   `json:"jql"`
+	Columns  []string `json:"columns"` // fields to show, in order
+	Max      int      `json:"max"`
+}
+
+func (c *jiraConfig) Clean() {
+	c.URL = strings.TrimRight(strings.TrimSpace(c.URL), "/")
+	if len(c.URL) > 0 && !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+		c.URL = "https://" + c.URL
+	}
+	c.Username = strings.TrimSpace(c.Username)
+	c.Token = strings.TrimSpace(c.Token)
+	c.JQL = strings.TrimSpace(c.JQL)
+
+	if c.Max <= 0 || c.Max > maxIssues {
+		c.Max = maxIssues
+	}
+
+	var chosen []string
+	for _, id := range c.Columns {
+		if _, ok := find(id); ok {
+			chosen = append(chosen, id)
+		}
+	}
+	if len(chosen) == 0 {
+		chosen = defaultColumns
+	}
+	c.Columns = chosen
+}
+
+func find(id string) (column, bool) {
+	for _, c := range columns {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// jiraIssue holds the fields of an issue we can show, as text.
+type jiraIssue struct {
+	Key         string   `json:"key"`
+	URL         string   `json:"url"`
+	Summary     string   `json:"summary"`
+	Status      string   `json:"status"`
+	Assignee    string   `json:"assignee"`
+	FixVersions []string `json:"fixVersions"`
+	Type        string   `json:"issuetype"`
+	Priority    string   `json:"priority"`
+	Reporter    string   `json:"reporter"`
+	Updated     string   `json:"updated"`
+}
+
+// jiraData is what we store as the section data.
+type jiraData struct {
+	Issues []jiraIssue `json:"issues"`
+	Total  int         `json:"total"`
+}
+
+type jiraCell struct {
+	Text string
+	URL  string
+}
+
+type jiraRender struct {
+	Config  jiraConfig
+	Columns []column
+	Rows    [][]jiraCell
+	Count   int
+	Total   int
+	HasData bool
+}
+
+// API types, holding only the fields we use.
+
+type apiName struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type apiSearch struct {
+	StartAt    int `json:"startAt"`
+	MaxResults int `json:"maxResults"`
+	Total      int `json:"total"`
+	Issues     []struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string    `json:"summary"`
+			Status      *apiName  `json:"status"`
+			Assignee    *apiName  `json:"assignee"`
+			Reporter    *apiName  `json:"reporter"`
+			FixVersions []apiName `json:"fixVersions"`
+			IssueType   *apiName  `json:"issuetype"`
+			Priority    *apiName  `json:"priority"`
+			Updated     string    `json:"updated"`
+		} `json:"fields"`
+	} `json:"issues"`
+}
+
+type apiErrors struct {
+	ErrorMessages []string `json:"errorMessages"`
+}
@@ -138,6 +138,7 @@ func (*Provider) Refresh(ctx *provider.Context, config, data string) (newData st
 
 	if len(c.APIToken) == 0 {
 		log.Error("missing API token", err)
+		ctx.RefreshFailed(errors.New("missing API token"))
 		return
 	}
 
@@ -145,6 +146,7 @@ func (*Provider) Refresh(ctx *provider.Context, config, data string) (newData st
 
 	if err != nil {
 		log.Error("Papertrail fetchEvents failed", err)
+		ctx.RefreshFailed(err)
 		return
 	}
 
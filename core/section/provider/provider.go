@@ -12,6 +12,7 @@
 package provider
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -40,8 +41,19 @@ type TypeMeta struct {
 	Description string                                         `json:"description"`
 	Preview     bool                                           `json:"preview"` // coming soon!
 	Callback    func(http.ResponseWriter, *http.Request) error `json:"-"`
+	Webhook     WebhookFunc                                    `json:"-"`
 }
 
+// WebhookFunc handles an event posted to the webhook of a section type, checking it was signed with the secret.
+// It returns which sections the event concerns, or nil for events that concern none.
+type WebhookFunc func(r *http.Request, body []byte, secret string) (WebhookMatch, error)
+
+// WebhookMatch reports whether a webhook event concerns the section with the given config.
+type WebhookMatch func(config string) bool
+
+// ErrWebhookSignature is returned by a TypeMeta.Webhook for events that were not signed with the secret.
+var ErrWebhookSignature = errors.New("webhook signature does not match")
+
 // ConfigHandle returns the key name for database config table
 func (t *TypeMeta) ConfigHandle() string {
 	return fmt.Sprintf("SECTION-%s", strings.ToUpper(t.ContentType))
@@ -57,10 +69,12 @@ type Provider interface {
 
 // Context describes the environment the section code runs in
 type Context struct {
-	OrgID     string
-	UserID    string
-	prov      Provider
-	inCommand bool
+	OrgID      string
+	UserID     string
+	prov       Provider
+	inCommand  bool
+	failure    error
+	connection string
 }
 
 // NewContext is a convenience function.
@@ -93,16 +107,101 @@ func GetSectionMeta() []TypeMeta {
 }
 
 // Command passes parameters to the given section id, the returned bool indicates success.
+// When the context uses a shared connection, its secrets are masked in whatever the command returns,
+// as the connection was set up by an administrator and the user is only allowed to use it.
 func Command(section string, ctx *Context, w http.ResponseWriter, r *http.Request) bool {
 	s, ok := sectionsMap[section]
 	if ok {
 		ctx.prov = s
 		ctx.inCommand = true
-		s.Command(ctx, w, r)
+
+		if len(ctx.connection) == 0 {
+			s.Command(ctx, w, r)
+			return ok
+		}
+
+		m := &maskingWriter{ResponseWriter: w, status: http.StatusOK}
+		s.Command(ctx, m, r)
+		m.flush(secretValues(ctx.GetSecrets("")))
 	}
 	return ok
 }
 
+// maskingWriter holds back a response so the secrets in it can be masked.
+type maskingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// WriteHeader keeps the first status written, as the ResponseWriter would.
+func (m *maskingWriter) WriteHeader(status int) {
+	if !m.wroteHeader {
+		m.status = status
+		m.wroteHeader = true
+	}
+}
+
+func (m *maskingWriter) Write(b []byte) (int, error) {
+	m.WriteHeader(http.StatusOK)
+	return m.body.Write(b)
+}
+
+// flush writes the response with each secret replaced by SecretReplacement.
+func (m *maskingWriter) flush(secrets []string) {
+	out := m.body.Bytes()
+	for _, s := range secrets {
+		out = bytes.Replace(out, []byte(s), []byte(SecretReplacement), -1)
+	}
+
+	m.ResponseWriter.Header().Del("Content-Length")
+	m.ResponseWriter.WriteHeader(m.status)
+	_, err := m.ResponseWriter.Write(out)
+	log.IfErr(err)
+}
+
+// secretValues returns the strings held in the secrets, as they are and as they appear within JSON,
+// even JSON held in a JSON string, longest first so no part of a longer secret is left behind.
+func secretValues(secrets string) (values []string) {
+	var v interface{}
+	if json.Unmarshal([]byte(secrets), &v) != nil {
+		return
+	}
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case string:
+			// very short values, such as a port, would mask unrelated text
+			if len(t) < 4 {
+				return
+			}
+			for i := 0; i < 3; i++ {
+				values = append(values, t)
+				b, _ := json.Marshal(t)
+				if string(b[1:len(b)-1]) == t {
+					break
+				}
+				t = string(b[1 : len(b)-1])
+			}
+		case map[string]interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		case []interface{}:
+			for _, e := range t {
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	return
+}
+
 // Callback passes parameters to the given section callback, the returned error indicates success.
 func Callback(section string, w http.ResponseWriter, r *http.Request) error {
 	s, ok := sectionsMap[section]
@@ -114,6 +213,23 @@ func Callback(section string, w http.ResponseWriter, r *http.Request) error {
 	return errors.New("section not found")
 }
 
+// HasWebhook reports whether the given section can be told about changes to its source by a webhook.
+func HasWebhook(section string) bool {
+	s, ok := sectionsMap[section]
+	return ok && s.Meta().Webhook != nil
+}
+
+// Webhook passes an event posted to a webhook to the given section, which returns what the event concerns.
+func Webhook(section string, r *http.Request, body []byte, secret string) (WebhookMatch, error) {
+	s, ok := sectionsMap[section]
+	if ok {
+		if wh := s.Meta().Webhook; wh != nil {
+			return wh(r, body, secret)
+		}
+	}
+	return nil, errors.New("section not found")
+}
+
 // Render runs that operation for the given section id, the returned bool indicates success.
 func Render(section string, ctx *Context, config, data string) (string, bool) {
 	s, ok := sectionsMap[section]
@@ -200,16 +316,47 @@ func WriteForbidden(w http.ResponseWriter) {
 	log.IfErr(err)
 }
 
+// RefreshFailed records why Refresh could not fetch the latest data, which is shown to the section owner.
+// Refresh should still return the data it was given.
+func (c *Context) RefreshFailed(err error) {
+	if c != nil {
+		c.failure = err
+	}
+}
+
+// RefreshError returns the reason recorded by RefreshFailed, or nil if the refresh worked.
+func (c *Context) RefreshError() error {
+	if c == nil {
+		return nil
+	}
+	return c.failure
+}
+
 // Secrets handling
 
+// UseConnection makes the secrets those of the shared connection, rather than those of the context user.
+// An empty id keeps the secrets of the user.
+func (c *Context) UseConnection(id string) {
+	c.connection = id
+}
+
+// Connection returns the shared connection in use, or the empty string when the secrets are the user's own.
+func (c *Context) Connection() string {
+	return c.connection
+}
+
 // SaveSecrets for the current user/org combination.
 // The secrets must be in the form of a JSON format string, for example `{"mysecret":"lover"}`.
 // An empty string signifies no valid secrets for this user/org combination.
 // Note that this function can only be called within the Command method of a section.
+// The secrets of a shared connection are only set by administrators, so they are left as they are.
 func (c *Context) SaveSecrets(JSONobj string) error {
 	if !c.inCommand {
 		return errors.New("SaveSecrets() may only be called from within Command()")
 	}
+	if len(c.connection) > 0 {
+		return nil
+	}
 	m := c.prov.Meta()
 	return request.UserConfigSetJSON(c.OrgID, c.UserID, m.ContentType, JSONobj)
 }
@@ -227,16 +374,22 @@ func (c *Context) MarshalSecrets(sec interface{}) error {
 	return c.SaveSecrets(string(byts))
 }
 
-// GetSecrets for the current context user/org.
+// GetSecrets for the current context user/org, or for the shared connection when one is used.
 // For example (see SaveSecrets example): thisContext.GetSecrets("mysecret")
 // JSONpath format is defined at https://dev.mysql.com/doc/refman/5.7/en/json-path-syntax.html .
 // An empty JSONpath returns the whole JSON object, as JSON.
 // Errors return the empty string.
 func (c *Context) GetSecrets(JSONpath string) string {
 	m := c.prov.Meta()
+	if len(c.connection) > 0 {
+		return connectionSecrets(c.OrgID, c.connection, m.ContentType, JSONpath)
+	}
 	return request.UserConfigGetJSON(c.OrgID, c.UserID, m.ContentType, JSONpath)
 }
 
+// connectionSecrets reads the secrets of a shared connection, replaced when testing.
+var connectionSecrets = request.ConnectionGetJSON
+
 // ErrNoSecrets is returned if no secret is found in the database.
 var ErrNoSecrets = errors.New("no secrets in database")
 
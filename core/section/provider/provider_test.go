@@ -0,0 +1,65 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package provider
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// echo is a section whose command returns its secrets, as some editors ask for them.
+type echo struct{}
+
+func (*echo) Meta() TypeMeta { return TypeMeta{ContentType: "echo"} }
+
+func (*echo) Command(ctx *Context, w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+	WriteJSON(w, map[string]string{"secrets": ctx.GetSecrets(""), "key": ctx.GetSecrets("apikey")})
+}
+
+func (*echo) Render(ctx *Context, config, data string) string  { return "" }
+func (*echo) Refresh(ctx *Context, config, data string) string { return "" }
+
+func TestCommandMasksConnectionSecrets(t *testing.T) {
+	Register("echo", &echo{})
+	defer delete(sectionsMap, "echo")
+
+	saved := connectionSecrets
+	defer func() { connectionSecrets = saved }()
+
+	connectionSecrets = func(orgid, connectionid, area, path string) string {
+		if path == "apikey" {
+			return "k3y-\"quoted\""
+		}
+		return `{"url":"https://gemini.example.com/api","username":"admin","apikey":"k3y-\"quoted\"","port":"80"}`
+	}
+
+	ctx := NewContext("org", "user")
+	ctx.UseConnection("conn")
+
+	w := httptest.NewRecorder()
+	if !Command("echo", ctx, w, httptest.NewRequest("POST", "/?method=secrets", nil)) {
+		t.Fatal("section not found")
+	}
+
+	out := w.Body.String()
+	for _, secret := range []string{"gemini.example.com", "admin", "k3y"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("response holds %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, SecretReplacement) || w.Code != http.StatusAccepted {
+		t.Errorf("unexpected response %d %s", w.Code, out)
+	}
+}
@@ -16,12 +16,19 @@ import (
 
 	"github.com/documize/community/core/log"
 	"github.com/documize/community/core/section/airtable"
+	"github.com/documize/community/core/section/chart"
 	"github.com/documize/community/core/section/code"
+	"github.com/documize/community/core/section/feed"
 	"github.com/documize/community/core/section/gemini"
 	"github.com/documize/community/core/section/github"
+	"github.com/documize/community/core/section/gitlab"
+	"github.com/documize/community/core/section/jira"
 	"github.com/documize/community/core/section/markdown"
 	"github.com/documize/community/core/section/papertrail"
 	"github.com/documize/community/core/section/provider"
+	"github.com/documize/community/core/section/remote"
+	"github.com/documize/community/core/section/rest"
+	"github.com/documize/community/core/section/sql"
 	"github.com/documize/community/core/section/table"
 	"github.com/documize/community/core/section/trello"
 	"github.com/documize/community/core/section/wysiwyg"
@@ -29,15 +36,22 @@ import (
 
 // Register sections
 func Register() {
+	provider.Register("chart", &chart.Provider{})
 	provider.Register("code", &code.Provider{})
+	provider.Register("feed", &feed.Provider{})
 	provider.Register("gemini", &gemini.Provider{})
 	provider.Register("github", &github.Provider{})
+	provider.Register("gitlab", &gitlab.Provider{})
+	provider.Register("jira", &jira.Provider{})
 	provider.Register("markdown", &markdown.Provider{})
 	provider.Register("papertrail", &papertrail.Provider{})
+	provider.Register("rest", &rest.Provider{})
+	provider.Register("sql", &sql.Provider{})
 	provider.Register("table", &table.Provider{})
 	provider.Register("trello", &trello.Provider{})
 	provider.Register("wysiwyg", &wysiwyg.Provider{})
 	provider.Register("airtable", &airtable.Provider{})
+	remote.Register() // plugins declared in the config table, which may not clash with those above
 	p := provider.List()
 	log.Info(fmt.Sprintf("Documize registered %d sections and tabs", len(p)))
 }
@@ -0,0 +1,126 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package remote
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	defaultTimeout = 10 // seconds
+	maxTimeout     = 120
+	defaultHealth  = 60 // seconds
+	minHealth      = 10
+)
+
+// Plugin declares a section provider that runs in another process.
+// The SECTIONPLUGINS entry of the config table holds a JSON list of these.
+type Plugin struct {
+	Name     string `json:"name"`     // content type of the section, which must not clash with a built-in one
+	Protocol string `json:"protocol"` // "http" (the default) or "rpc"
+	URL      string `json:"url"`      // base URL for http, host:port for rpc
+	Token    string `json:"token"`    // sent as a bearer token over http
+	Timeout  int    `json:"timeout"`  // seconds allowed for each call
+	Health   int    `json:"health"`   // seconds between health checks
+}
+
+// Clean fills in defaults for the plugin.
+func (p *Plugin) Clean() {
+	p.Name = strings.ToLower(strings.TrimSpace(p.Name))
+	p.Protocol = strings.ToLower(strings.TrimSpace(p.Protocol))
+	p.URL = strings.TrimRight(strings.TrimSpace(p.URL), "/")
+
+	if len(p.Protocol) == 0 {
+		p.Protocol = "http"
+	}
+
+	if p.Timeout <= 0 {
+		p.Timeout = defaultTimeout
+	}
+	if p.Timeout > maxTimeout {
+		p.Timeout = maxTimeout
+	}
+
+	if p.Health <= 0 {
+		p.Health = defaultHealth
+	}
+	if p.Health < minHealth {
+		p.Health = minHealth
+	}
+}
+
+// Field is an input shown in the section editor, its value is kept in the section config under Name.
+type Field struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	Tip   string `json:"tip"`
+	Type  string `json:"type"` // text, number, textarea or checkbox
+}
+
+// MetaRequest asks the plugin to describe its section, and is also used as the health check.
+type MetaRequest struct {
+}
+
+// MetaResponse describes the section.
+type MetaResponse struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	PageType    string  `json:"pageType"` // "tab" (the default) or "section"
+	Order       int     `json:"order"`
+	Fields      []Field `json:"fields"`
+}
+
+// CommandRequest passes a call from the section editor to the plugin.
+// The editor calls the "data" method with the config when saving, and stores what it returns as the section data.
+type CommandRequest struct {
+	OrgID   string          `json:"orgId"`
+	UserID  string          `json:"userId"`
+	Method  string          `json:"method"`
+	Payload json.RawMessage `json:"payload"` // as posted by the editor
+	Secrets json.RawMessage `json:"secrets"` // saved for this user, or of the shared connection in use, null when there are none
+}
+
+// CommandResponse is written back to the section editor.
+type CommandResponse struct {
+	Payload json.RawMessage `json:"payload"`
+	Error   string          `json:"error"`   // shown to the author instead of the payload
+	Secrets json.RawMessage `json:"secrets"` // saved for this user when present
+}
+
+// RenderRequest asks the plugin to turn section data into HTML.
+type RenderRequest struct {
+	OrgID  string `json:"orgId"`
+	UserID string `json:"userId"`
+	Config string `json:"config"`
+	Data   string `json:"data"`
+}
+
+// RenderResponse holds the HTML of the section.
+type RenderResponse struct {
+	HTML string `json:"html"`
+}
+
+// RefreshRequest asks the plugin for the latest section data, with the secrets of the section owner or its shared connection.
+type RefreshRequest struct {
+	OrgID   string          `json:"orgId"`
+	UserID  string          `json:"userId"`
+	Config  string          `json:"config"`
+	Data    string          `json:"data"`
+	Secrets json.RawMessage `json:"secrets"`
+}
+
+// RefreshResponse holds the latest section data, or why it could not be fetched.
+type RefreshResponse struct {
+	Data  string `json:"data"`
+	Error string `json:"error"`
+}
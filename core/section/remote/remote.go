@@ -0,0 +1,312 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+// Package remote provides sections whose provider runs in another process, reached over HTTP or JSON-RPC.
+// Plugins are declared in the SECTIONPLUGINS entry of the config table, for example:
+//
+//	[{"name": "inventory", "url": "https://inventory.example.com/documize", "token": "...", "timeout": 10}]
+//	[{"name": "builds", "protocol": "rpc", "url": "builds.example.com:7000"}]
+//
+// Over HTTP each method is a POST of JSON to the URL followed by /meta, /command, /render or /refresh.
+// Over JSON-RPC the methods are Section.Meta, Section.Command, Section.Render and Section.Refresh.
+// The request and response types are defined in this package.
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+)
+
+// configKey is the config table entry that declares the plugins.
+const configKey = "SECTIONPLUGINS"
+
+// names are used in URLs and GUI component paths.
+var names = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+var errUnavailable = errors.New("the section plugin is not available")
+
+// Provider passes section calls to a plugin running in another process.
+type Provider struct {
+	plugin    Plugin
+	transport transport
+
+	mutex   sync.RWMutex
+	meta    MetaResponse
+	healthy bool
+}
+
+// Register adds the section plugins declared in the config table, which are then checked on in the background.
+// Plugins that cannot be reached are still added, so they can be used once they are running.
+func Register() {
+	j := strings.TrimSpace(request.ConfigString(configKey, ""))
+
+	if len(j) == 0 {
+		return
+	}
+
+	plugins := []Plugin{}
+	err := json.Unmarshal([]byte(j), &plugins)
+
+	if err != nil {
+		log.Error("unable to read "+configKey+" from the config table", err)
+		return
+	}
+
+	for _, pl := range plugins {
+		p, err := New(pl)
+
+		if err != nil {
+			log.Error("unable to add section plugin", err)
+			continue
+		}
+
+		if _, exists := provider.List()[p.plugin.Name]; exists {
+			log.ErrorString(fmt.Sprintf("section plugin %s has the same name as another section", p.plugin.Name))
+			continue
+		}
+
+		if err = p.Check(); err != nil {
+			log.Error(fmt.Sprintf("section plugin %s is not available", p.plugin.Name), err)
+		}
+
+		provider.Register(p.plugin.Name, p)
+		go p.monitor()
+
+		log.Info(fmt.Sprintf("Added section plugin %s at %s", p.plugin.Name, p.plugin.URL))
+	}
+}
+
+// New returns a provider for the plugin, without contacting it.
+func New(pl Plugin) (*Provider, error) {
+	pl.Clean()
+
+	if !names.MatchString(pl.Name) {
+		return nil, fmt.Errorf("section plugin name '%s' must be lower case letters, digits and dashes", pl.Name)
+	}
+
+	t, err := newTransport(pl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{plugin: pl, transport: t}, nil
+}
+
+// Check asks the plugin to describe its section, recording whether it answered.
+func (p *Provider) Check() error {
+	var meta MetaResponse
+	err := p.transport.call("Meta", MetaRequest{}, &meta)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.healthy = err == nil
+	if err == nil {
+		p.meta = meta
+	}
+
+	return err
+}
+
+// Healthy reports whether the plugin answered when last called.
+func (p *Provider) Healthy() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return p.healthy
+}
+
+// monitor checks on the plugin for as long as the server runs, logging when it goes away or comes back.
+func (p *Provider) monitor() {
+	for range time.Tick(time.Duration(p.plugin.Health) * time.Second) {
+		was := p.Healthy()
+		err := p.Check()
+
+		if was && err != nil {
+			log.Error(fmt.Sprintf("section plugin %s is not available", p.plugin.Name), err)
+		}
+		if !was && err == nil {
+			log.Info(fmt.Sprintf("section plugin %s is available", p.plugin.Name))
+		}
+	}
+}
+
+// call makes the call on the plugin, a failure to reach it marks it unhealthy until the next check.
+func (p *Provider) call(method string, args, reply interface{}) error {
+	err := p.transport.call(method, args, reply)
+
+	if err != nil {
+		p.mutex.Lock()
+		p.healthy = false
+		p.mutex.Unlock()
+	}
+
+	return err
+}
+
+// Meta describes the section as the plugin last did.
+func (p *Provider) Meta() provider.TypeMeta {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	section := provider.TypeMeta{}
+
+	section.ID = "plugin-" + p.plugin.Name
+	section.Title = p.meta.Title
+	section.Description = p.meta.Description
+	section.ContentType = p.plugin.Name
+	section.PageType = p.meta.PageType
+	section.Order = p.meta.Order
+
+	if len(section.Title) == 0 {
+		section.Title = p.plugin.Name
+	}
+	if section.PageType != "section" {
+		section.PageType = "tab"
+	}
+
+	return section
+}
+
+// Command answers the "form" method with the editor fields, and passes other methods to the plugin.
+func (p *Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Query().Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, p.plugin.Name, "missing method name")
+		return
+	}
+
+	if method == "form" {
+		p.mutex.RLock()
+		fields := p.meta.Fields
+		p.mutex.RUnlock()
+
+		if fields == nil {
+			fields = []Field{}
+		}
+
+		provider.WriteJSON(w, fields)
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBytes))
+
+	if err != nil {
+		provider.WriteMessage(w, p.plugin.Name, "Bad payload")
+		return
+	}
+
+	req := CommandRequest{OrgID: ctx.OrgID, UserID: ctx.UserID, Method: method, Secrets: secrets(ctx)}
+
+	if len(bytes.TrimSpace(body)) > 0 {
+		if !json.Valid(body) {
+			provider.WriteMessage(w, p.plugin.Name, "Bad payload")
+			return
+		}
+		req.Payload = body
+	}
+
+	var res CommandResponse
+	err = p.call("Command", req, &res)
+
+	if err != nil {
+		provider.WriteError(w, p.plugin.Name, err)
+		return
+	}
+
+	if len(res.Error) > 0 {
+		provider.WriteMessage(w, p.plugin.Name, res.Error)
+		return
+	}
+
+	if s := bytes.TrimSpace(res.Secrets); len(s) > 0 && !bytes.Equal(s, []byte("null")) {
+		if err = ctx.SaveSecrets(string(s)); err != nil {
+			provider.WriteError(w, p.plugin.Name, err)
+			return
+		}
+	}
+
+	provider.WriteJSON(w, res.Payload)
+}
+
+// Render asks the plugin for the HTML of the section.
+func (p *Provider) Render(ctx *provider.Context, config, data string) string {
+	req := RenderRequest{Config: config, Data: data}
+	if ctx != nil {
+		req.OrgID, req.UserID = ctx.OrgID, ctx.UserID
+	}
+
+	var res RenderResponse
+	err := p.call("Render", req, &res)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("section plugin %s unable to render", p.plugin.Name), err)
+		return fmt.Sprintf("<p>Unable to show this section: %s</p>", template.HTMLEscapeString(err.Error()))
+	}
+
+	return res.HTML
+}
+
+// Refresh asks the plugin for the latest data, keeping the data as it is while the plugin is not available.
+func (p *Provider) Refresh(ctx *provider.Context, config, data string) string {
+	if !p.Healthy() {
+		ctx.RefreshFailed(errUnavailable)
+		return data
+	}
+
+	req := RefreshRequest{Config: config, Data: data}
+	if ctx != nil {
+		req.OrgID, req.UserID, req.Secrets = ctx.OrgID, ctx.UserID, secrets(ctx)
+	}
+
+	var res RefreshResponse
+	err := p.call("Refresh", req, &res)
+
+	if err != nil {
+		log.Error(fmt.Sprintf("section plugin %s unable to refresh", p.plugin.Name), err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	if len(res.Error) > 0 {
+		ctx.RefreshFailed(errors.New(res.Error))
+		return data
+	}
+
+	return res.Data
+}
+
+// secrets returns those saved for the context user, or nil when there are none.
+func secrets(ctx *provider.Context) json.RawMessage {
+	s := ctx.GetSecrets("")
+
+	if len(s) == 0 || !json.Valid([]byte(s)) {
+		return nil
+	}
+
+	return json.RawMessage(s)
+}
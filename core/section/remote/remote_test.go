@@ -0,0 +1,261 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package remote
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+// Section is a plugin as it would be written for either protocol.
+type Section struct{}
+
+func (*Section) Meta(req MetaRequest, res *MetaResponse) error {
+	*res = MetaResponse{Title: "Inventory", Description: "Stock levels", Fields: []Field{{Name: "sku", Label: "SKU", Type: "text"}}}
+	return nil
+}
+
+func (*Section) Command(req CommandRequest, res *CommandResponse) error {
+	if req.Method == "fail" {
+		res.Error = "no such SKU"
+		return nil
+	}
+	res.Payload = json.RawMessage(`{"method":"` + req.Method + `","echo":` + string(req.Payload) + `}`)
+	return nil
+}
+
+func (*Section) Render(req RenderRequest, res *RenderResponse) error {
+	res.HTML = "<p>" + req.Data + "</p>"
+	return nil
+}
+
+func (*Section) Refresh(req RefreshRequest, res *RefreshResponse) error {
+	if strings.Contains(req.Config, "broken") {
+		res.Error = "the stock service is down"
+		return nil
+	}
+	res.Data = "latest " + req.Config
+	return nil
+}
+
+// standIn serves the plugin over HTTP, checking the token.
+func standIn() *httptest.Server {
+	s := &Section{}
+
+	handle := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var res interface{}
+		var err error
+		d := json.NewDecoder(r.Body)
+
+		switch r.URL.Path {
+		case "/plugin/meta":
+			var req MetaRequest
+			var out MetaResponse
+			d.Decode(&req)
+			err, res = s.Meta(req, &out), &out
+		case "/plugin/command":
+			var req CommandRequest
+			var out CommandResponse
+			d.Decode(&req)
+			err, res = s.Command(req, &out), &out
+		case "/plugin/render":
+			var req RenderRequest
+			var out RenderResponse
+			d.Decode(&req)
+			err, res = s.Render(req, &out), &out
+		case "/plugin/refresh":
+			var req RefreshRequest
+			var out RefreshResponse
+			d.Decode(&req)
+			err, res = s.Refresh(req, &out), &out
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(handle))
+}
+
+func TestHTTP(t *testing.T) {
+	s := standIn()
+
+	p, err := New(Plugin{Name: " Inventory ", URL: s.URL + "/plugin/", Token: "t0ken", Timeout: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Check(); err != nil || !p.Healthy() {
+		t.Fatalf("check failed: %v", err)
+	}
+
+	provider.Register("inventory", p)
+
+	m := p.Meta()
+	if m.Title != "Inventory" || m.ContentType != "inventory" || m.PageType != "tab" {
+		t.Errorf("unexpected meta %+v", m)
+	}
+
+	ctx := provider.NewContext("org", "user")
+
+	if html, _ := provider.Render("inventory", ctx, "{}", "12 left"); html != "<p>12 left</p>" {
+		t.Errorf("unexpected render %s", html)
+	}
+
+	if data, _ := provider.Refresh("inventory", ctx, "widgets", "old"); data != "latest widgets" || ctx.RefreshError() != nil {
+		t.Errorf("unexpected refresh %s %v", data, ctx.RefreshError())
+	}
+
+	ctx = provider.NewContext("org", "user")
+	if data, _ := provider.Refresh("inventory", ctx, "broken", "old"); data != "old" || ctx.RefreshError() == nil {
+		t.Errorf("expected the failure recorded, got %s %v", data, ctx.RefreshError())
+	}
+
+	for method, want := range map[string]string{
+		"form": `[{"name":"sku","label":"SKU","tip":"","type":"text"}]`,
+		"data": `{"method":"data","echo":{"sku":"A1"}}`,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/sections?method="+method, strings.NewReader(`{"sku":"A1"}`))
+		provider.Command("inventory", provider.NewContext("org", "user"), w, r)
+
+		if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != want {
+			t.Errorf("%s: unexpected reply %d %s", method, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	provider.Command("inventory", provider.NewContext("org", "user"), w, httptest.NewRequest("POST", "/sections?method=fail", nil))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "no such SKU") {
+		t.Errorf("expected the plugin error, got %d %s", w.Code, w.Body.String())
+	}
+
+	// once the plugin goes away, refreshes keep the data
+	s.Close()
+
+	if html := p.Render(ctx, "{}", "12 left"); !strings.Contains(html, "Unable to show this section") || p.Healthy() {
+		t.Errorf("expected the plugin to be unavailable, got %s", html)
+	}
+
+	ctx = provider.NewContext("org", "user")
+	if data, _ := provider.Refresh("inventory", ctx, "widgets", "old"); data != "old" || ctx.RefreshError() != errUnavailable {
+		t.Errorf("expected the data kept, got %s %v", data, ctx.RefreshError())
+	}
+}
+
+func TestRPC(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.Register(&Section{}); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+
+	p, err := New(Plugin{Name: "stock", Protocol: "rpc", URL: l.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	provider.Register("stock", p)
+
+	if p.Meta().Title != "Inventory" {
+		t.Errorf("unexpected meta %+v", p.Meta())
+	}
+	if html := p.Render(nil, "{}", "3 left"); html != "<p>3 left</p>" {
+		t.Errorf("unexpected render %s", html)
+	}
+
+	ctx := provider.NewContext("org", "user")
+	if data, _ := provider.Refresh("stock", ctx, "bolts", "old"); data != "latest bolts" {
+		t.Errorf("unexpected refresh %s %v", data, ctx.RefreshError())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// accepts connections but never answers
+	go func() {
+		for {
+			if _, err := l.Accept(); err != nil {
+				return
+			}
+		}
+	}()
+
+	p, _ := New(Plugin{Name: "slow", Protocol: "rpc", URL: l.Addr().String(), Timeout: 1})
+
+	start := time.Now()
+	if err = p.Check(); err == nil || time.Since(start) > 5*time.Second {
+		t.Errorf("expected a timeout, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, pl := range []Plugin{
+		{Name: "", URL: "http://x"},
+		{Name: "has space", URL: "http://x"},
+		{Name: "ok", URL: "ftp://x"},
+		{Name: "ok", Protocol: "rpc", URL: "no-port"},
+		{Name: "ok", Protocol: "grpc", URL: "x:1"},
+	} {
+		if _, err := New(pl); err == nil {
+			t.Errorf("expected an error for %+v", pl)
+		}
+	}
+
+	pl := Plugin{Name: "ok", URL: "http://x", Timeout: 1000, Health: 1}
+	pl.Clean()
+	if pl.Timeout != maxTimeout || pl.Health != minHealth || pl.Protocol != "http" {
+		t.Errorf("plugin not cleaned: %+v", pl)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc/jsonrpc"
+	"strings"
+	"time"
+)
+
+// maxBytes caps the size of a reply we are prepared to read.
+const maxBytes = 10 << 20
+
+// transport calls one of the plugin methods: Meta, Command, Render or Refresh.
+type transport interface {
+	call(method string, args, reply interface{}) error
+}
+
+func newTransport(p Plugin) (transport, error) {
+	timeout := time.Duration(p.Timeout) * time.Second
+
+	switch p.Protocol {
+	case "http":
+		if !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+			return nil, fmt.Errorf("plugin %s needs an http or https URL", p.Name)
+		}
+		return &httpTransport{url: p.URL, token: p.Token, client: &http.Client{Timeout: timeout}}, nil
+	case "rpc":
+		if _, _, err := net.SplitHostPort(p.URL); err != nil {
+			return nil, fmt.Errorf("plugin %s needs a host:port address: %s", p.Name, err)
+		}
+		return &rpcTransport{address: p.URL, timeout: timeout}, nil
+	}
+
+	return nil, fmt.Errorf("plugin %s uses unknown protocol '%s'", p.Name, p.Protocol)
+}
+
+// httpTransport posts JSON to the plugin URL followed by the lower-cased method name, e.g. /render.
+type httpTransport struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (t *httpTransport) call(method string, args, reply interface{}) error {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", t.url+"/"+strings.ToLower(method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if len(t.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", method, res.Status)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, maxBytes)).Decode(reply)
+}
+
+// rpcTransport makes JSON-RPC calls on the plugin's "Section" service, e.g. Section.Render,
+// with a connection for each call so a slow plugin cannot hold up others.
+type rpcTransport struct {
+	address string
+	timeout time.Duration
+}
+
+func (t *rpcTransport) call(method string, args, reply interface{}) error {
+	conn, err := net.DialTimeout("tcp", t.address, t.timeout)
+	if err != nil {
+		return err
+	}
+
+	conn.SetDeadline(time.Now().Add(t.timeout))
+
+	client := jsonrpc.NewClient(conn)
+	defer client.Close()
+
+	return client.Call("Section."+method, args, reply)
+}
@@ -0,0 +1,113 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+// the template used when the author has not written one, it shows the data as JSON.
+const defaultTemplate = `<pre>{{json .}}</pre>`
+
+// the HTML shown when the author's template cannot be used.
+const errorTemplate = `<p class="section-rest-error">Unable to show the data: {{.}}</p>`
+
+// secrets holds the values of secret headers, for the server they were saved for.
+type secrets struct {
+	Origin  string            `json:"origin"`
+	Headers map[string]string `json:"headers"`
+}
+
+// header is sent with the request, secret values are kept out of the section config.
+type header struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"` // for secrets, only contains the correct value just after it is typed in
+	Secret bool   `json:"secret"`
+}
+
+type restConfig struct {
+	URL      string   `json:"url"`
+	Headers  []header `json:"headers"`
+	Selector string   `json:"selector"` // JSONPath-style, e.g. $.items[*]
+	Template string   `json:"template"` // HTML with Go template actions
+}
+
+func (c *restConfig) Clean() {
+	c.URL = strings.TrimSpace(c.URL)
+	if len(c.URL) > 0 && !strings.Contains(c.URL, "://") {
+		c.URL = "https://" + c.URL
+	}
+
+	headers := []header{}
+	for _, h := range c.Headers {
+		h.Name = http.CanonicalHeaderKey(strings.TrimSpace(h.Name))
+		h.Value = strings.TrimSpace(h.Value)
+		if len(h.Name) > 0 {
+			headers = append(headers, h)
+		}
+	}
+	c.Headers = headers
+
+	c.Selector = strings.TrimSpace(c.Selector)
+	if len(strings.TrimSpace(c.Template)) == 0 {
+		c.Template = defaultTemplate
+	}
+}
+
+// typed reports whether secret header values have just been typed in, rather than saved earlier.
+func (c *restConfig) typed() bool {
+	for _, h := range c.Headers {
+		if h.Secret && len(h.Value) > 0 && h.Value != provider.SecretReplacement {
+			return true
+		}
+	}
+	return false
+}
+
+// useSecrets fills in the secret headers that were not typed in from those saved,
+// but only when they were saved for the server we are about to call.
+func (c *restConfig) useSecrets(s secrets) {
+	same := len(s.Origin) > 0 && s.Origin == origin(c.URL)
+	for i, h := range c.Headers {
+		if !h.Secret || (len(h.Value) > 0 && h.Value != provider.SecretReplacement) {
+			continue
+		}
+		c.Headers[i].Value = ""
+		if same {
+			c.Headers[i].Value = s.Headers[h.Name]
+		}
+	}
+}
+
+// secrets returns the secret header values to be saved.
+func (c *restConfig) secrets() secrets {
+	s := secrets{Origin: origin(c.URL), Headers: map[string]string{}}
+	for _, h := range c.Headers {
+		if h.Secret {
+			s.Headers[h.Name] = h.Value
+		}
+	}
+	return s
+}
+
+// origin returns the scheme and host of a URL.
+func origin(u string) string {
+	p, err := url.Parse(u)
+	if err != nil || len(p.Host) == 0 {
+		return ""
+	}
+	return strings.ToLower(p.Scheme + "://" + p.Host)
+}
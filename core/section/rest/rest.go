@@ -0,0 +1,295 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+)
+
+const me = "rest"
+
+// maxBytes caps the size of a response we are prepared to read.
+const maxBytes = 2 << 20
+
+// maxOutput caps the size of the HTML produced by a template.
+const maxOutput = 1 << 20
+
+var client = &http.Client{Timeout: 30 * time.Second, CheckRedirect: sameServer}
+
+var errForbidden = errors.New("forbidden")
+
+var errTooLarge = errors.New("the template produced too much HTML")
+
+// functions are those available to templates, in addition to the Go template builtins.
+var functions = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		j, err := json.MarshalIndent(v, "", "  ")
+		return string(j), err
+	},
+}
+
+// Provider represents any REST API that returns JSON
+type Provider struct {
+}
+
+// preview is returned to the editor so the author can check the selector and template.
+type preview struct {
+	Data  interface{} `json:"data"`
+	HTML  string      `json:"html"`
+	Error string      `json:"error"`
+}
+
+// Meta describes us
+func (*Provider) Meta() provider.TypeMeta {
+	section := provider.TypeMeta{}
+
+	section.ID = "7d3f1c52-6e0a-4b8d-9c21-0f5e8a4b6d17"
+	section.Title = "REST API"
+	section.Description = "JSON data from any REST API, shown with your own template"
+	section.ContentType = "rest"
+	section.PageType = "tab"
+
+	return section
+}
+
+// Command fetches the data and renders it for the editor to preview.
+func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var config = restConfig{}
+	err = json.Unmarshal(body, &config)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad config")
+		return
+	}
+
+	config.Clean()
+
+	if len(config.URL) == 0 {
+		provider.WriteMessage(w, me, "Missing URL")
+		return
+	}
+
+	typed := config.typed()
+	var s secrets
+	ctx.UnmarshalSecrets(&s) // ignore error, there are none until a secret header has been used
+	config.useSecrets(s)
+
+	switch method {
+	case "preview":
+		var result preview
+		result.Data, err = fetch(config)
+
+		if err == errForbidden {
+			log.IfErr(ctx.SaveSecrets("")) // invalid credentials, so reset them
+			provider.WriteForbidden(w)
+			return
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			provider.WriteJSON(w, result)
+			return
+		}
+
+		// the secret headers have just worked, so save them
+		if typed {
+			log.IfErr(ctx.MarshalSecrets(config.secrets()))
+		}
+
+		result.HTML, err = render(config, result.Data)
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		provider.WriteJSON(w, result)
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+	}
+}
+
+// Render applies the author's template to the data.
+func (*Provider) Render(ctx *provider.Context, config, data string) string {
+	var c = restConfig{}
+	json.Unmarshal([]byte(config), &c)
+	c.Clean()
+
+	var d interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	decoder.Decode(&d)
+
+	html, err := render(c, d)
+	if err != nil {
+		return failed(err)
+	}
+
+	return html
+}
+
+// Refresh fetches the data again, using the secret headers saved for the server.
+func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
+	var c = restConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil {
+		return data
+	}
+
+	c.Clean()
+
+	if len(c.URL) == 0 {
+		return data
+	}
+
+	var s secrets
+	ctx.UnmarshalSecrets(&s) // ignore error, there are none when no secret headers are used
+	c.useSecrets(s)
+
+	result, err := fetch(c)
+
+	if err != nil {
+		log.Error("rest refresh: unable to fetch "+c.URL, err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	j, err := json.Marshal(result)
+
+	if err != nil {
+		log.Error("unable to marshal rest data", err)
+		return data
+	}
+
+	return string(j)
+}
+
+// render executes the template with the data, then removes anything unsafe from the result.
+// Values from the data are escaped by html/template as they are written out.
+func render(c restConfig, data interface{}) (string, error) {
+	t, err := template.New("rest").Funcs(functions).Parse(c.Template)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := &limitedBuffer{max: maxOutput}
+	if err = t.Execute(buffer, data); err != nil {
+		return "", err
+	}
+
+	return `<div class="section-rest-render">` + sanitize(buffer.String()) + `</div>`, nil
+}
+
+func failed(err error) string {
+	t := template.Must(template.New("error").Parse(errorTemplate))
+
+	buffer := new(bytes.Buffer)
+	t.Execute(buffer, err.Error())
+
+	return buffer.String()
+}
+
+// fetch calls the URL with the configured headers and returns the selected part of the response.
+// sameServer refuses redirects to another scheme or host, as secret headers would be sent there too.
+func sameServer(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if req.URL.Scheme != via[0].URL.Scheme || !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+		return fmt.Errorf("redirect to %s://%s refused", req.URL.Scheme, req.URL.Host)
+	}
+	return nil
+}
+
+func fetch(c restConfig) (interface{}, error) {
+	req, err := http.NewRequest("GET", c.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme '%s'", req.URL.Scheme)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	for _, h := range c.Headers {
+		if len(h.Value) > 0 {
+			req.Header.Set(h.Name, h.Value)
+		}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return nil, errForbidden
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: HTTP status code %d", res.StatusCode)
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(io.LimitReader(res.Body, maxBytes))
+	decoder.UseNumber()
+	if err = decoder.Decode(&value); err != nil {
+		return nil, errors.New("the response is not JSON, or is too large")
+	}
+
+	if len(c.Selector) == 0 {
+		return value, nil
+	}
+
+	return choose(value, c.Selector)
+}
+
+// limitedBuffer stops a template once it has written too much.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		return 0, errTooLarge
+	}
+	return b.Buffer.Write(p)
+}
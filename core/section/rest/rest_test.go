@@ -0,0 +1,201 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+// standIn serves an API that needs a key in a header.
+func standIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "k3y" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/builds":
+			if r.Header.Get("X-Team") != "docs" {
+				t.Errorf("header not sent: %v", r.Header)
+			}
+			w.Write([]byte(`{"data":{"builds":[
+				{"id":1001,"name":"<b>nightly</b>","status":"passed","url":"https://ci.example.com/1001"},
+				{"id":1002,"name":"release","status":"failed","url":"javascript:alert(1)"}
+			]}}`))
+		case "/moved":
+			http.Redirect(w, r, "/builds", http.StatusFound)
+		case "/away":
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+		case "/text":
+			w.Write([]byte(`not json`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestFetchAndRender(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	c := restConfig{
+		URL:      s.URL + "/builds",
+		Headers:  []header{{Name: "x-team", Value: "docs"}, {Name: "X-API-KEY", Value: "k3y", Secret: true}, {Name: " "}},
+		Selector: "$.data.builds[*]",
+		Template: `<table><script>alert(1)</script>{{range .}}<tr onclick="x()"><td class="id">{{.id}}</td><td><a href="{{.url}}">{{.name}}</a></td></tr>{{end}}</table>`,
+	}
+	c.Clean()
+
+	if len(c.Headers) != 2 || c.Headers[1].Name != "X-Api-Key" || !c.typed() {
+		t.Fatalf("headers not cleaned: %+v", c.Headers)
+	}
+
+	data, err := fetch(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j, _ := json.Marshal(data)
+	cfg, _ := json.Marshal(c)
+
+	html := (&Provider{}).Render(nil, string(cfg), string(j))
+	for _, want := range []string{
+		`<div class="section-rest-render"><table>`,
+		`<tr><td class="id">1001</td><td><a href="https://ci.example.com/1001" rel="nofollow noopener">&lt;b&gt;nightly&lt;/b&gt;</a></td></tr>`,
+		`<td class="id">1002</td><td><a>release</a></td>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in %s", want, html)
+		}
+	}
+	for _, bad := range []string{"script", "onclick", "javascript"} {
+		if strings.Contains(html, bad) {
+			t.Errorf("unsafe %s rendered: %s", bad, html)
+		}
+	}
+
+	// secret values are only sent to the server they were saved for
+	saved := c.secrets()
+	c.Headers[1].Value = provider.SecretReplacement
+	c.useSecrets(saved)
+	if c.Headers[1].Value != "k3y" {
+		t.Errorf("saved secret not used: %+v", c.Headers)
+	}
+
+	c.URL = "https://elsewhere.example.com/builds"
+	c.Headers[1].Value = provider.SecretReplacement
+	c.useSecrets(saved)
+	if c.Headers[1].Value != "" {
+		t.Errorf("secret sent to another server: %+v", c.Headers)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	s := standIn(t)
+	defer s.Close()
+
+	c := restConfig{URL: s.URL + "/builds"}
+	c.Clean()
+	if _, err := fetch(c); err != errForbidden {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+
+	c.Headers = []header{{Name: "X-Api-Key", Value: "k3y"}}
+	for _, path := range []string{"/text", "/missing"} {
+		c.URL = s.URL + path
+		if _, err := fetch(c); err == nil {
+			t.Errorf("expected an error for %s", path)
+		}
+	}
+
+	// secret headers follow redirects, so only those to the same server are allowed
+	c.Headers = append(c.Headers, header{Name: "X-Team", Value: "docs"})
+	c.URL = s.URL + "/moved"
+	if _, err := fetch(c); err != nil {
+		t.Errorf("redirect to the same server not followed: %v", err)
+	}
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("redirected to another server with headers %v", r.Header)
+	}))
+	defer other.Close()
+
+	c.URL = s.URL + "/away?to=" + other.URL + "/steal"
+	if _, err := fetch(c); err == nil {
+		t.Error("expected an error for a redirect to another server")
+	}
+
+	c.URL = "file:///etc/passwd"
+	if _, err := fetch(c); err == nil {
+		t.Error("expected an error for a file URL")
+	}
+}
+
+func TestSelector(t *testing.T) {
+	var v interface{}
+	json.Unmarshal([]byte(`{"a":{"b c":[{"n":1},{"n":2},{"m":3}]},"list":[10,20,30]}`), &v)
+
+	for selector, want := range map[string]string{
+		"":                `{"a":{"b c":[{"n":1},{"n":2},{"m":3}]},"list":[10,20,30]}`,
+		"$":               `{"a":{"b c":[{"n":1},{"n":2},{"m":3}]},"list":[10,20,30]}`,
+		"list[1]":         `20`,
+		"$.list[-1]":      `30`,
+		"$.a['b c'][*].n": `[1,2]`,
+		`$["a"].*`:        `[[{"n":1},{"n":2},{"m":3}]]`,
+		"$.list.*":        `[10,20,30]`,
+	} {
+		got, err := choose(v, selector)
+		if err != nil {
+			t.Errorf("%s: %v", selector, err)
+			continue
+		}
+		if j, _ := json.Marshal(got); string(j) != want {
+			t.Errorf("%s: expected %s, got %s", selector, want, j)
+		}
+	}
+
+	for _, selector := range []string{"$.missing", "$.list[9]", "$..n", "$.list[x]", "$.a[", "$."} {
+		if _, err := choose(v, selector); err == nil {
+			t.Errorf("%s: expected an error", selector)
+		}
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	c := restConfig{Template: `{{range .}`}
+	cfg, _ := json.Marshal(c)
+	if html := (&Provider{}).Render(nil, string(cfg), `[]`); !strings.Contains(html, "Unable to show the data") {
+		t.Errorf("expected a template error, got %s", html)
+	}
+
+	c = restConfig{Template: `{{range .}}{{.}}{{end}}`}
+	big := []interface{}{}
+	for len(big) <= maxOutput/100 {
+		big = append(big, strings.Repeat("x", 100))
+	}
+	if _, err := render(c, big); err == nil {
+		t.Error("expected the output to be limited")
+	}
+
+	c = restConfig{}
+	c.Clean()
+	if html, _ := render(c, map[string]interface{}{"a": "<b>"}); !strings.Contains(html, "&#34;a&#34;: &#34;\\u003cb\\u003e&#34;") {
+		t.Errorf("expected the data as JSON, got %s", html)
+	}
+}
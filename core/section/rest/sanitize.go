@@ -0,0 +1,39 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package rest
+
+import (
+	"github.com/documize/community/core/stringutil"
+	"golang.org/x/net/html/atom"
+)
+
+// rendered keeps the elements a template may produce, anything else is replaced by its content.
+var rendered = stringutil.Sanitizer{
+	Elements: map[atom.Atom]bool{
+		atom.A: true, atom.Abbr: true, atom.B: true, atom.Blockquote: true, atom.Br: true, atom.Caption: true,
+		atom.Code: true, atom.Col: true, atom.Colgroup: true, atom.Dd: true, atom.Div: true, atom.Dl: true,
+		atom.Dt: true, atom.Em: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true,
+		atom.H6: true, atom.Hr: true, atom.I: true, atom.Img: true, atom.Li: true, atom.Ol: true, atom.P: true,
+		atom.Pre: true, atom.S: true, atom.Small: true, atom.Span: true, atom.Strong: true, atom.Sub: true,
+		atom.Sup: true, atom.Table: true, atom.Tbody: true, atom.Td: true, atom.Tfoot: true, atom.Th: true,
+		atom.Thead: true, atom.Tr: true, atom.U: true, atom.Ul: true,
+	},
+	Attributes: map[string]bool{
+		"class": true, "title": true, "alt": true, "colspan": true, "rowspan": true,
+		"width": true, "height": true, "align": true,
+	},
+}
+
+// sanitize returns the rendered HTML without scripts, styles, event handlers or unsafe links.
+func sanitize(s string) string {
+	return rendered.Sanitize(s)
+}
@@ -0,0 +1,141 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package rest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// step is one part of a selector: a property name, an array index or a wildcard.
+type step struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseSelector reads a JSONPath-style selector such as $.data.items[*].name, $['odd key'][0] or items.
+func parseSelector(selector string) (steps []step, err error) {
+	s := strings.TrimSpace(selector)
+	s = strings.TrimPrefix(s, "$")
+
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			if strings.HasPrefix(s, ".") {
+				return nil, fmt.Errorf("recursive descent is not supported in %s", selector)
+			}
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			s = s[end:]
+			if len(name) == 0 {
+				return nil, fmt.Errorf("missing property name in %s", selector)
+			}
+			if name == "*" {
+				steps = append(steps, step{wildcard: true})
+			} else {
+				steps = append(steps, step{name: name})
+			}
+
+		case '[':
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in %s", selector)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, step{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, step{name: inner[1 : len(inner)-1]})
+			default:
+				i, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("unsupported [%s] in %s", inner, selector)
+				}
+				steps = append(steps, step{index: i, isIndex: true})
+			}
+
+		default:
+			// a selector may start with a bare property name
+			s = "." + s
+		}
+	}
+
+	return
+}
+
+// choose returns the part of the JSON value picked out by the selector.
+// Once a wildcard is used the result is the list of everything matched.
+func choose(value interface{}, selector string) (interface{}, error) {
+	steps, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []interface{}{value}
+	many := false
+
+	for _, st := range steps {
+		next := []interface{}{}
+		for _, n := range nodes {
+			switch v := n.(type) {
+			case map[string]interface{}:
+				if st.wildcard {
+					keys := make([]string, 0, len(v))
+					for k := range v {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, v[k])
+					}
+				} else if c, ok := v[st.name]; ok && !st.isIndex {
+					next = append(next, c)
+				}
+			case []interface{}:
+				if st.wildcard {
+					next = append(next, v...)
+				} else if st.isIndex {
+					i := st.index
+					if i < 0 {
+						i += len(v)
+					}
+					if i >= 0 && i < len(v) {
+						next = append(next, v[i])
+					}
+				}
+			}
+		}
+
+		if st.wildcard {
+			many = true
+		}
+		if !many && len(next) == 0 {
+			return nil, fmt.Errorf("nothing found for %s", selector)
+		}
+		nodes = next
+	}
+
+	if many {
+		return nodes, nil
+	}
+	return nodes[0], nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package sql
+
+import "strings"
+
+// the HTML that is rendered by this section.
+const renderTemplate = `
+{{if .HasData}}
+<table class="basic-table section-sql-table">
+	<thead>
+		<tr>
+			{{range $column := .Columns}}<th class="bordered">{{$column}}</th>{{end}}
+		</tr>
+	</thead>
+	<tbody>
+		{{range $row := .Rows}}
+		<tr>
+			{{range $cell := $row}}<td class="bordered">{{if $cell}}{{$cell}}{{end}}</td>{{end}}
+		</tr>
+		{{end}}
+	</tbody>
+</table>
+{{if .Truncated}}<p class="section-sql-note">Showing the first {{.Count}} rows.</p>{{end}}
+{{else}}
+<p>The query returned no rows.</p>
+{{end}}
+`
+
+const (
+	defaultRows    = 100
+	maxRows        = 1000
+	defaultTimeout = 10 // seconds
+	maxTimeout     = 60
+)
+
+type sqlConfig struct {
+	Source  string `json:"source"` // name of a data source registered by the administrator
+	Query   string `json:"query"`
+	Max     int    `json:"max"`     // rows
+	Timeout int    `json:"timeout"` // seconds
+}
+
+func (c *sqlConfig) Clean() {
+	c.Source = strings.TrimSpace(c.Source)
+	c.Query = strings.TrimSpace(c.Query)
+
+	if c.Max <= 0 {
+		c.Max = defaultRows
+	}
+	if c.Max > maxRows {
+		c.Max = maxRows
+	}
+
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.Timeout > maxTimeout {
+		c.Timeout = maxTimeout
+	}
+}
+
+// sqlData is what we store as the section data, NULL values are nil.
+type sqlData struct {
+	Columns   []string    `json:"columns"`
+	Rows      [][]*string `json:"rows"`
+	Truncated bool        `json:"truncated"` // there were more rows than the limit
+}
+
+type sqlRender struct {
+	Columns   []string
+	Rows      [][]*string
+	Count     int
+	Truncated bool
+	HasData   bool
+}
+
+// preview is returned to the editor, so the author can see why a query failed.
+type preview struct {
+	Data  sqlData `json:"data"`
+	Error string  `json:"error"`
+}
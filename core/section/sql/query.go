@@ -0,0 +1,277 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// readers are the statements a query may start with.
+var readers = map[string]bool{
+	"SELECT": true, "WITH": true, "SHOW": true, "EXPLAIN": true, "DESCRIBE": true, "DESC": true, "VALUES": true, "TABLE": true,
+}
+
+// writers are keywords that change data, settings or files, which a query may not contain.
+// The query also runs in a read-only transaction, this gives authors a clear error.
+var writers = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "REPLACE": true, "MERGE": true, "UPSERT": true,
+	"INTO": true, "OUTFILE": true, "DUMPFILE": true, "CREATE": true, "ALTER": true, "DROP": true,
+	"TRUNCATE": true, "RENAME": true, "GRANT": true, "REVOKE": true, "LOCK": true, "UNLOCK": true,
+	"CALL": true, "EXEC": true, "EXECUTE": true, "PREPARE": true, "SET": true, "LOAD": true,
+	"HANDLER": true, "ATTACH": true, "DETACH": true, "VACUUM": true, "PRAGMA": true, "COPY": true,
+	"COMMIT": true, "ROLLBACK": true, "BEGIN": true, "START": true,
+}
+
+// validate checks the query is a single statement that only reads data, returning it without a trailing semicolon.
+func validate(query string) (string, error) {
+	words, statements, err := scan(query)
+	if err != nil {
+		return "", err
+	}
+
+	if len(words) == 0 {
+		return "", errors.New("the query is empty")
+	}
+	if statements > 1 {
+		return "", errors.New("only one statement may be run")
+	}
+	if !readers[words[0]] {
+		return "", fmt.Errorf("queries must read data, %s is not allowed", words[0])
+	}
+	for _, w := range words {
+		if writers[w] {
+			return "", fmt.Errorf("queries must only read data, %s is not allowed", w)
+		}
+	}
+
+	return strings.TrimRight(strings.TrimSpace(query), "; \t\r\n"), nil
+}
+
+// scan returns the upper-cased words of the query outside of comments, strings and quoted names,
+// with the number of statements found.
+func scan(query string) (words []string, statements int, err error) {
+	r := []rune(query)
+	word := []rune{}
+	pending := false // text seen since the last semicolon
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToUpper(string(word)))
+			word = word[:0]
+		}
+	}
+
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+
+		switch {
+		case c == '-' && i+1 < len(r) && r[i+1] == '-', c == '#':
+			flush()
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < len(r) && r[i+1] == '*':
+			flush()
+			if i+2 < len(r) && r[i+2] == '!' {
+				// MySQL runs the content of these comments
+				return nil, 0, errors.New("executable comments are not allowed")
+			}
+			for i += 2; i+1 < len(r) && !(r[i] == '*' && r[i+1] == '/'); i++ {
+			}
+			if i+1 >= len(r) {
+				return nil, 0, errors.New("unterminated comment")
+			}
+			i++
+
+		case c == '\'' || c == '"' || c == '`':
+			flush()
+			pending = true
+			i++
+			for ; i < len(r); i++ {
+				if r[i] == '\\' && c == '\'' {
+					i++
+					continue
+				}
+				if r[i] == c {
+					if i+1 < len(r) && r[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			if i >= len(r) {
+				return nil, 0, errors.New("unterminated string or quoted name")
+			}
+
+		case c == ';':
+			flush()
+			if pending {
+				statements++
+				pending = false
+			}
+
+		case unicode.IsLetter(c) || c == '_' || (len(word) > 0 && unicode.IsDigit(c)):
+			word = append(word, c)
+			pending = true
+
+		default:
+			flush()
+			if !unicode.IsSpace(c) {
+				pending = true
+			}
+		}
+	}
+	flush()
+
+	if pending {
+		statements++
+	}
+
+	return
+}
+
+// run executes the query against the data source, reading at most max rows.
+func run(s Source, query string, max int, timeout time.Duration) (data sqlData, err error) {
+	d, ok := dialects[s.Driver]
+	if !ok {
+		err = fmt.Errorf("unsupported driver '%s'", s.Driver)
+		return
+	}
+
+	query, err = validate(query)
+	if err != nil {
+		return
+	}
+
+	dsn := s.DSN
+	if d.dsn != nil {
+		if dsn, err = d.dsn(dsn, timeout); err != nil {
+			return
+		}
+	}
+
+	db, err := sql.Open(s.Driver, dsn)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	defer func() {
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("the query took longer than %s", timeout)
+		}
+	}()
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if d.session != nil {
+		for _, statement := range d.session(timeout) {
+			if _, err = conn.ExecContext(ctx, statement); err != nil {
+				return
+			}
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback() // nothing is ever committed
+
+	if d.transaction != nil {
+		for _, statement := range d.transaction(timeout) {
+			if _, err = tx.ExecContext(ctx, statement); err != nil {
+				return
+			}
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	data.Columns, err = rows.Columns()
+	if err != nil {
+		return
+	}
+	data.Rows = [][]*string{}
+
+	values := make([]interface{}, len(data.Columns))
+	pointers := make([]interface{}, len(values))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if len(data.Rows) >= max {
+			data.Truncated = true
+			break
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return
+		}
+		row := make([]*string, len(values))
+		for i, v := range values {
+			row[i] = text(v)
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	err = rows.Err()
+	return
+}
+
+// text returns a column value as shown in the table, nil for NULL.
+func text(v interface{}) *string {
+	var s string
+
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		s = string(t)
+	case string:
+		s = t
+	case time.Time:
+		s = t.Format("2006-01-02 15:04:05")
+		if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+			s = t.Format("2006-01-02")
+		}
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(t), 'f', -1, 32)
+	default:
+		s = fmt.Sprint(t)
+	}
+
+	return &s
+}
@@ -0,0 +1,189 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package sql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/documize/community/core/api/request"
+	"github.com/documize/community/core/section/provider"
+	"github.com/go-sql-driver/mysql"
+)
+
+// Source is a named database that authors can query, registered by the administrator.
+// The DSN is never sent to authors, and is masked when sent to the administrator.
+type Source struct {
+	Name   string `json:"name"`
+	Driver string `json:"driver"`
+	DSN    string `json:"dsn"`
+}
+
+// dialect describes how to make a session read-only, and limit how long a query runs, for a driver.
+type dialect struct {
+	dsn         func(dsn string, timeout time.Duration) (string, error) // applies connection timeouts
+	session     func(timeout time.Duration) []string                    // statements run before the transaction
+	transaction func(timeout time.Duration) []string                    // statements run at the start of the transaction
+}
+
+// dialects lists the supported drivers, which are only offered when compiled in.
+var dialects = map[string]dialect{
+	"mysql": {
+		dsn: func(dsn string, timeout time.Duration) (string, error) {
+			cfg, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				return "", err
+			}
+			cfg.Timeout = timeout
+			cfg.ReadTimeout = timeout
+			cfg.WriteTimeout = timeout
+			cfg.MultiStatements = false
+			cfg.AllowAllFiles = false
+			return cfg.FormatDSN(), nil
+		},
+		session: func(time.Duration) []string {
+			return []string{"SET SESSION TRANSACTION READ ONLY"}
+		},
+	},
+	"postgres": {
+		transaction: func(timeout time.Duration) []string {
+			return []string{
+				"SET TRANSACTION READ ONLY",
+				fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout/time.Millisecond),
+			}
+		},
+	},
+	"sqlite3": {
+		session: func(time.Duration) []string {
+			return []string{"PRAGMA query_only = ON"}
+		},
+	},
+}
+
+// Drivers returns the names of the supported drivers that are available.
+func Drivers() []string {
+	available := map[string]bool{}
+	for _, d := range sql.Drivers() {
+		available[d] = true
+	}
+
+	drivers := []string{}
+	for d := range dialects {
+		if available[d] {
+			drivers = append(drivers, d)
+		}
+	}
+	sort.Strings(drivers)
+
+	return drivers
+}
+
+func configHandle() string {
+	meta := (&Provider{}).Meta()
+	return meta.ConfigHandle()
+}
+
+// Sources returns the registered data sources.
+func Sources() []Source {
+	sources := []Source{}
+	json.Unmarshal([]byte(request.ConfigString(configHandle(), "sources")), &sources)
+	return sources
+}
+
+// Masked returns the data sources without their DSNs.
+func Masked(sources []Source) []Source {
+	masked := []Source{}
+	for _, s := range sources {
+		s.DSN = provider.SecretReplacement
+		masked = append(masked, s)
+	}
+	return masked
+}
+
+// SaveSources replaces the registered data sources.
+// A masked DSN keeps what was saved before for a source of the same name.
+func SaveSources(sources []Source) error {
+	sources, err := merge(Sources(), sources)
+	if err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(struct {
+		Sources []Source `json:"sources"`
+	}{sources})
+	if err != nil {
+		return err
+	}
+
+	return request.ConfigSet(configHandle(), string(j))
+}
+
+// merge checks the updated data sources, filling in masked DSNs from those saved before.
+func merge(saved, updated []Source) ([]Source, error) {
+	previous := map[string]Source{}
+	for _, s := range saved {
+		previous[s.Name] = s
+	}
+
+	drivers := map[string]bool{}
+	for _, d := range Drivers() {
+		drivers[d] = true
+	}
+
+	names := map[string]bool{}
+	sources := []Source{}
+
+	for _, s := range updated {
+		s.Name = strings.TrimSpace(s.Name)
+		s.DSN = strings.TrimSpace(s.DSN)
+
+		if len(s.Name) == 0 {
+			return nil, fmt.Errorf("data source name missing")
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("data source %s is named twice", s.Name)
+		}
+		names[s.Name] = true
+
+		if !drivers[s.Driver] {
+			return nil, fmt.Errorf("data source %s uses unsupported driver '%s'", s.Name, s.Driver)
+		}
+
+		if s.DSN == provider.SecretReplacement {
+			s.DSN = ""
+			if p, ok := previous[s.Name]; ok && p.Driver == s.Driver {
+				s.DSN = p.DSN
+			}
+		}
+		if len(s.DSN) == 0 {
+			return nil, fmt.Errorf("data source %s needs a connection string", s.Name)
+		}
+
+		sources = append(sources, s)
+	}
+
+	return sources, nil
+}
+
+// find returns the data source with the given name.
+func find(sources []Source, name string) (Source, bool) {
+	for _, s := range sources {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Source{}, false
+}
@@ -0,0 +1,170 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package sql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/documize/community/core/log"
+	"github.com/documize/community/core/section/provider"
+)
+
+const me = "sql"
+
+// Provider represents read-only SQL queries against data sources registered by the administrator
+type Provider struct {
+}
+
+// Meta describes us
+func (*Provider) Meta() provider.TypeMeta {
+	section := provider.TypeMeta{}
+
+	section.ID = "c1e7a3b9-58d2-4f06-9e4b-2a6d0f8c3e15"
+	section.Title = "SQL Query"
+	section.Description = "Live table from a read-only database query"
+	section.ContentType = "sql"
+	section.PageType = "tab"
+
+	return section
+}
+
+// Command lists the data sources and runs queries for the editor.
+func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if len(method) == 0 {
+		provider.WriteMessage(w, me, "missing method name")
+		return
+	}
+
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	var config = sqlConfig{}
+	err = json.Unmarshal(body, &config)
+
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad config")
+		return
+	}
+
+	config.Clean()
+
+	switch method {
+	case "sources":
+		// authors only see the names
+		names := []string{}
+		for _, s := range Sources() {
+			names = append(names, s.Name)
+		}
+		provider.WriteJSON(w, names)
+
+	case "query":
+		source, ok := find(Sources(), config.Source)
+		if !ok {
+			provider.WriteMessage(w, me, "Unknown data source")
+			return
+		}
+
+		var result preview
+		result.Data, err = run(source, config.Query, config.Max, time.Duration(config.Timeout)*time.Second)
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		provider.WriteJSON(w, result)
+
+	default:
+		provider.WriteMessage(w, me, "unknown method name "+method)
+	}
+}
+
+// Render converts the query results into an HTML table.
+func (*Provider) Render(ctx *provider.Context, config, data string) string {
+	var c = sqlConfig{}
+	var d = sqlData{}
+
+	json.Unmarshal([]byte(config), &c)
+	json.Unmarshal([]byte(data), &d)
+
+	c.Clean()
+
+	return render(c, d)
+}
+
+// Refresh runs the query again, against the data source as currently registered.
+func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
+	var c = sqlConfig{}
+	err := json.Unmarshal([]byte(config), &c)
+
+	if err != nil {
+		return data
+	}
+
+	c.Clean()
+
+	source, ok := find(Sources(), c.Source)
+	if !ok {
+		log.ErrorString("sql refresh: unknown data source " + c.Source)
+		ctx.RefreshFailed(fmt.Errorf("unknown data source %s", c.Source))
+		return data
+	}
+
+	result, err := run(source, c.Query, c.Max, time.Duration(c.Timeout)*time.Second)
+
+	if err != nil {
+		log.Error("sql refresh: unable to run query on "+c.Source, err)
+		ctx.RefreshFailed(err)
+		return data
+	}
+
+	j, err := json.Marshal(result)
+
+	if err != nil {
+		log.Error("unable to marshal sql results", err)
+		return data
+	}
+
+	return string(j)
+}
+
+func render(c sqlConfig, d sqlData) string {
+	payload := sqlRender{Columns: d.Columns, Rows: d.Rows, Truncated: d.Truncated}
+
+	if len(payload.Rows) > c.Max {
+		payload.Rows = payload.Rows[:c.Max]
+		payload.Truncated = true
+	}
+
+	payload.Count = len(payload.Rows)
+	payload.HasData = payload.Count > 0
+
+	t := template.New("sql")
+	t, _ = t.Parse(renderTemplate)
+
+	buffer := new(bytes.Buffer)
+	t.Execute(buffer, payload)
+
+	return buffer.String()
+}
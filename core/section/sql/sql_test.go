@@ -0,0 +1,211 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package sql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/documize/community/core/section/provider"
+)
+
+// standIn is a database driver that records the statements it is given and answers queries with fixed rows.
+type standIn struct {
+	log []string
+}
+
+var db = &standIn{}
+
+func init() {
+	sql.Register("standin", db)
+	dialects["standin"] = dialect{
+		session:     func(time.Duration) []string { return []string{"SESSION READ ONLY"} },
+		transaction: func(t time.Duration) []string { return []string{"TRANSACTION TIMEOUT " + t.String()} },
+	}
+}
+
+func (d *standIn) Open(name string) (driver.Conn, error) {
+	if name != "dsn" {
+		return nil, errors.New("bad dsn")
+	}
+	return &standInConn{d}, nil
+}
+
+type standInConn struct{ d *standIn }
+
+func (c *standInConn) Prepare(query string) (driver.Stmt, error) {
+	return &standInStmt{c.d, query}, nil
+}
+func (c *standInConn) Close() error { return nil }
+func (c *standInConn) Begin() (driver.Tx, error) {
+	c.d.log = append(c.d.log, "BEGIN")
+	return c, nil
+}
+func (c *standInConn) Commit() error {
+	c.d.log = append(c.d.log, "COMMIT")
+	return nil
+}
+func (c *standInConn) Rollback() error {
+	c.d.log = append(c.d.log, "ROLLBACK")
+	return nil
+}
+
+type standInStmt struct {
+	d     *standIn
+	query string
+}
+
+func (s *standInStmt) Close() error  { return nil }
+func (s *standInStmt) NumInput() int { return -1 }
+func (s *standInStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.log = append(s.d.log, s.query)
+	return driver.RowsAffected(0), nil
+}
+func (s *standInStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.log = append(s.d.log, s.query)
+	if strings.Contains(s.query, "missing") {
+		return nil, errors.New("no such table: missing")
+	}
+	return &standInRows{values: [][]driver.Value{
+		{int64(1), []byte("<b>alpha</b>"), time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC), 1.5},
+		{int64(2), nil, time.Date(2016, 5, 2, 13, 4, 5, 0, time.UTC), nil},
+		{int64(3), "gamma", nil, 2.0},
+	}}, nil
+}
+
+type standInRows struct {
+	values [][]driver.Value
+	next   int
+}
+
+func (r *standInRows) Columns() []string { return []string{"id", "name", "created", "score"} }
+func (r *standInRows) Close() error      { return nil }
+func (r *standInRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+func TestRunAndRender(t *testing.T) {
+	db.log = nil
+	s := Source{Name: "ops", Driver: "standin", DSN: "dsn"}
+
+	data, err := run(s, "  SELECT * FROM builds; ", 2, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"SESSION READ ONLY", "BEGIN", "TRANSACTION TIMEOUT 5s", "SELECT * FROM builds", "ROLLBACK"}
+	if strings.Join(db.log, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v, got %v", want, db.log)
+	}
+
+	if len(data.Rows) != 2 || !data.Truncated || *data.Rows[0][2] != "2016-05-01" || *data.Rows[1][2] != "2016-05-02 13:04:05" || data.Rows[1][1] != nil {
+		j, _ := json.Marshal(data)
+		t.Fatalf("rows not read: %s", j)
+	}
+
+	j, _ := json.Marshal(data)
+	cfg, _ := json.Marshal(sqlConfig{Source: "ops", Query: "SELECT * FROM builds", Max: 10})
+
+	html := (&Provider{}).Render(nil, string(cfg), string(j))
+	for _, want := range []string{
+		`<th class="bordered">id</th><th class="bordered">name</th><th class="bordered">created</th><th class="bordered">score</th>`,
+		`<td class="bordered">1</td><td class="bordered">&lt;b&gt;alpha&lt;/b&gt;</td><td class="bordered">2016-05-01</td><td class="bordered">1.5</td>`,
+		`<td class="bordered">2</td><td class="bordered"></td>`,
+		`Showing the first 2 rows.`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %s in %s", want, html)
+		}
+	}
+
+	if _, err = run(s, "SELECT * FROM missing", 10, time.Second); err == nil || err.Error() != "no such table: missing" {
+		t.Errorf("expected the query error, got %v", err)
+	}
+	if _, err = run(Source{Name: "x", Driver: "oracle", DSN: "dsn"}, "SELECT 1", 10, time.Second); err == nil {
+		t.Error("expected an error for an unknown driver")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for query, want := range map[string]string{
+		"select id from t;": "select id from t",
+		"-- builds\nWITH x AS (SELECT 1) SELECT * FROM x": "-- builds\nWITH x AS (SELECT 1) SELECT * FROM x",
+		"SELECT 'a;b', \"update\", `drop` FROM t ;  ":     "SELECT 'a;b', \"update\", `drop` FROM t",
+		"SELECT 'it''s', 'x\\'y' /* set */ FROM t":        "SELECT 'it''s', 'x\\'y' /* set */ FROM t",
+		"SHOW TABLES": "SHOW TABLES",
+	} {
+		got, err := validate(query)
+		if err != nil || got != want {
+			t.Errorf("%q: expected %q, got %q %v", query, want, got, err)
+		}
+	}
+
+	for _, query := range []string{
+		"", "  ;  ", "DELETE FROM t", "SELECT 1; DROP TABLE t", "SELECT * INTO OUTFILE '/tmp/x' FROM t",
+		"SELECT * FROM t FOR UPDATE", "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d",
+		"SELECT 1 /*!50000 INTO OUTFILE '/tmp/x' */", "SELECT 'open", "SELECT 1 /* open", "SET x = 1",
+	} {
+		if _, err := validate(query); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	saved := []Source{{Name: "ops", Driver: "standin", DSN: "secret"}}
+
+	sources, err := merge(saved, []Source{
+		{Name: " ops ", Driver: "standin", DSN: provider.SecretReplacement},
+		{Name: "new", Driver: "standin", DSN: "dsn"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[0].DSN != "secret" || sources[1].DSN != "dsn" {
+		t.Errorf("sources not merged: %+v", sources)
+	}
+	if m := Masked(sources); m[0].DSN != provider.SecretReplacement || sources[0].DSN != "secret" {
+		t.Errorf("sources not masked: %+v", m)
+	}
+
+	for _, bad := range [][]Source{
+		{{Name: "", Driver: "standin", DSN: "dsn"}},
+		{{Name: "a", Driver: "standin", DSN: "dsn"}, {Name: "a", Driver: "standin", DSN: "dsn"}},
+		{{Name: "a", Driver: "oracle", DSN: "dsn"}},
+		{{Name: "a", Driver: "standin", DSN: provider.SecretReplacement}},
+	} {
+		if _, err := merge(saved, bad); err == nil {
+			t.Errorf("expected an error for %+v", bad)
+		}
+	}
+}
+
+func TestMySQLTimeout(t *testing.T) {
+	dsn, err := dialects["mysql"].dsn("reader:p@ss@tcp(db:3306)/ops?multiStatements=true", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dsn, "readTimeout=5s") || !strings.Contains(dsn, "timeout=5s") || strings.Contains(dsn, "multiStatements") {
+		t.Errorf("unexpected dsn %s", dsn)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/documize/community/core/stringutil"
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// maxRows caps the number of rows imported into a section.
+	maxRows = 5000
+	// maxSpan caps the columns a merged cell is taken to span, as browsers do.
+	maxSpan = 1000
+)
+
+// readCSV returns the records of comma, semicolon or tab separated data.
+func readCSV(data []byte) (rows [][]string, err error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter(data)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	for len(rows) < maxRows {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, record)
+	}
+
+	return rows, nil
+}
+
+// delimiter guesses the field separator from the first line.
+func delimiter(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+
+	best, count := ',', bytes.Count(line, []byte{','})
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(line, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+
+	return best
+}
+
+// tableHTML returns the rows as the HTML used by the section editor.
+// The first row becomes the table heading when header is true.
+func tableHTML(rows [][]string, header bool) string {
+	rows = trim(rows)
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var b bytes.Buffer
+	b.WriteString(`<table class="wysiwyg-table" style="width: 100%;">`)
+
+	if header && len(rows) > 0 {
+		b.WriteString("<thead>")
+		writeRow(&b, rows[0], columns, "th")
+		b.WriteString("</thead>")
+		rows = rows[1:]
+	}
+
+	b.WriteString("<tbody>")
+	for _, row := range rows {
+		writeRow(&b, row, columns, "td")
+	}
+	b.WriteString("</tbody></table>")
+
+	return b.String()
+}
+
+func writeRow(b *bytes.Buffer, row []string, columns int, tag string) {
+	b.WriteString("<tr>")
+	for i := 0; i < columns; i++ {
+		value := ""
+		if i < len(row) {
+			value = strings.TrimSpace(row[i])
+		}
+
+		b.WriteString("<" + tag + ">")
+		if len(value) == 0 {
+			b.WriteString("<br>")
+		} else {
+			b.WriteString(strings.Replace(html.EscapeString(value), "\n", "<br>", -1))
+		}
+		b.WriteString("</" + tag + ">")
+	}
+	b.WriteString("</tr>")
+}
+
+// trim removes trailing empty rows and columns, and caps the number of rows.
+func trim(rows [][]string) [][]string {
+	if len(rows) > maxRows {
+		rows = rows[:maxRows]
+	}
+
+	empty := func(s string) bool { return len(strings.TrimSpace(s)) == 0 }
+
+	for len(rows) > 0 {
+		last := rows[len(rows)-1]
+		blank := true
+		for _, v := range last {
+			if !empty(v) {
+				blank = false
+				break
+			}
+		}
+		if !blank {
+			break
+		}
+		rows = rows[:len(rows)-1]
+	}
+
+	out := make([][]string, len(rows))
+	for i, row := range rows {
+		n := len(row)
+		for n > 0 && empty(row[n-1]) {
+			n--
+		}
+		out[i] = row[:n]
+	}
+
+	return out
+}
+
+// CSV returns the first table found in section HTML as comma separated values.
+// Merged cells are followed by empty values so that columns line up.
+func CSV(body string) ([]byte, error) {
+	doc, err := nethtml.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	t := stringutil.FindElement(doc, atom.Table)
+	if t == nil {
+		return nil, errors.New("section contains no table")
+	}
+
+	var rows [][]string
+	var walk func(n *nethtml.Node)
+	walk = func(n *nethtml.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != nethtml.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			case atom.Tr:
+				rows = append(rows, cells(c))
+			}
+		}
+	}
+	walk(t)
+
+	var out bytes.Buffer
+	w := csv.NewWriter(&out)
+	if err = w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func cells(tr *nethtml.Node) (row []string) {
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != nethtml.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+			continue
+		}
+
+		row = append(row, strings.TrimSpace(text(c)))
+
+		span := 1
+		for _, a := range c.Attr {
+			if a.Key == "colspan" {
+				span, _ = strconv.Atoi(a.Val)
+			}
+		}
+		if span > maxSpan {
+			span = maxSpan
+		}
+		for i := 1; i < span; i++ {
+			row = append(row, "")
+		}
+	}
+	return
+}
+
+// text returns the text of a cell, with line breaks between blocks.
+func text(n *nethtml.Node) string {
+	if n.Type == nethtml.TextNode {
+		return n.Data
+	}
+	if n.Type == nethtml.ElementNode && n.DataAtom == atom.Br {
+		return "\n"
+	}
+
+	var b bytes.Buffer
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == nethtml.ElementNode && (c.DataAtom == atom.P || c.DataAtom == atom.Div || c.DataAtom == atom.Li) && b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(text(c))
+	}
+	return b.String()
+}
@@ -12,11 +12,29 @@
 package table
 
 import (
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"path"
+	"strings"
 
+	"github.com/documize/community/core/log"
 	"github.com/documize/community/core/section/provider"
 )
 
+const me = "table"
+
+// maxUpload caps the size of an imported spreadsheet.
+const maxUpload = 10 << 20
+
+// tableImport is the result of importing a spreadsheet.
+type tableImport struct {
+	Sheets []string `json:"sheets"` // sheets within a workbook, empty for CSV
+	Sheet  string   `json:"sheet"`  // sheet imported
+	Rows   int      `json:"rows"`
+	HTML   string   `json:"html"`
+}
+
 // Provider represents Table
 type Provider struct {
 }
@@ -35,9 +53,81 @@ func (*Provider) Meta() provider.TypeMeta {
 	return section
 }
 
-// Command stub.
+// Command imports CSV and XLSX files, uploaded as "attachment", into table HTML.
+// Query parameters choose the workbook sheet and whether the first row is a heading.
 func (*Provider) Command(ctx *provider.Context, w http.ResponseWriter, r *http.Request) {
-	provider.WriteEmpty(w)
+	query := r.URL.Query()
+	method := query.Get("method")
+
+	if method != "import" {
+		provider.WriteMessage(w, me, "unknown method name "+method)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUpload)
+	filedata, filename, err := r.FormFile("attachment")
+	if err != nil {
+		provider.WriteMessage(w, me, "Missing attachment")
+		return
+	}
+	defer filedata.Close()
+
+	data, err := ioutil.ReadAll(filedata)
+	if err != nil {
+		provider.WriteMessage(w, me, "Bad payload")
+		return
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	if len(format) == 0 {
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(filename.Filename)), ".")
+	}
+
+	result, err := importTable(data, format, query.Get("sheet"), query.Get("header") != "false")
+	if err != nil {
+		log.Info("table import failed: " + err.Error())
+		provider.WriteMessage(w, me, err.Error())
+		return
+	}
+
+	provider.WriteJSON(w, result)
+}
+
+// importTable converts spreadsheet data into table HTML.
+func importTable(data []byte, format, sheet string, header bool) (result tableImport, err error) {
+	var rows [][]string
+
+	switch format {
+	case "csv", "tsv", "txt":
+		rows, err = readCSV(data)
+	case "xlsx":
+		var wb *workbook
+		wb, err = openWorkbook(data)
+		if err != nil {
+			return
+		}
+		result.Sheets = wb.names()
+		result.Sheet, rows, err = wb.rows(sheet)
+	default:
+		err = errors.New("unsupported file type " + format)
+	}
+
+	if err != nil {
+		return
+	}
+
+	result.HTML = tableHTML(rows, header)
+	if len(result.HTML) == 0 {
+		err = errors.New("no data to import")
+		return
+	}
+
+	result.Rows = len(trim(rows))
+	if result.Sheets == nil {
+		result.Sheets = []string{}
+	}
+
+	return
 }
 
 // Render sends back data as-is (HTML).
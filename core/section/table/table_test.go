@@ -0,0 +1,178 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package table
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestImportCSV(t *testing.T) {
+	in := "\xef\xbb\xbfName;Score;\n<b>Ann</b>;\"1;5\";\n;;\n"
+
+	result, err := importTable([]byte(in), "csv", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<table class="wysiwyg-table" style="width: 100%;"><thead><tr><th>Name</th><th>Score</th></tr></thead>` +
+		`<tbody><tr><td>&lt;b&gt;Ann&lt;/b&gt;</td><td>1;5</td></tr></tbody></table>`
+	if result.HTML != want {
+		t.Errorf("got %s", result.HTML)
+	}
+	if result.Rows != 2 || len(result.Sheets) != 0 {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	result, err = importTable([]byte("a,b\n1,2\n"), "csv", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result.HTML, "<thead>") || !strings.Contains(result.HTML, "<tbody><tr><td>a</td>") {
+		t.Errorf("header not left in body: %s", result.HTML)
+	}
+}
+
+func TestImportXLSX(t *testing.T) {
+	data := workbookFile(t)
+
+	result, err := importTable(data, "xlsx", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(result.Sheets, ",") != "Summary,Detail" || result.Sheet != "Summary" {
+		t.Errorf("unexpected sheets %+v", result)
+	}
+
+	want := `<thead><tr><th>Item</th><th><br></th><th>Due</th></tr></thead>` +
+		`<tbody><tr><td>Rich text</td><td>2.5</td><td>2017-03-01</td></tr><tr><td><br></td><td><br></td><td><br></td></tr><tr><td>TRUE</td><td>inline</td><td><br></td></tr></tbody>`
+	if !strings.Contains(result.HTML, want) {
+		t.Errorf("got %s", result.HTML)
+	}
+
+	result, err = importTable(data, "xlsx", "Detail", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Sheet != "Detail" || !strings.Contains(result.HTML, "<tbody><tr><td>Item</td></tr></tbody>") {
+		t.Errorf("sheet not chosen: %+v", result)
+	}
+
+	if _, err = importTable(data, "xlsx", "Missing", false); err == nil {
+		t.Error("expected error for missing sheet")
+	}
+	if _, err = importTable([]byte("x"), "xlsx", "", false); err == nil {
+		t.Error("expected error for bad workbook")
+	}
+}
+
+func TestCSV(t *testing.T) {
+	body := `<table class="wysiwyg-table"><thead><tr><th>Name</th><th>Notes</th><th>Total</th></tr></thead>` +
+		`<tbody><tr><td>Ann</td><td><p>one</p><p>two, "three"</p></td><td>5</td></tr>` +
+		`<tr><td colspan="2">Sum</td><td><br></td></tr></tbody></table>`
+
+	out, err := CSV(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Name,Notes,Total\nAnn,\"one\ntwo, \"\"three\"\"\",5\nSum,,\n"
+	if string(out) != want {
+		t.Errorf("got %q", out)
+	}
+
+	if _, err = CSV("<p>no table</p>"); err == nil {
+		t.Error("expected error without table")
+	}
+}
+
+func TestIsDateFormat(t *testing.T) {
+	for code, want := range map[string]bool{
+		"General":       false,
+		"0.00":          false,
+		"[Red]0.00":     false,
+		`"day "0`:       false,
+		"dd/mm/yyyy":    true,
+		"[h]:mm":        true,
+		"[$-409]mmm-yy": true,
+	} {
+		if isDateFormat(code) != want {
+			t.Errorf("isDateFormat(%q) != %v", code, want)
+		}
+	}
+}
+
+func TestImportLimits(t *testing.T) {
+	sheet := `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>first</t></is></c><c r="XFD1"><v>1</v></c><c r="ZZZZZZZZ1"><v>2</v></c></row>` +
+		`<row r="2000000000"><c r="A2000000000"><v>3</v></c></row></sheetData></worksheet>`
+
+	result, err := importTable(workbookFile(t, sheet), "xlsx", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 1 || strings.Count(result.HTML, "<td>") != maxColumns || strings.Contains(result.HTML, ">2<") {
+		t.Errorf("limits not applied: %d rows, %d cells", result.Rows, strings.Count(result.HTML, "<td>"))
+	}
+
+	result, err = importTable([]byte(strings.Repeat("x\n", maxRows+10)), "csv", "", false)
+	if err != nil || result.Rows != maxRows {
+		t.Errorf("expected %d rows, got %d %v", maxRows, result.Rows, err)
+	}
+
+	out, err := CSV(`<table><tr><td colspan="2000000000">wide</td></tr></table>`)
+	if err != nil || strings.Count(string(out), ",") != maxSpan-1 {
+		t.Errorf("colspan not capped: %d columns %v", strings.Count(string(out), ",")+1, err)
+	}
+}
+
+// workbookFile returns a minimal workbook with two sheets, the first replaced by sheet when given.
+func workbookFile(t *testing.T, sheet ...string) []byte {
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Detail" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="worksheet" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>Item</t></si><si><r><t>Rich </t></r><r><t>text</t></r></si><si><t>Due</t></si></sst>`,
+		"xl/styles.xml":        `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><cellXfs><xf numFmtId="0"/><xf numFmtId="14"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>2</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>1</v></c><c r="B2"><v>2.5</v></c><c r="C2" s="1"><v>42795</v></c></row>` +
+			`<row r="4"><c r="A4" t="b"><v>1</v></c><c r="B4" t="inlineStr"><is><t>inline</t></is></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData><row r="1"><c r="A1" t="s"><v>0</v></c></row></sheetData></worksheet>`,
+	}
+
+	if len(sheet) > 0 {
+		parts["xl/worksheets/sheet1.xml"] = sheet[0]
+	}
+
+	var b bytes.Buffer
+	z := zip.NewWriter(&b)
+	for name, content := range parts {
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,343 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package table
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxColumns is the widest sheet Excel allows, columns beyond it are ignored.
+	maxColumns = 16384
+	// maxPart caps the uncompressed size of a workbook part, which keeps a zip bomb from exhausting memory.
+	maxPart = 64 << 20
+)
+
+// workbook is an Excel workbook, read only as far as needed to get at cell values.
+type workbook struct {
+	files    map[string]*zip.File
+	sheets   []xlsxSheetRef
+	targets  map[string]string // relationship ID to worksheet part
+	strings  []string
+	dates    map[int]bool // cell style index to whether it formats a date
+	date1904 bool
+}
+
+type xlsxWorkbook struct {
+	Properties struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []xlsxSheetRef `xml:"sheets>sheet"`
+}
+
+type xlsxSheetRef struct {
+	Name string `xml:"name,attr"`
+	RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.T
+	for _, r := range t.R {
+		s += r.T
+	}
+	return s
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	Xfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int        `xml:"r,attr"`
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Style  int      `xml:"s,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+// openWorkbook reads the workbook structure, shared strings and date styles.
+func openWorkbook(data []byte) (wb *workbook, err error) {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("not an XLSX file")
+	}
+
+	wb = &workbook{files: make(map[string]*zip.File), targets: make(map[string]string), dates: make(map[int]bool)}
+	for _, f := range z.File {
+		wb.files[f.Name] = f
+	}
+
+	var book xlsxWorkbook
+	if err = wb.decode("xl/workbook.xml", &book); err != nil {
+		return nil, err
+	}
+	wb.sheets = book.Sheets
+	wb.date1904 = book.Properties.Date1904
+
+	var rels xlsxRelationships
+	if err = wb.decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	for _, r := range rels.Relationships {
+		target := r.Target
+		if strings.HasPrefix(target, "/") {
+			target = target[1:]
+		} else {
+			target = path.Join("xl", target)
+		}
+		wb.targets[r.ID] = target
+	}
+
+	if _, ok := wb.files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStrings
+		if err = wb.decode("xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Items {
+			wb.strings = append(wb.strings, si.String())
+		}
+	}
+
+	if _, ok := wb.files["xl/styles.xml"]; ok {
+		var styles xlsxStyles
+		if err = wb.decode("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+		custom := make(map[int]bool)
+		for _, f := range styles.NumFmts {
+			custom[f.ID] = isDateFormat(f.Code)
+		}
+		for i, xf := range styles.Xfs {
+			id := xf.NumFmtID
+			wb.dates[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id]
+		}
+	}
+
+	return wb, nil
+}
+
+// names returns the sheet names in workbook order.
+func (wb *workbook) names() (names []string) {
+	for _, s := range wb.sheets {
+		names = append(names, s.Name)
+	}
+	return
+}
+
+// rows returns the cell values of the named sheet, or the first sheet when no name is given.
+func (wb *workbook) rows(name string) (sheet string, rows [][]string, err error) {
+	if len(wb.sheets) == 0 {
+		return "", nil, errors.New("workbook has no sheets")
+	}
+
+	ref := wb.sheets[0]
+	if len(name) > 0 {
+		found := false
+		for _, s := range wb.sheets {
+			if s.Name == name {
+				ref, found = s, true
+				break
+			}
+		}
+		if !found {
+			return "", nil, fmt.Errorf("sheet %s not found", name)
+		}
+	}
+
+	var ws xlsxWorksheet
+	if err = wb.decode(wb.targets[ref.RID], &ws); err != nil {
+		return
+	}
+
+	for _, row := range ws.Rows {
+		if row.R > maxRows || len(rows) >= maxRows {
+			break
+		}
+
+		// rows without content are left out of the file
+		for row.R > len(rows)+1 {
+			rows = append(rows, nil)
+		}
+
+		var values []string
+		for i, c := range row.Cells {
+			col := i
+			if len(c.Ref) > 0 {
+				col = column(c.Ref)
+			}
+			if col < 0 || col >= maxColumns {
+				continue
+			}
+			for len(values) < col {
+				values = append(values, "")
+			}
+			values = append(values, wb.value(c))
+		}
+		rows = append(rows, values)
+	}
+
+	return ref.Name, rows, nil
+}
+
+// value returns the text of a cell as it would be displayed, ignoring number formatting other than dates.
+func (wb *workbook) value(c xlsxCell) string {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(wb.strings) {
+			return ""
+		}
+		return wb.strings[i]
+	case "inlineStr":
+		return c.Inline.String()
+	case "b":
+		if c.Value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "str", "e", "d":
+		return c.Value
+	}
+
+	if wb.dates[c.Style] {
+		if f, err := strconv.ParseFloat(c.Value, 64); err == nil {
+			return wb.date(f)
+		}
+	}
+
+	return c.Value
+}
+
+// date converts an Excel serial date into text.
+func (wb *workbook) date(serial float64) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if wb.date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	days, frac := math.Modf(serial)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(math.Round(frac*86400)) * time.Second)
+
+	switch {
+	case days == 0 && !wb.date1904:
+		return t.Format("15:04")
+	case frac == 0:
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func (wb *workbook) decode(name string, v interface{}) error {
+	f, ok := wb.files[name]
+	if !ok {
+		return fmt.Errorf("XLSX part %s is missing", name)
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxPart+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > maxPart {
+		return fmt.Errorf("XLSX part %s is too large", name)
+	}
+
+	return xml.Unmarshal(data, v)
+}
+
+// column returns the zero based column of a cell reference such as AB12, or maxColumns when it is past the last.
+func column(ref string) (col int) {
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if col = col*26 + int(r-'A') + 1; col > maxColumns {
+			return maxColumns
+		}
+	}
+	return col - 1
+}
+
+// isDateFormat reports whether a custom number format displays a date or time.
+func isDateFormat(code string) bool {
+	quoted := false
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case c == '[':
+			// skip colours and conditions, but elapsed times such as [h] are dates
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				return false
+			}
+			inner := strings.ToLower(code[i+1 : i+end])
+			if inner == "h" || inner == "hh" || inner == "m" || inner == "mm" || inner == "s" || inner == "ss" {
+				return true
+			}
+			i += end
+		case strings.IndexByte("dmyhsDMYHS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
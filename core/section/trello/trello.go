@@ -183,6 +183,7 @@ func (*Provider) Refresh(ctx *provider.Context, config, data string) string {
 	refreshed, err := getCards(c)
 
 	if err != nil {
+		ctx.RefreshFailed(err)
 		return data
 	}
 
@@ -0,0 +1,43 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package stringutil
+
+import (
+	"regexp"
+)
+
+var placeholder = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+var placeholderName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsPlaceholderName tells if name can be written as {{name}} and filled in by Fill.
+func IsPlaceholderName(name string) bool {
+	return len(name) <= 50 && placeholderName.MatchString(name)
+}
+
+// Fill replaces each {{name}} in s, spaces inside the braces allowed, with values[name]
+// passed through escape when that is not nil. Placeholders without a value are left as they are.
+func Fill(s string, values map[string]string, escape func(string) string) string {
+	if len(values) == 0 {
+		return s
+	}
+
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		v, ok := values[placeholder.FindStringSubmatch(m)[1]]
+		if !ok {
+			return m
+		}
+		if escape != nil {
+			v = escape(v)
+		}
+		return v
+	})
+}
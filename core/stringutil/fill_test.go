@@ -0,0 +1,49 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package stringutil
+
+import (
+	"html"
+	"testing"
+)
+
+func TestFill(t *testing.T) {
+	values := map[string]string{"service": "<api>", "date": "2017-03-01"}
+
+	tests := []struct {
+		in, out string
+		escape  func(string) string
+	}{
+		{"{{service}} down on {{ date }}", "<api> down on 2017-03-01", nil},
+		{"<p>{{service}}</p>", "<p>&lt;api&gt;</p>", html.EscapeString},
+		{"{{owner}} {{#each}} {service}", "{{owner}} {{#each}} {service}", nil},
+		{"", "", nil},
+	}
+
+	for _, test := range tests {
+		if got := Fill(test.in, values, test.escape); got != test.out {
+			t.Errorf("Fill(%q) = %q, want %q", test.in, got, test.out)
+		}
+	}
+
+	if Fill("{{service}}", nil, nil) != "{{service}}" {
+		t.Error("expected no change without values")
+	}
+}
+
+func TestIsPlaceholderName(t *testing.T) {
+	for name, want := range map[string]bool{"service": true, "_id2": true, "2nd": false, "a-b": false, "": false} {
+		if IsPlaceholderName(name) != want {
+			t.Errorf("IsPlaceholderName(%q) != %v", name, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package stringutil
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestNode(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<p>x</p><table><tr><td lang="en">one<br>two <b>three</b></td></tr></table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	td := FindElement(doc, atom.Td)
+	if td == nil {
+		t.Fatal("cell not found")
+	}
+	if got := NodeText(td); got != "one\ntwo three" {
+		t.Errorf("unexpected text %q", got)
+	}
+	if NodeAttr(td, "lang") != "en" || NodeAttr(td, "class") != "" {
+		t.Errorf("unexpected attributes %v", td.Attr)
+	}
+	if FindElement(doc, atom.Img) != nil {
+		t.Error("found an element that is not there")
+	}
+}
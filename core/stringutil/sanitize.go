@@ -0,0 +1,157 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package stringutil
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Sanitizer keeps the parts of untrusted HTML, such as a feed or an API response, that are safe to show.
+// Scripts, styles, event handlers and links other than to web pages and mail addresses are always removed.
+type Sanitizer struct {
+	Elements   map[atom.Atom]bool // kept, any other element is replaced by its content
+	Attributes map[string]bool    // kept on the elements, besides the href of links and src of images
+	NewWindow  bool               // links open in a new window
+}
+
+// dropped lists the elements removed along with their content.
+var dropped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Frame: true, atom.Frameset: true,
+	atom.Object: true, atom.Embed: true, atom.Applet: true, atom.Form: true, atom.Input: true,
+	atom.Button: true, atom.Select: true, atom.Textarea: true, atom.Noscript: true, atom.Head: true,
+	atom.Title: true, atom.Meta: true, atom.Link: true, atom.Base: true, atom.Svg: true, atom.Math: true,
+}
+
+// void lists the elements that have no content or end tag.
+var void = map[atom.Atom]bool{
+	atom.Br: true, atom.Col: true, atom.Hr: true, atom.Img: true,
+}
+
+// Sanitize returns the HTML with only the elements and attributes the sanitizer keeps.
+func (s Sanitizer) Sanitize(h string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(h), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return html.EscapeString(h)
+	}
+
+	var b bytes.Buffer
+	for _, n := range nodes {
+		s.write(&b, n)
+	}
+	return b.String()
+}
+
+func (s Sanitizer) write(b *bytes.Buffer, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			s.write(b, c)
+		}
+		return
+	}
+
+	if dropped[n.DataAtom] {
+		return
+	}
+
+	keep := s.Elements[n.DataAtom]
+	if keep {
+		b.WriteString("<" + n.Data)
+		link := false
+		for _, a := range n.Attr {
+			value := a.Val
+			switch {
+			case len(a.Namespace) > 0:
+				continue
+			case a.Key == "href" && n.DataAtom == atom.A:
+				value = SafeURL(value, "http", "https", "mailto")
+				link = len(value) > 0
+			case a.Key == "src" && n.DataAtom == atom.Img:
+				value = SafeURL(value, "http", "https")
+			case !s.Attributes[a.Key]:
+				continue
+			}
+			if len(value) > 0 {
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(value) + `"`)
+			}
+		}
+		if link {
+			b.WriteString(` rel="nofollow noopener"`)
+			if s.NewWindow {
+				b.WriteString(` target="_blank"`)
+			}
+		}
+		b.WriteString(">")
+	}
+
+	if void[n.DataAtom] {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		s.write(b, c)
+	}
+	if keep {
+		b.WriteString("</" + n.Data + ">")
+	}
+}
+
+// SafeURL returns the URL when it uses one of the schemes, dropping others such as javascript.
+func SafeURL(s string, schemes ...string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+	for _, scheme := range schemes {
+		if strings.ToLower(u.Scheme) == scheme {
+			return u.String()
+		}
+	}
+	return ""
+}
+
+// PlainText returns the text of untrusted HTML, leaving out scripts and styles, with runs of whitespace collapsed.
+func PlainText(h string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(h), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return strings.TrimSpace(h)
+	}
+
+	var b bytes.Buffer
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && dropped[n.DataAtom] {
+			return
+		}
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		b.WriteString(" ")
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,42 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+package stringutil
+
+import (
+	"testing"
+
+	"golang.org/x/net/html/atom"
+)
+
+func TestSanitize(t *testing.T) {
+	s := Sanitizer{
+		Elements:   map[atom.Atom]bool{atom.A: true, atom.P: true, atom.Img: true},
+		Attributes: map[string]bool{"title": true},
+		NewWindow:  true,
+	}
+
+	for in, out := range map[string]string{
+		`<p onclick="x()" title="t">hi<script>alert(1)</script></p>`:           `<p title="t">hi</p>`,
+		`<a href="javascript:alert(1)">x</a><a href="https://a.b/c">y</a>`:     `<a>x</a><a href="https://a.b/c" rel="nofollow noopener" target="_blank">y</a>`,
+		`<img src="data:image/png;base64,AA" alt="a"><img src="http://a.b/i">`: `<img><img src="http://a.b/i">`,
+		`<div><b>bold</b> &lt;ok&gt;</div><style>p{}</style>`:                  `bold &lt;ok&gt;`,
+		`<svg><a href="https://a.b">in svg</a></svg>`:                          ``,
+	} {
+		if got := s.Sanitize(in); got != out {
+			t.Errorf("for %s got %s", in, got)
+		}
+	}
+
+	if got := PlainText("<p>one <b>two</b></p><script>x</script>\n<p>three</p>"); got != "one two three" {
+		t.Errorf("unexpected text %q", got)
+	}
+}
@@ -36,6 +36,11 @@ func InitRuntime(r *env.Runtime) bool {
 		r.Log.Info("please set DOCUMIZESALT or use -salt with this value: " + r.Flags.Salt)
 	}
 
+	// Secrets such as section credentials are stored as given without a master key
+	if r.Flags.SecretKey == "" {
+		r.Log.Info("please set DOCUMIZESECRETKEY or use -secretkey to encrypt stored secrets")
+	}
+
 	// We can use either or both HTTP and HTTPS ports
 	if r.Flags.SSLCertFile == "" && r.Flags.SSLKeyFile == "" {
 		if r.Flags.HTTPPort == "" {
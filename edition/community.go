@@ -19,6 +19,7 @@ import (
 	"github.com/documize/community/core/api/endpoint"
 	"github.com/documize/community/core/api/request"
 	"github.com/documize/community/core/env"
+	"github.com/documize/community/core/secrets"
 	"github.com/documize/community/core/section"
 	"github.com/documize/community/edition/boot"
 	"github.com/documize/community/edition/logging"
@@ -58,6 +59,7 @@ func init() {
 	// temp code repair
 	api.Runtime = runtime
 	request.Db = runtime.Db
+	request.Keyring = secrets.NewKeyring(runtime.Flags.SecretKey, runtime.Flags.OldSecretKeys)
 }
 
 func main() {
@@ -22,6 +22,20 @@ export default Ember.Component.extend({
 	SMTPUserIdEmptyError: computed.empty('model.smtp.userid'),
 	SMTPPasswordEmptyError: computed.empty('model.smtp.password'),
 
+	// the drivers for the select boxes, each data source holds the chosen option
+	sqlDrivers: [],
+
+	didReceiveAttrs() {
+		let drivers = (this.get('model.sql.drivers') || []).map(function (d) {
+			return { id: d, name: d };
+		});
+		this.set('sqlDrivers', drivers);
+
+		(this.get('model.sql.sources') || []).forEach(function (s) {
+			Ember.set(s, 'option', drivers.findBy('id', s.driver));
+		});
+	},
+
 	actions: {
 		saveSMTP() {
 			if (this.get('SMTPHostEmptyError')) {
@@ -49,6 +63,28 @@ export default Ember.Component.extend({
 			});
 		},
 
+		addSQLSource() {
+			let option = this.get('sqlDrivers.firstObject');
+			this.get('model.sql.sources').pushObject({ name: "", driver: is.undefined(option) ? "" : option.id, dsn: "", option: option });
+		},
+
+		removeSQLSource(source) {
+			this.get('model.sql.sources').removeObject(source);
+		},
+
+		onSQLDriverChange(source, option) {
+			Ember.set(source, 'option', option);
+			Ember.set(source, 'driver', option.id);
+		},
+
+		saveSQL() {
+			this.get('saveSQL')();
+		},
+
+		rotateSecrets() {
+			this.get('rotateSecrets')();
+		},
+
 		saveLicense() {
 			this.get('saveLicense')().then(() => {
 				window.location.reload();
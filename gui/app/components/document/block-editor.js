@@ -38,7 +38,14 @@ export default Ember.Component.extend({
 		this.set('page', p);
 		this.set('meta', m);
 
-        this.set('editorType', 'section/' + this.get('block.contentType') + '/type-editor');
+        let editorType = 'section/' + this.get('block.contentType') + '/type-editor';
+
+        // sections provided by plugins share one editor
+        if (is.undefined(Ember.getOwner(this).factoryFor('component:' + editorType))) {
+            editorType = 'section/remote/type-editor';
+        }
+
+        this.set('editorType', editorType);
     },
 
     actions: {
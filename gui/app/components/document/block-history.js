@@ -0,0 +1,73 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+
+import Ember from 'ember';
+
+export default Ember.Component.extend({
+	sectionService: Ember.inject.service('section'),
+	revisions: [],
+	revision: null,
+	usage: [],
+	diff: '',
+
+	didReceiveAttrs() {
+		let blockId = this.get('block.id');
+
+		this.get('sectionService').getBlockRevisions(blockId).then((revisions) => {
+			if (this.get('isDestroyed') || this.get('isDestroying')) {
+				return;
+			}
+
+			revisions.forEach((r) => {
+				Ember.set(r, 'label', `Version ${r.version} - ${r.firstname} ${r.lastname} - ${r.title}`);
+			});
+
+			this.set('revisions', revisions);
+			this.set('revision', null);
+			this.set('diff', '');
+
+			if (revisions.length > 0) {
+				this.send('onSelectRevision', revisions[0]);
+			}
+		});
+
+		this.get('sectionService').getBlockUsage(blockId).then((usage) => {
+			if (this.get('isDestroyed') || this.get('isDestroying')) {
+				return;
+			}
+
+			// sections inserted before blocks had versions do not know theirs
+			usage.forEach((u) => {
+				Ember.set(u, 'versionLabel', u.version > 0 ? `${u.version}` : 'unknown');
+			});
+
+			this.set('usage', usage);
+		});
+	},
+
+	actions: {
+		onSelectRevision(revision) {
+			this.set('revision', revision);
+
+			this.get('sectionService').getBlockDiff(this.get('block.id'), revision.id).then((diff) => {
+				this.set('diff', diff);
+			});
+		},
+
+		onRollback() {
+			let revision = this.get('revision');
+			this.attrs.onRollback(revision.id);
+
+			return true;
+		}
+	}
+});
@@ -13,7 +13,14 @@ import Ember from 'ember';
 
 export default Ember.Component.extend({
     didReceiveAttrs() {
-        this.set('editorType', 'section/' + this.get('page.contentType') + '/type-editor');
+        let editorType = 'section/' + this.get('page.contentType') + '/type-editor';
+
+        // sections provided by plugins share one editor
+        if (is.undefined(Ember.getOwner(this).factoryFor('component:' + editorType))) {
+            editorType = 'section/remote/type-editor';
+        }
+
+        this.set('editorType', editorType);
     },
 
     actions: {
@@ -31,7 +31,7 @@ export default Ember.Component.extend(NotifierMixin, TooltipMixin, {
 			}
 
 			changes.forEach((newPage) => {
-				if (oldPage.get('id') === newPage.get('id')) {
+				if (oldPage.get('id') === newPage.get('id') && oldPage.get('body') !== newPage.get('body')) {
 					oldPage.set('body', newPage.get('body'));
 					oldPage.set('revised', newPage.get('revised'));
 					this.showNotification(`Refreshed ${oldPage.get('title')}`);
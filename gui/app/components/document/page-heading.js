@@ -23,6 +23,7 @@ export default Ember.Component.extend(TooltipMixin, {
 	menuOpen: false,
 	blockTitle: "",
 	blockExcerpt: "",
+	blockShared: false,
 	documentList: [], 		//includes the current document
 	documentListOthers: [], //excludes the current document
 	selectedDocument: null,
@@ -55,6 +56,14 @@ export default Ember.Component.extend(TooltipMixin, {
 		let id = this.get('page.id');
 		return `block-excerpt-${id}`;
 	}),
+	detachButtonId: computed('page', function () {
+		let id = this.get('page.id');
+		return `detach-page-button-${id}`;
+	}),
+	blockSharedId: computed('page', function () {
+		let id = this.get('page.id');
+		return `block-shared-${id}`;
+	}),
 	copyButtonId: computed('page', function () {
 		let id = this.get('page.id');
 		return `copy-page-button-${id}`;
@@ -127,7 +136,8 @@ export default Ember.Component.extend(TooltipMixin, {
 					excerpt: blockExcerpt,
 					rawBody: pm.get('rawBody'),
 					config: pm.get('config'),
-					externalSource: pm.get('externalSource')
+					externalSource: pm.get('externalSource'),
+					shared: this.get('blockShared')
 				};
 
 				this.attrs.onSavePageAsBlock(block);
@@ -135,6 +145,7 @@ export default Ember.Component.extend(TooltipMixin, {
 				this.set('menuOpen', false);
 				this.set('blockTitle', '');
 				this.set('blockExcerpt', '');
+				this.set('blockShared', false);
 				$(titleElem).removeClass('error');
 				$(excerptElem).removeClass('error');
 
@@ -142,6 +153,14 @@ export default Ember.Component.extend(TooltipMixin, {
 			});
 		},
 
+		onDetachPage() {
+			this.get('documentService').detachPage(this.get('document.id'), this.get('page.id')).then(() => {
+				this.set('menuOpen', false);
+			});
+
+			return true;
+		},
+
 		// Copy/move actions
 		onCopyDialogOpen() {
 			// Fetch document targets once.
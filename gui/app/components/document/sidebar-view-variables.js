@@ -0,0 +1,97 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+
+import Ember from 'ember';
+import NotifierMixin from '../../mixins/notifier';
+
+const types = [
+	{ id: 'text', name: 'Text' },
+	{ id: 'date', name: 'Date' },
+	{ id: 'number', name: 'Number' },
+	{ id: 'user', name: 'Person' }
+];
+
+export default Ember.Component.extend(NotifierMixin, {
+	templateService: Ember.inject.service('template'),
+	types: types,
+	variables: [],
+	emptyState: Ember.computed.empty('variables'),
+
+	didReceiveAttrs() {
+		this._super(...arguments);
+
+		this.get('templateService').getVariables(this.get('document.id')).then((variables) => {
+			if (this.get('isDestroyed') || this.get('isDestroying')) {
+				return;
+			}
+
+			this.set('variables', variables.map((v) => this.makeVariable(v)));
+		});
+	},
+
+	makeVariable(v) {
+		return Ember.Object.create({
+			name: v.name,
+			label: v.label,
+			type: v.type,
+			defaultValue: v.defaultValue,
+			selectedType: types.findBy('id', v.type) || types[0]
+		});
+	},
+
+	actions: {
+		onAdd() {
+			this.get('variables').pushObject(this.makeVariable({ name: '', label: '', type: 'text', defaultValue: '' }));
+		},
+
+		onRemove(variable) {
+			this.get('variables').removeObject(variable);
+		},
+
+		onTypeChange(variable, type) {
+			variable.set('type', type.id);
+			variable.set('selectedType', type);
+		},
+
+		onSave() {
+			let names = {};
+			let variables = this.get('variables');
+
+			for (let i = 0; i < variables.length; i++) {
+				let name = variables[i].get('name').trim();
+
+				if (!/^[A-Za-z_][A-Za-z0-9_]*$/.test(name) || names[name]) {
+					this.showNotification('Use a unique name of letters, digits and underscores');
+					return;
+				}
+
+				names[name] = true;
+			}
+
+			let payload = variables.map((v) => {
+				return {
+					name: v.get('name').trim(),
+					label: v.get('label'),
+					type: v.get('type'),
+					defaultValue: v.get('defaultValue')
+				};
+			});
+
+			this.get('templateService').setVariables(this.get('document.id'), payload).then((saved) => {
+				this.set('variables', saved.map((v) => this.makeVariable(v)));
+				this.showNotification('Saved');
+			}).catch(() => {
+				this.showNotification('Dates need to be today or like 2017-03-31, and numbers a number');
+			});
+		}
+	}
+});
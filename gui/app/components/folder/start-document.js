@@ -24,13 +24,18 @@ export default Ember.Component.extend(NotifierMixin, {
 	importedDocuments: [],
 	savedTemplates: [],
 	drop: null,
+	fillTemplate: null,
+	fillFields: [],
 	newDocumentName: 'New Document',
 	newDocumentNameMissing: computed.empty('newDocumentName'),
 
 	init() {
 		this._super(...arguments);
 
-		this.get('templateService').getSavedTemplates().then((saved) => {
+		Ember.RSVP.hash({
+			saved: this.get('templateService').getSavedTemplates(),
+			stock: this.get('templateService').getStockTemplates()
+		}).then((templates) => {
             let emptyTemplate = {
                 id: "0",
                 title: "Empty",
@@ -39,6 +44,13 @@ export default Ember.Component.extend(NotifierMixin, {
 				locked: true
             };
 
+			// catalogue templates cannot be edited here
+			templates.stock.forEach((t) => {
+				t.locked = true;
+			});
+
+			let saved = Ember.A(templates.saved.toArray().concat(templates.stock));
+
             saved.unshiftObject(emptyTemplate);
             this.set('savedTemplates', saved);
         });
@@ -118,15 +130,74 @@ export default Ember.Component.extend(NotifierMixin, {
 		},
 
 		startDocument(template) {
-            this.send("showNotification", "Creating");
+			let variables = Ember.get(template, 'variables');
+
+			// ask for the values of the template variables first
+			if (is.array(variables) && variables.length > 0) {
+				let fields = variables.map((v) => {
+					let placeholder = '';
+					let value = v.defaultValue;
+
+					if ((v.type === 'date' && value === 'today') || (v.type === 'user' && value === 'me')) {
+						placeholder = v.type === 'date' ? 'Today' : 'You';
+						value = '';
+					}
+
+					return Ember.Object.create({
+						name: v.name,
+						label: is.empty(v.label) ? v.name : v.label,
+						inputType: v.type === 'text' || v.type === 'user' ? 'text' : v.type,
+						placeholder: placeholder,
+						value: value
+					});
+				});
 
-            this.get('templateService').importSavedTemplate(this.folder.get('id'), template.id, this.get('newDocumentName')).then((document) => {
-				this.get('router').transitionTo('document', this.get('folder.id'), this.get('folder.slug'), document.get('id'), document.get('slug'));
-            });
+				this.set('fillTemplate', template);
+				this.set('fillFields', fields);
+
+				return true;
+			}
+
+			if (Ember.get(template, 'type') === 1) {
+				this.send("showNotification", "Creating");
+
+				this.get('templateService').importStockTemplate(this.folder.get('id'), template.id).then((document) => {
+					this.get('router').transitionTo('document', this.get('folder.id'), this.get('folder.slug'), document.get('id'), document.get('slug'));
+				});
+
+				return true;
+			}
+
+			this.send('onStart', template);
 
 			return true;
 		},
 
+		onCancelFill() {
+			this.set('fillTemplate', null);
+			this.set('fillFields', []);
+		},
+
+		onStartFilled() {
+			let values = {};
+
+			this.get('fillFields').forEach((f) => {
+				values[f.get('name')] = f.get('value');
+			});
+
+			this.send('onStart', this.get('fillTemplate'), values);
+		},
+
+		onStart(template, values) {
+            this.send("showNotification", "Creating");
+
+            this.get('templateService').importSavedTemplate(this.folder.get('id'), template.id, this.get('newDocumentName'), values).then((document) => {
+				this.get('router').transitionTo('document', this.get('folder.id'), this.get('folder.slug'), document.get('id'), document.get('slug'));
+            }).catch(() => {
+				this.showNotification('Check the values and try again');
+			});
+		},
+
 		onDocumentImporting(filename) {
 			this.send("showNotification", `Importing ${filename}`);
 			this.get('onHideDocumentWizard')();
@@ -12,6 +12,7 @@
 import Ember from 'ember';
 
 export default Ember.Component.extend({
+	sectionService: Ember.inject.service('section'),
 	drop: null,
 	cancelLabel: "Close",
 	actionLabel: "Save",
@@ -20,6 +21,50 @@ export default Ember.Component.extend({
 	hasExcerpt: Ember.computed('page', function () {
 		return is.not.undefined(this.get('page.excerpt'));
 	}),
+	// external data sections pass their meta so authors can choose how often it is refreshed
+	hasRefresh: Ember.computed('meta', function () {
+		return is.not.undefined(this.get('meta')) && is.not.null(this.get('meta'));
+	}),
+	refreshIntervals: [
+		{ minutes: 0, label: 'Every hour (default)' },
+		{ minutes: 15, label: 'Every 15 minutes' },
+		{ minutes: 360, label: 'Every 6 hours' },
+		{ minutes: 1440, label: 'Every day' },
+	],
+	refreshInterval: Ember.computed('meta.refreshInterval', function () {
+		let minutes = this.get('meta.refreshInterval') || 0;
+		let intervals = this.get('refreshIntervals');
+
+		return intervals.find((i) => i.minutes === minutes) || intervals[0];
+	}),
+	// organization-wide connections an administrator has set up for this type of section
+	connections: [],
+	hasConnections: Ember.computed('connections', function () {
+		return this.get('connections').length > 1;
+	}),
+	connection: Ember.computed('meta.connectionId', 'connections', function () {
+		let id = this.get('meta.connectionId') || '';
+		let connections = this.get('connections');
+
+		return connections.find((c) => c.id === id) || connections[0];
+	}),
+
+	didReceiveAttrs() {
+		if (!this.get('hasRefresh')) {
+			return;
+		}
+
+		this.set('page.connectionId', this.get('meta.connectionId'));
+
+		this.get('sectionService').getConnections(this.get('page.contentType')).then((connections) => {
+			if (this.get('isDestroyed') || this.get('isDestroying')) {
+				return;
+			}
+
+			connections.unshift({ id: '', name: 'My own credentials' });
+			this.set('connections', connections);
+		});
+	},
 
 	didRender() {
 		let self = this;
@@ -52,6 +97,15 @@ export default Ember.Component.extend({
 	},
 
 	actions: {
+		onRefreshInterval(interval) {
+			this.set('meta.refreshInterval', interval.minutes);
+		},
+
+		onConnection(connection) {
+			this.set('meta.connectionId', connection.id);
+			this.set('page.connectionId', connection.id);
+		},
+
 		onCancel() {
 			if (this.attrs.isDirty() !== null && this.attrs.isDirty()) {
 				$(".discard-edits-dialog").css("display", "block");
@@ -0,0 +1,189 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import SectionMixin from '../../../mixins/section';
+
+export default Ember.Component.extend(SectionMixin, NotifierMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	config: {},
+	data: '',
+	colors: '',
+	preview: '',
+	sources: [
+		{ id: 'data', name: 'Enter or paste data' },
+		{ id: 'table', name: 'A table in this document' }
+	],
+	types: [
+		{ id: 'bar', name: 'Bar' },
+		{ id: 'line', name: 'Line' },
+		{ id: 'area', name: 'Area' },
+		{ id: 'pie', name: 'Pie' }
+	],
+	legends: [
+		{ id: 'right', name: 'Right' },
+		{ id: 'bottom', name: 'Bottom' },
+		{ id: 'none', name: 'None' }
+	],
+	tables: [],
+	source: null,
+	type: null,
+	legend: null,
+	table: null,
+	isTable: Ember.computed.equal('source.id', 'table'),
+	isPie: Ember.computed.equal('type.id', 'pie'),
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		if (is.empty(config)) {
+			config = {
+				type: "bar",
+				title: "",
+				source: "data",
+				pageId: "",
+				xLabel: "",
+				yLabel: "",
+				colors: [],
+				legend: "right",
+				stacked: false,
+				height: 320
+			};
+		}
+
+		this.set('config', config);
+		this.set('data', this.get('meta.rawBody') || "Month,Sales,Costs\nJan,120,80\nFeb,150,95\nMar,170,90\n");
+		this.set('colors', (config.colors || []).join(', '));
+		this.set('source', this.get('sources').findBy('id', config.source) || this.get('sources')[0]);
+		this.set('type', this.get('types').findBy('id', config.type) || this.get('types')[0]);
+		this.set('legend', this.get('legends').findBy('id', config.legend) || this.get('legends')[0]);
+
+		this.set('waiting', true);
+
+		this.get('sectionService').fetch(this.get('page'), "tables", {})
+			.then((response) => {
+				this.set('tables', response);
+				this.set('table', response.findBy('id', config.pageId) || response[0] || null);
+				this.set('waiting', false);
+			}, () => {
+				this.set('waiting', false);
+			});
+	},
+
+	// readConfig copies the choices made in the form into the config.
+	readConfig() {
+		let config = this.get('config');
+		let height = parseInt(config.height);
+
+		Ember.set(config, 'source', this.get('source.id'));
+		Ember.set(config, 'type', this.get('type.id'));
+		Ember.set(config, 'legend', this.get('legend.id'));
+		Ember.set(config, 'pageId', this.get('isTable') && is.not.null(this.get('table')) ? this.get('table.id') : "");
+		Ember.set(config, 'height', is.number(height) && height > 0 ? height : 320);
+		Ember.set(config, 'colors', this.get('colors').split(',').map((c) => c.trim()).filter((c) => c.length > 0));
+
+		return config;
+	},
+
+	// loadData fetches the data of the chosen table, or resolves with the data the author entered.
+	loadData(config) {
+		if (!this.get('isTable')) {
+			return Ember.RSVP.resolve(this.get('data'));
+		}
+
+		if (is.null(this.get('table'))) {
+			return Ember.RSVP.reject(`Add a table to the document first`);
+		}
+
+		return this.get('sectionService').fetch(this.get('page'), "table", { config: config }).then((data) => {
+			this.set('data', data);
+			return data;
+		}, () => {
+			return Ember.RSVP.reject(`Unable to read the table`);
+		});
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		onSourceChange(source) {
+			this.set('source', source);
+			this.set('isDirty', true);
+		},
+
+		onTypeChange(type) {
+			this.set('type', type);
+			this.set('isDirty', true);
+		},
+
+		onLegendChange(legend) {
+			this.set('legend', legend);
+			this.set('isDirty', true);
+		},
+
+		onTableChange(table) {
+			this.set('table', table);
+			this.set('isDirty', true);
+		},
+
+		onPreview() {
+			let config = this.readConfig();
+			this.set('waiting', true);
+
+			this.loadData(config).then((data) => {
+				return this.get('sectionService').fetch(this.get('page'), "preview", { config: config, data: data });
+			}).then((svg) => {
+				this.set('waiting', false);
+				this.set('preview', svg);
+			}, (reason) => {
+				this.set('waiting', false);
+				this.showNotification(is.string(reason) ? reason : `Unable to chart the data`);
+			});
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let page = this.get('page');
+			let meta = this.get('meta');
+			let config = this.readConfig();
+			page.set('title', title);
+
+			this.set('waiting', true);
+
+			this.loadData(config).then((data) => {
+				this.set('waiting', false);
+
+				// charts of a table are refreshed so they follow edits to the table
+				meta.set('externalSource', config.source === 'table');
+				meta.set('config', JSON.stringify(config));
+				meta.set('rawBody', data);
+
+				this.attrs.onAction(page, meta);
+			}, (reason) => {
+				this.set('waiting', false);
+				this.showNotification(is.string(reason) ? reason : `Something went wrong, try again!`);
+			});
+		}
+	}
+});
@@ -17,6 +17,12 @@ export default Ember.Component.extend(TooltipMixin, {
     pageBody: "",
     syntaxOptions: [],
     codeSyntax: null,
+    themeOptions: [
+        { theme: "solarized-dark", name: "Solarized Dark" },
+        { theme: "solarized-light", name: "Solarized Light" },
+        { theme: "github", name: "GitHub" }
+    ],
+    codeTheme: null,
 	codeEditor: null,
 	editorId: Ember.computed('page', function () {
 		let page = this.get('page');
@@ -26,6 +32,10 @@ export default Ember.Component.extend(TooltipMixin, {
 		let page = this.get('page');
 		return `code-editor-syntax-${page.id}`;
 	}),
+	themeId: Ember.computed('page', function () {
+		let page = this.get('page');
+		return `code-editor-theme-${page.id}`;
+	}),
 
 	init() {
 		this._super(...arguments);
@@ -65,6 +75,14 @@ export default Ember.Component.extend(TooltipMixin, {
         if (is.null(this.get("codeSyntax"))) {
             this.set("codeSyntax", opts.findBy("mode", "htmlmixed"));
         }
+
+        let config = {};
+        try {
+            config = JSON.parse(this.get('meta.config'));
+        } catch (e) {} // eslint-disable-line no-empty
+
+        let themes = this.get('themeOptions');
+        this.set('codeTheme', themes.findBy('theme', is.not.null(config) ? config.theme : "") || themes[0]);
     },
 
     didInsertElement() {
@@ -122,6 +140,11 @@ export default Ember.Component.extend(TooltipMixin, {
             this.set('codeSyntax', syntax);
         },
 
+        onThemeChange(theme) {
+            this.set('isDirty', true);
+            this.set('codeTheme', theme);
+        },
+
         isDirty() {
             return this.get('isDirty') || (this.get('codeEditor').getDoc().isClean() === false);
         },
@@ -134,6 +157,7 @@ export default Ember.Component.extend(TooltipMixin, {
             let page = this.get('page');
             let meta = this.get('meta');
             meta.set('rawBody', this.getPRE());
+            meta.set('config', JSON.stringify({ lang: this.get('codeSyntax.mode'), theme: this.get('codeTheme.theme') }));
             page.set('title', title);
             page.set('body', meta.get('rawBody'));
 
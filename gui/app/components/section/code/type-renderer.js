@@ -24,12 +24,20 @@ export default Ember.Component.extend({
 
         let page = this.get('page');
         let rawBody = page.get('body');
-        let cleanBody = rawBody.replace("</pre>", "").replace('<pre class="code-mirror cm-s-solarized cm-s-dark" data-lang="', "");
-        let startPos = cleanBody.indexOf('">');
 
-        if (startPos !== -1) {
-            this.set('codeSyntax', cleanBody.substring(0, startPos));
-            this.set('codeBody', cleanBody.substring(startPos + 2));
+        // highlighted server-side, so the code is escaped within the markup
+        if (rawBody.indexOf('code-highlight') !== -1) {
+            let pre = $(rawBody);
+            this.set('codeSyntax', pre.attr('data-lang'));
+            this.set('codeBody', pre.text());
+        } else {
+            let cleanBody = rawBody.replace("</pre>", "").replace('<pre class="code-mirror cm-s-solarized cm-s-dark" data-lang="', "");
+            let startPos = cleanBody.indexOf('">');
+
+            if (startPos !== -1) {
+                this.set('codeSyntax', cleanBody.substring(0, startPos));
+                this.set('codeBody', cleanBody.substring(startPos + 2));
+            }
         }
 
         _.each(_.sortBy(CodeMirror.modeInfo, 'name'), (item) => {
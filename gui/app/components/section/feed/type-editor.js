@@ -0,0 +1,90 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import TooltipMixin from '../../../mixins/tooltip';
+import SectionMixin from '../../../mixins/section';
+
+export default Ember.Component.extend(SectionMixin, NotifierMixin, TooltipMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	config: {},
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		if (is.empty(config)) {
+			config = {
+				url: "",
+				max: 10,
+				showSummary: true
+			};
+		}
+
+		this.set('config', config);
+	},
+
+	willDestroyElement() {
+		this.destroyTooltips();
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		onSummaryCheckbox() {
+			this.set('config.showSummary', !this.get('config.showSummary'));
+			this.set('isDirty', true);
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let self = this;
+			let page = this.get('page');
+			let meta = this.get('meta');
+			let config = this.get('config');
+			page.set('title', title);
+			meta.set('externalSource', true);
+
+			if (is.empty(config.url)) {
+				this.showNotification(`Enter the feed URL`);
+				return;
+			}
+
+			let max = parseInt(config.max);
+			Ember.set(config, 'max', is.number(max) && max > 0 ? max : 10);
+
+			this.set('waiting', true);
+
+			this.get('sectionService').fetch(page, "preview", config)
+				.then(function (response) {
+					meta.set('config', JSON.stringify(config));
+					meta.set('rawBody', JSON.stringify(response));
+
+					self.set('waiting', false);
+					self.attrs.onAction(page, meta);
+				}, function () {
+					self.set('waiting', false);
+					self.showNotification(`Unable to read the feed`);
+				});
+		}
+	}
+});
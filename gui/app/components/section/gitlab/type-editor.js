@@ -0,0 +1,136 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import TooltipMixin from '../../../mixins/tooltip';
+import SectionMixin from '../../../mixins/section';
+import netUtil from '../../../utils/net';
+
+export default Ember.Component.extend(SectionMixin, NotifierMixin, TooltipMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	authenticated: false,
+	config: {},
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		if (is.empty(config)) {
+			config = {
+				url: "",
+				token: "",
+				since: "",
+				projects: [],
+				showMergeRequests: true,
+				showCommits: true,
+				showIssues: true,
+				showMilestones: true
+			};
+		}
+
+		this.set('config', config);
+
+		if (is.not.empty(config.url)) {
+			this.send('auth');
+		}
+	},
+
+	willDestroyElement() {
+		this.destroyTooltips();
+	},
+
+	displayError(reason) {
+		if (netUtil.isAjaxAccessError(reason)) {
+			this.showNotification(`Unable to authenticate`);
+		} else {
+			this.showNotification(`Something went wrong, try again!`);
+		}
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		auth() {
+			let page = this.get('page');
+			let config = this.get('config');
+			let self = this;
+
+			this.set('waiting', true);
+
+			this.get('sectionService').fetch(page, "auth", config)
+				.then(function (response) {
+					// keep the choices already made
+					let chosen = _.indexBy(config.projects || [], 'id');
+					let projects = response.map(function (p) {
+						let existing = chosen[p.id];
+						if (is.not.undefined(existing)) {
+							p.included = existing.included;
+							p.branch = existing.branch;
+						}
+						return p;
+					});
+
+					Ember.set(config, 'projects', projects);
+					self.set('authenticated', true);
+					self.set('waiting', false);
+					self.set('config.token', '********'); // reset the token once it has been sent to the host
+				}, function (reason) {
+					self.set('authenticated', false);
+					self.set('waiting', false);
+					self.set('config.token', ''); // clear the token
+					self.displayError(reason);
+				});
+		},
+
+		onProjectCheckbox(id) {
+			let project = _.findWhere(this.get('config.projects'), { id: id });
+			if (is.not.undefined(project)) {
+				Ember.set(project, 'included', !project.included);
+				this.set('isDirty', true);
+			}
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let self = this;
+			let page = this.get('page');
+			let meta = this.get('meta');
+			let config = this.get('config');
+			page.set('title', title);
+			meta.set('externalSource', true);
+
+			this.set('waiting', true);
+
+			this.get('sectionService').fetch(page, "content", config)
+				.then(function (response) {
+					meta.set('config', JSON.stringify(config));
+					meta.set('rawBody', JSON.stringify(response));
+
+					self.set('waiting', false);
+					self.attrs.onAction(page, meta);
+				}, function (reason) {
+					self.set('waiting', false);
+					self.displayError(reason);
+				});
+		}
+	}
+});
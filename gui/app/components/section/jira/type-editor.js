@@ -0,0 +1,141 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import TooltipMixin from '../../../mixins/tooltip';
+import SectionMixin from '../../../mixins/section';
+import netUtil from '../../../utils/net';
+
+export default Ember.Component.extend(SectionMixin, NotifierMixin, TooltipMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	authenticated: false,
+	config: {},
+	columns: [],
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		if (is.empty(config)) {
+			config = {
+				url: "",
+				username: "",
+				token: "",
+				jql: "",
+				max: 50,
+				columns: ["key", "summary", "status", "assignee", "fixVersions"]
+			};
+		}
+
+		this.set('config', config);
+
+		let columns = [
+			{ id: "key", title: "Key" },
+			{ id: "summary", title: "Summary" },
+			{ id: "status", title: "Status" },
+			{ id: "assignee", title: "Assignee" },
+			{ id: "fixVersions", title: "Fix Version" },
+			{ id: "issuetype", title: "Type" },
+			{ id: "priority", title: "Priority" },
+			{ id: "reporter", title: "Reporter" },
+			{ id: "updated", title: "Updated" }
+		];
+		columns.forEach(function (c) {
+			c.included = _.contains(config.columns, c.id);
+		});
+		this.set('columns', columns);
+
+		if (is.not.empty(config.url)) {
+			this.send('auth');
+		}
+	},
+
+	willDestroyElement() {
+		this.destroyTooltips();
+	},
+
+	displayError(reason) {
+		if (netUtil.isAjaxAccessError(reason)) {
+			this.showNotification(`Unable to authenticate`);
+		} else {
+			this.showNotification(`Something went wrong, try again!`);
+		}
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		auth() {
+			let page = this.get('page');
+			let config = this.get('config');
+			let self = this;
+
+			this.set('waiting', true);
+
+			this.get('sectionService').fetch(page, "auth", config)
+				.then(function () {
+					self.set('authenticated', true);
+					self.set('waiting', false);
+					self.set('config.token', '********'); // reset the token once it has been sent to the host
+				}, function (reason) {
+					self.set('authenticated', false);
+					self.set('waiting', false);
+					self.set('config.token', ''); // clear the token
+					self.displayError(reason);
+				});
+		},
+
+		onColumnCheckbox(id) {
+			let column = _.findWhere(this.get('columns'), { id: id });
+			Ember.set(column, 'included', !column.included);
+			this.set('isDirty', true);
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let self = this;
+			let page = this.get('page');
+			let meta = this.get('meta');
+			let config = this.get('config');
+			page.set('title', title);
+			meta.set('externalSource', true);
+
+			let max = parseInt(config.max);
+			Ember.set(config, 'max', is.number(max) && max > 0 ? max : 50);
+			Ember.set(config, 'columns', _.pluck(_.where(this.get('columns'), { included: true }), 'id'));
+
+			this.set('waiting', true);
+
+			this.get('sectionService').fetch(page, "issues", config)
+				.then(function (response) {
+					meta.set('config', JSON.stringify(config));
+					meta.set('rawBody', JSON.stringify(response));
+
+					self.set('waiting', false);
+					self.attrs.onAction(page, meta);
+				}, function (reason) {
+					self.set('waiting', false);
+					self.displayError(reason);
+				});
+		}
+	}
+});
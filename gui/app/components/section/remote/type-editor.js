@@ -0,0 +1,101 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import SectionMixin from '../../../mixins/section';
+
+// Editor for sections provided by plugins, showing the fields the plugin asks for.
+export default Ember.Component.extend(SectionMixin, NotifierMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	config: {},
+	fields: [],
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		this.set('config', is.object(config) ? config : {});
+		this.set('waiting', true);
+
+		this.get('sectionService').fetch(this.get('page'), "form", {}).then((fields) => {
+			if (this.get('isDestroyed') || this.get('isDestroying')) {
+				return;
+			}
+
+			let config = this.get('config');
+
+			this.set('fields', (fields || []).map((f) => {
+				if (is.undefined(config[f.name])) {
+					Ember.set(config, f.name, f.type === 'checkbox' ? false : '');
+				}
+
+				return {
+					name: f.name,
+					label: f.label,
+					tip: f.tip,
+					isCheckbox: f.type === 'checkbox',
+					isTextarea: f.type === 'textarea',
+					isNumber: f.type === 'number',
+					isText: ['checkbox', 'textarea', 'number'].indexOf(f.type) === -1
+				};
+			}));
+
+			this.set('waiting', false);
+		}, () => {
+			this.set('waiting', false);
+			this.showNotification(`Unable to reach this section's service`);
+		});
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let page = this.get('page');
+			let meta = this.get('meta');
+			let config = this.get('config');
+			page.set('title', title);
+			meta.set('externalSource', true);
+
+			this.get('fields').forEach((f) => {
+				if (f.isNumber) {
+					let n = parseFloat(config[f.name]);
+					Ember.set(config, f.name, is.number(n) ? n : 0);
+				}
+			});
+
+			this.set('waiting', true);
+
+			this.get('sectionService').fetch(page, "data", config).then((response) => {
+				meta.set('config', JSON.stringify(config));
+				meta.set('rawBody', JSON.stringify(response));
+
+				this.set('waiting', false);
+				this.attrs.onAction(page, meta);
+			}, () => {
+				this.set('waiting', false);
+				this.showNotification(`Unable to fetch the data`);
+			});
+		}
+	}
+});
@@ -0,0 +1,141 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import TooltipMixin from '../../../mixins/tooltip';
+import SectionMixin from '../../../mixins/section';
+import netUtil from '../../../utils/net';
+
+export default Ember.Component.extend(SectionMixin, NotifierMixin, TooltipMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	config: {},
+	previewHTML: '',
+	previewError: '',
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		if (is.empty(config)) {
+			config = {
+				url: "",
+				headers: [],
+				selector: "",
+				template: "<pre>{{json .}}</pre>"
+			};
+		}
+
+		if (is.not.array(config.headers)) {
+			config.headers = [];
+		}
+
+		this.set('config', config);
+	},
+
+	willDestroyElement() {
+		this.destroyTooltips();
+	},
+
+	displayError(reason) {
+		if (netUtil.isAjaxAccessError(reason)) {
+			this.showNotification(`Unable to authenticate`);
+		} else {
+			this.showNotification(`Something went wrong, try again!`);
+		}
+	},
+
+	// fetch the data and render it, hiding secret header values once they have been sent
+	fetch() {
+		let page = this.get('page');
+		let config = this.get('config');
+
+		return this.get('sectionService').fetch(page, "preview", config).then((response) => {
+			config.headers.forEach(function (h) {
+				if (h.secret && is.not.empty(h.value)) {
+					Ember.set(h, 'value', '********');
+				}
+			});
+
+			this.set('previewHTML', response.html);
+			this.set('previewError', response.error);
+
+			return response;
+		});
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		onAddHeader() {
+			this.get('config.headers').pushObject({ name: "", value: "", secret: false });
+			this.set('isDirty', true);
+		},
+
+		onRemoveHeader(header) {
+			this.get('config.headers').removeObject(header);
+			this.set('isDirty', true);
+		},
+
+		onSecretCheckbox(header) {
+			Ember.set(header, 'secret', !header.secret);
+			this.set('isDirty', true);
+		},
+
+		onPreview() {
+			this.set('waiting', true);
+
+			this.fetch().then(() => {
+				this.set('waiting', false);
+			}, (reason) => {
+				this.set('waiting', false);
+				this.displayError(reason);
+			});
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let page = this.get('page');
+			let meta = this.get('meta');
+			page.set('title', title);
+			meta.set('externalSource', true);
+
+			this.set('waiting', true);
+
+			this.fetch().then((response) => {
+				this.set('waiting', false);
+
+				if (is.not.empty(response.error)) {
+					this.showNotification(`Check the preview for errors`);
+					return;
+				}
+
+				meta.set('config', JSON.stringify(this.get('config')));
+				meta.set('rawBody', JSON.stringify(response.data));
+
+				this.attrs.onAction(page, meta);
+			}, (reason) => {
+				this.set('waiting', false);
+				this.displayError(reason);
+			});
+		}
+	}
+});
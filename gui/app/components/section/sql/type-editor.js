@@ -0,0 +1,117 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+import TooltipMixin from '../../../mixins/tooltip';
+import SectionMixin from '../../../mixins/section';
+
+export default Ember.Component.extend(SectionMixin, NotifierMixin, TooltipMixin, {
+	sectionService: Ember.inject.service('section'),
+	isDirty: false,
+	waiting: false,
+	config: {},
+	sources: [],
+	source: null,
+	queryError: '',
+
+	didReceiveAttrs() {
+		let config = {};
+
+		try {
+			config = JSON.parse(this.get('meta.config'));
+		} catch (e) {} // eslint-disable-line no-empty
+
+		if (is.empty(config)) {
+			config = {
+				source: "",
+				query: "",
+				max: 100,
+				timeout: 10
+			};
+		}
+
+		this.set('config', config);
+		this.set('waiting', true);
+
+		this.get('sectionService').fetch(this.get('page'), "sources", config)
+			.then((response) => {
+				let sources = response.map(function (name) {
+					return { name: name };
+				});
+				this.set('sources', sources);
+				this.set('source', sources.findBy('name', config.source) || sources[0]);
+				this.set('waiting', false);
+			}, () => {
+				this.set('waiting', false);
+				this.showNotification(`Unable to list the data sources`);
+			});
+	},
+
+	willDestroyElement() {
+		this.destroyTooltips();
+	},
+
+	actions: {
+		isDirty() {
+			return this.get('isDirty');
+		},
+
+		onSourceChange(source) {
+			this.set('source', source);
+			this.set('isDirty', true);
+		},
+
+		onCancel() {
+			this.attrs.onCancel();
+		},
+
+		onAction(title) {
+			let page = this.get('page');
+			let meta = this.get('meta');
+			let config = this.get('config');
+			page.set('title', title);
+			meta.set('externalSource', true);
+
+			if (is.null(this.get('source'))) {
+				this.showNotification(`Ask your administrator to add a data source`);
+				return;
+			}
+
+			let max = parseInt(config.max);
+			let timeout = parseInt(config.timeout);
+			Ember.set(config, 'source', this.get('source.name'));
+			Ember.set(config, 'max', is.number(max) && max > 0 ? max : 100);
+			Ember.set(config, 'timeout', is.number(timeout) && timeout > 0 ? timeout : 10);
+
+			this.set('waiting', true);
+			this.set('queryError', '');
+
+			this.get('sectionService').fetch(page, "query", config)
+				.then((response) => {
+					this.set('waiting', false);
+
+					if (is.not.empty(response.error)) {
+						this.set('queryError', response.error);
+						return;
+					}
+
+					meta.set('config', JSON.stringify(config));
+					meta.set('rawBody', JSON.stringify(response.data));
+
+					this.attrs.onAction(page, meta);
+				}, () => {
+					this.set('waiting', false);
+					this.showNotification(`Something went wrong, try again!`);
+				});
+		}
+	}
+});
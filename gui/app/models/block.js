@@ -15,6 +15,7 @@ import attr from 'ember-data/attr';
 export default Model.extend({
 	orgId: attr('string'),
 	folderId: attr('string'),
+	shared: attr('boolean', { defaultValue: false }),
 	userId: attr('string'),
 	contentType: attr('string'),
 	pageType: attr('string'),
@@ -22,6 +23,7 @@ export default Model.extend({
 	body: attr('string'),
 	excerpt: attr('string'),
 	used: attr('number', { defaultValue: 0 }),
+	version: attr('number', { defaultValue: 1 }),
 	rawBody: attr(),
 	config: attr(),
 	externalSource: attr('boolean', { defaultValue: false }),
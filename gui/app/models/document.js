@@ -26,6 +26,8 @@ export default Model.extend({
 	tags: attr('string'),
 	template: attr('boolean'),
 	layout: attr('string'),
+	templateId: attr('string'),
+	templateVersion: attr('string'),
 
 	// client-side property
 	selected: attr('boolean', { defaultValue: false }),
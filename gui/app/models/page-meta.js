@@ -20,6 +20,11 @@ export default Model.extend({
 	rawBody: attr(),
 	config: attr(),
 	externalSource: attr('boolean', { defaultValue: false }),
+	connectionId: attr('string', { defaultValue: '' }),
+	refreshInterval: attr('number', { defaultValue: 0 }),
+	refreshed: attr(),
+	refreshAttempted: attr(),
+	refreshError: attr('string'),
 	created: attr(),
 	revised: attr(),
 });
@@ -23,6 +23,7 @@ export default Model.extend({
 	sequence: attr('number', { defaultValue: 0 }),
 	revisions: attr('number', { defaultValue: 0 }),
 	blockId: attr('string'),
+	blockLinked: attr('boolean', { defaultValue: false }),
 	title: attr('string'),
 	body: attr('string'),
 	rawBody: attr('string'),
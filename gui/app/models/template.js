@@ -21,6 +21,9 @@ export default Model.extend({
 	description: attr('string'),
 	title: attr('string'),
 	type: attr('number', { defaultValue: 0 }),
+	category: attr('string'),
+	version: attr('string'),
+	variables: attr(),
 
 	slug: Ember.computed('title', function () {
 		return stringUtil.makeSlug(this.get('title'));
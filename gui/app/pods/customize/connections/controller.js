@@ -0,0 +1,108 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+
+export default Ember.Controller.extend(NotifierMixin, {
+	sectionService: Ember.inject.service('section'),
+	connections: [],
+	sections: [],
+	section: null,
+	editing: null,
+	name: '',
+	secrets: '',
+	log: [],
+	logFor: null,
+	hasNameError: false,
+	hasSecretsError: false,
+
+	label: function () {
+		switch (this.get('connections').length) {
+		case 1:
+			return "connection";
+		default:
+			return "connections";
+		}
+	}.property('connections'),
+
+	reset() {
+		this.set('editing', null);
+		this.set('name', '');
+		this.set('secrets', '');
+		this.set('hasNameError', false);
+		this.set('hasSecretsError', false);
+	},
+
+	actions: {
+		onSection(section) {
+			this.set('section', section);
+		},
+
+		edit(connection) {
+			this.set('editing', connection);
+			this.set('name', connection.name);
+			this.set('secrets', '');
+		},
+
+		cancel() {
+			this.reset();
+		},
+
+		save() {
+			let editing = this.get('editing');
+			let name = this.get('name').trim();
+			let secrets = this.get('secrets').trim();
+
+			this.set('hasNameError', is.empty(name));
+			this.set('hasSecretsError', false);
+
+			// new connections need secrets, existing ones keep theirs unless replaced
+			if (is.not.empty(secrets) || is.null(editing)) {
+				try {
+					if (!is.json(JSON.parse(secrets))) {
+						this.set('hasSecretsError', true);
+					}
+				} catch (e) {
+					this.set('hasSecretsError', true);
+				}
+			}
+
+			if (this.get('hasNameError') || this.get('hasSecretsError')) {
+				return;
+			}
+
+			let save = is.null(editing) ?
+				this.get('sectionService').addConnection({ contentType: this.get('section.contentType'), name: name, secrets: secrets }) :
+				this.get('sectionService').updateConnection({ id: editing.id, name: name, secrets: secrets });
+
+			save.then(() => {
+				this.showNotification("Saved");
+				this.reset();
+				this.send('onChange');
+			});
+		},
+
+		remove(connection) {
+			this.get('sectionService').deleteConnection(connection.id).then(() => {
+				this.showNotification("Deleted");
+				this.send('onChange');
+			});
+		},
+
+		showLog(connection) {
+			this.get('sectionService').getConnectionLog(connection.id).then((log) => {
+				this.set('logFor', connection);
+				this.set('log', log);
+			});
+		}
+	}
+});
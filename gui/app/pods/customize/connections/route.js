@@ -0,0 +1,54 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import AuthenticatedRouteMixin from 'ember-simple-auth/mixins/authenticated-route-mixin';
+
+export default Ember.Route.extend(AuthenticatedRouteMixin, {
+	sectionService: Ember.inject.service('section'),
+
+	beforeModel() {
+		if (!this.session.isAdmin) {
+			this.transitionTo('auth.login');
+		}
+	},
+
+	model() {
+		return Ember.RSVP.hash({
+			connections: this.get('sectionService').getConnections(),
+			sections: this.get('sectionService').getAll()
+		});
+	},
+
+	setupController(controller, model) {
+		let sections = model.sections.sortBy('title');
+
+		model.connections.forEach((c) => {
+			let section = sections.findBy('contentType', c.contentType);
+			c.sectionTitle = is.undefined(section) ? c.contentType : section.get('title');
+		});
+
+		controller.set('connections', model.connections);
+		controller.set('sections', sections);
+		controller.set('section', sections[0]);
+		controller.set('log', []);
+	},
+
+	activate() {
+		document.title = "Connections | Documize";
+	},
+
+	actions: {
+		onChange() {
+			this.refresh();
+		}
+	}
+});
@@ -24,6 +24,27 @@ export default Ember.Controller.extend(NotifierMixin, {
 			}
 		},
 
+		saveSQL() {
+			if(this.get('session.isGlobalAdmin')) {
+				return this.get('global').saveSQLSources(this.model.sql.sources).then(() => {
+					this.showNotification('Saved');
+				}, () => {
+					this.showNotification('Unable to save, each data source needs a unique name, a driver and a connection string');
+				});
+			}
+		},
+
+		rotateSecrets() {
+			if(this.get('session.isGlobalAdmin')) {
+				return this.get('global').rotateSecrets().then((result) => {
+					let note = result.failed > 0 ? `, ${result.failed} need a master key that is no longer given` : '';
+					this.showNotification(`Encrypted ${result.sealed} secrets${note}`);
+				}, () => {
+					this.showNotification('Unable to encrypt secrets without a master key');
+				});
+			}
+		},
+
 		saveLicense() {
 			if(this.get('session.isGlobalAdmin')) {
 				return this.get('global').saveLicense(this.model.license).then(() => {
@@ -27,7 +27,9 @@ export default Ember.Route.extend(AuthenticatedRouteMixin, {
 	model() {
 		return RSVP.hash({
 			smtp: this.get('global').getSMTPConfig(),
-			license: this.get('global').getLicense()
+			license: this.get('global').getLicense(),
+			sql: this.get('global').getSQLSources(),
+			secrets: this.get('global').getSecretsConfig()
 		});
 	},
 
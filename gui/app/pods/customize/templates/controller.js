@@ -0,0 +1,42 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+
+export default Ember.Controller.extend(NotifierMixin, {
+	templateService: Ember.inject.service('template'),
+	source: {},
+	folders: [],
+	folder: null,
+	templates: [],
+
+	actions: {
+		onFolderChange(folder) {
+			this.set('folder', folder);
+		},
+
+		save() {
+			let source = {
+				folderId: this.get('folder.id'),
+				directory: this.get('source.directory')
+			};
+
+			this.get('templateService').setSource(source).then(() => {
+				this.showNotification('Saved');
+				this.send('onChange');
+			}).catch(() => {
+				this.showNotification('The template directory cannot be read');
+			});
+		}
+	}
+});
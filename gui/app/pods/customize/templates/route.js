@@ -0,0 +1,53 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+
+import Ember from 'ember';
+import AuthenticatedRouteMixin from 'ember-simple-auth/mixins/authenticated-route-mixin';
+
+export default Ember.Route.extend(AuthenticatedRouteMixin, {
+	templateService: Ember.inject.service('template'),
+	folderService: Ember.inject.service('folder'),
+
+	beforeModel() {
+		if (!this.session.isAdmin) {
+			this.transitionTo('auth.login');
+		}
+	},
+
+	model() {
+		return Ember.RSVP.hash({
+			source: this.get('templateService').getSource(),
+			folders: this.get('folderService').getAll(),
+			templates: this.get('templateService').getStockTemplates()
+		});
+	},
+
+	setupController(controller, model) {
+		let none = { id: '', name: 'None' };
+		let folders = [none].concat(model.folders.toArray());
+
+		controller.set('source', model.source);
+		controller.set('folders', folders);
+		controller.set('folder', folders.findBy('id', model.source.folderId) || none);
+		controller.set('templates', model.templates);
+	},
+
+	activate() {
+		document.title = "Templates | Documize";
+	},
+
+	actions: {
+		onChange() {
+			this.refresh();
+		}
+	}
+});
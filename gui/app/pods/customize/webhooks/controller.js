@@ -0,0 +1,37 @@
+// Copyright 2016 Documize Inc. <legal@documize.com>. All rights reserved.
+//
+// This software (Documize Community Edition) is licensed under
+// GNU AGPL v3 http://www.gnu.org/licenses/agpl-3.0.en.html
+//
+// You can operate outside the AGPL restrictions by purchasing
+// Documize Enterprise Edition and obtaining a commercial license
+// by contacting <sales@documize.com>.
+//
+// https://documize.com
+
+import Ember from 'ember';
+import NotifierMixin from '../../../mixins/notifier';
+
+export default Ember.Controller.extend(NotifierMixin, {
+	sectionService: Ember.inject.service('section'),
+	webhooks: [],
+	generated: null,
+
+	actions: {
+		generate(webhook) {
+			this.get('sectionService').addWebhook(webhook.contentType).then((generated) => {
+				this.send('onChange');
+				generated.title = webhook.title;
+				this.set('generated', generated);
+			});
+		},
+
+		remove(webhook) {
+			this.get('sectionService').deleteWebhook(webhook.contentType).then(() => {
+				this.showNotification("Deleted");
+				this.set('generated', null);
+				this.send('onChange');
+			});
+		}
+	}
+});
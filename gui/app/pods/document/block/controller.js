@@ -33,6 +33,12 @@ export default Ember.Controller.extend({
 			this.get('sectionService').updateBlock(b).then(function () {
 				self.transitionToRoute('document');
 			});
+		},
+
+		onRollback(revisionId) {
+			this.get('sectionService').rollbackBlock(this.get('model.block.id'), revisionId).then(() => {
+				this.transitionToRoute('document');
+			});
 		}
 	}
 });
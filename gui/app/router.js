@@ -51,6 +51,15 @@ export default Router.map(function () {
 		this.route('folders', {
 			path: 'folders'
 		});
+		this.route('connections', {
+			path: 'connections'
+		});
+		this.route('webhooks', {
+			path: 'webhooks'
+		});
+		this.route('templates', {
+			path: 'templates'
+		});
 		this.route('global', {
 			path: 'global'
 		});
@@ -333,6 +333,16 @@ export default Ember.Service.extend({
 		});
 	},
 
+	// Make a page linked to a reusable block a copy of it, so it no longer changes with the block.
+	detachPage(documentId, pageId) {
+		return this.get('ajax').request(`documents/${documentId}/pages/${pageId}/detach`, {
+			method: 'POST'
+		}).then((response) => {
+			let data = this.get('store').normalize('page', response);
+			return this.get('store').push(data);
+		});
+	},
+
 	// Move existing page to different document.
 	movePage(documentId, pageId, targetDocumentId) {
 		return this.get('ajax').request(`documents/${documentId}/pages/${pageId}/move/${targetDocumentId}`, {
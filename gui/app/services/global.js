@@ -86,6 +86,49 @@ export default Ember.Service.extend({
 		}
 	},
 
+	// Returns the data sources for SQL sections, without connection strings.
+	getSQLSources() {
+		if(this.get('sessionService.isGlobalAdmin')) {
+			return this.get('ajax').request(`global/sql`, {
+				method: 'GET'
+			}).then((response) => {
+				return response;
+			});
+		}
+	},
+
+	// Saves the data sources for SQL sections.
+	saveSQLSources(sources) {
+		if(this.get('sessionService.isGlobalAdmin')) {
+			return this.get('ajax').request(`global/sql`, {
+				method: 'PUT',
+				data: JSON.stringify({ sources: sources })
+			});
+		}
+	},
+
+	// Reports whether stored secrets are encrypted.
+	getSecretsConfig() {
+		if(this.get('sessionService.isGlobalAdmin')) {
+			return this.get('ajax').request(`global/secrets`, {
+				method: 'GET'
+			}).then((response) => {
+				return response;
+			});
+		}
+	},
+
+	// Encrypts stored secrets with the current master key.
+	rotateSecrets() {
+		if(this.get('sessionService.isGlobalAdmin')) {
+			return this.get('ajax').request(`global/secrets/rotate`, {
+				method: 'POST'
+			}).then((response) => {
+				return response;
+			});
+		}
+	},
+
 	syncExternalUsers() {
 		if(this.get('sessionService.isAdmin')) {
 			return this.get('ajax').request(`users/sync`, {
@@ -43,6 +43,12 @@ export default BaseService.extend({
 		let documentId = page.get('documentId');
 		let section = page.get('contentType');
 		let url = `sections?documentID=${documentId}&section=${section}&method=${method}`;
+		let connectionId = page.get('connectionId');
+
+		// sections using a shared connection run with its secrets rather than the author's
+		if (is.not.undefined(connectionId) && is.not.empty(connectionId)) {
+			url += `&connection=${connectionId}&pageID=${page.get('id')}`;
+		}
 
 		return this.get('ajax').post(url, {
 			data: JSON.stringify(data),
@@ -73,6 +79,72 @@ export default BaseService.extend({
 		});
 	},
 
+	/**************************************************
+	 * Shared Connections
+	 **************************************************/
+
+	// Returns the connections shared across the organization, for one section type or for all of them.
+	getConnections(section = '') {
+		return this.get('ajax').request(`sections/connections?section=${section}`, {
+			method: 'GET'
+		});
+	},
+
+	// Save new shared connection.
+	addConnection(connection) {
+		return this.get('ajax').post(`sections/connections`, {
+			data: JSON.stringify(connection),
+			contentType: 'json'
+		});
+	},
+
+	// Renames a shared connection, and replaces its secrets when given.
+	updateConnection(connection) {
+		return this.get('ajax').request(`sections/connections/${connection.id}`, {
+			method: 'PUT',
+			data: JSON.stringify(connection)
+		});
+	},
+
+	// Removes specified shared connection.
+	deleteConnection(connectionId) {
+		return this.get('ajax').request(`sections/connections/${connectionId}`, {
+			method: 'DELETE'
+		});
+	},
+
+	// Returns who used a shared connection, and when.
+	getConnectionLog(connectionId) {
+		return this.get('ajax').request(`sections/connections/${connectionId}/log`, {
+			method: 'GET'
+		});
+	},
+
+	/**************************************************
+	 * Webhooks
+	 **************************************************/
+
+	// Returns the sections that can be refreshed by a webhook, with the webhook each has.
+	getWebhooks() {
+		return this.get('ajax').request(`sections/webhooks`, {
+			method: 'GET'
+		});
+	},
+
+	// Generates a new webhook URL and secret for the section, replacing any it had.
+	addWebhook(section) {
+		return this.get('ajax').post(`sections/webhooks/${section}`, {
+			contentType: 'json'
+		});
+	},
+
+	// Removes the webhook of the section.
+	deleteWebhook(section) {
+		return this.get('ajax').request(`sections/webhooks/${section}`, {
+			method: 'DELETE'
+		});
+	},
+
 	/**************************************************
 	 * Reusable Content Blocks
 	 **************************************************/
@@ -131,5 +203,41 @@ export default BaseService.extend({
 		return this.get('ajax').request(url, {
 			method: 'DELETE'
 		});
+	},
+
+	// Returns the previous versions of a reusable content block, latest first.
+	getBlockRevisions(blockId) {
+		return this.get('ajax').request(`sections/blocks/${blockId}/revisions`, {
+			method: 'GET'
+		});
+	},
+
+	// Returns the differences between the block and one of its previous versions as HTML.
+	getBlockDiff(blockId, revisionId) {
+		return this.get('ajax').request(`sections/blocks/${blockId}/revisions/${revisionId}`, {
+			method: 'GET',
+			dataType: 'text'
+		}).then((response) => {
+			return response;
+		}).catch(() => {
+			return "";
+		});
+	},
+
+	// Makes a previous version of the block the latest, linked sections follow it.
+	rollbackBlock(blockId, revisionId) {
+		return this.get('ajax').request(`sections/blocks/${blockId}/revisions/${revisionId}`, {
+			method: 'POST'
+		}).then((response) => {
+			let data = this.get('store').normalize('block', response);
+			return this.get('store').push(data);
+		});
+	},
+
+	// Returns the sections inserted from the block, with the version each has.
+	getBlockUsage(blockId) {
+		return this.get('ajax').request(`sections/blocks/${blockId}/usage`, {
+			method: 'GET'
+		});
 	}
 });
@@ -25,15 +25,24 @@ export default Ember.Service.extend({
 
 		return this.get('ajax').request(url, {
 			method: "POST"
+		}).then((doc) => {
+			let data = this.get('store').normalize('document', doc);
+			return this.get('store').push(data);
 		});
 	},
 
-	importSavedTemplate: function (folderId, templateId, docName) {
+	// values fill in the variables of the template, when it has any
+	importSavedTemplate: function (folderId, templateId, docName, values) {
 		let url = `templates/${templateId}/folder/${folderId}?type=saved`;
+		let data = docName;
+
+		if (is.not.undefined(values)) {
+			data = JSON.stringify({ title: docName, values: values });
+		}
 
 		return this.get('ajax').request(url, {
 			method: 'POST',
-			data: docName
+			data: data
 		}).then((doc) => {
 			let data = this.get('store').normalize('document', doc);
 			return this.get('store').push(data);			
@@ -66,6 +75,39 @@ export default Ember.Service.extend({
 		});
 	},
 
+	getVariables(templateId) {
+		return this.get('ajax').request(`templates/${templateId}/variables`, {
+			method: 'GET'
+		}).then((response) => {
+			if (is.not.array(response)) {
+				response = [];
+			}
+
+			return response;
+		});
+	},
+
+	setVariables(templateId, variables) {
+		return this.get('ajax').request(`templates/${templateId}/variables`, {
+			method: 'PUT',
+			data: JSON.stringify(variables)
+		});
+	},
+
+	// Returns the template directory of the installation and the template space of the organization.
+	getSource() {
+		return this.get('ajax').request(`templates/source`, {
+			method: 'GET'
+		});
+	},
+
+	setSource(source) {
+		return this.get('ajax').request(`templates/source`, {
+			method: 'PUT',
+			data: JSON.stringify(source)
+		});
+	},
+
 	saveAsTemplate(documentId, name, excerpt) {
 		let payload = {
 			DocumentID: documentId,
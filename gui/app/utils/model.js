@@ -192,6 +192,8 @@ let PageMetaModel = BaseModel.extend({
 	rawBody: "",
 	config: {},
 	externalSource: false,
+	connectionId: "",
+	refreshInterval: 0,
 });
 
 let SectionModel = BaseModel.extend({